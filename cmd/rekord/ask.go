@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/exler/rekord/internal/ask"
+)
+
+// runAsk implements the ask subcommand, which answers a question about the
+// saved transcripts with a language model, from the passages most similar
+// to the question
+func runAsk(args []string) int {
+	fs := flag.NewFlagSet("ask", flag.ExitOnError)
+	dir := fs.String("dir", ".", "Directory of saved transcripts to answer from")
+	passages := fs.Int("passages", 8, "Number of transcript passages given to the model")
+	indexPath := fs.String("index", ask.DefaultIndexPath(), "Embeddings index database, updated before every question")
+	apiURL := fs.String("url", ask.DefaultURL, "OpenAI-compatible API serving the models (default: a local Ollama); the key is read from "+ask.APIKeyEnv)
+	embedModel := fs.String("embed-model", ask.DefaultEmbedModel, "Embedding model")
+	chatModel := fs.String("model", ask.DefaultChatModel, "Chat model that answers")
+	showSources := fs.Bool("sources", false, "Print the passages the answer is based on")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rekord ask [flags] <question>\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	question := strings.Join(fs.Args(), " ")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client := ask.NewClient(ask.Config{URL: *apiURL, EmbedModel: *embedModel, ChatModel: *chatModel})
+	index, err := ask.Open(*indexPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening embeddings index: %v\n", err)
+		return 1
+	}
+	defer index.Close()
+
+	// Embedding a large archive for the first time takes a while
+	_, err = index.Update(ctx, *dir, client, func(path string) {
+		fmt.Fprintf(os.Stderr, "Embedding %s\n", filepath.Base(path))
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error indexing transcripts: %v\n", err)
+		return 1
+	}
+
+	vectors, err := client.Embed(ctx, []string{question})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	found, err := index.Nearest(*dir, vectors[0], *passages)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error searching transcripts: %v\n", err)
+		return 1
+	}
+	if len(found) == 0 {
+		fmt.Printf("No transcripts in %s to answer from\n", *dir)
+		return 1
+	}
+
+	answer, err := client.Answer(ctx, question, found)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Println(answer)
+
+	// The answer cites passages by number
+	fmt.Println()
+	fmt.Println("Sources:")
+	for i, p := range found {
+		fmt.Printf("  [%d] %s\n", i+1, ask.Source(p))
+		if *showSources {
+			for line := range strings.SplitSeq(p.Text, "\n") {
+				fmt.Printf("      %s\n", line)
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/exler/rekord/internal/audio"
+	"github.com/exler/rekord/internal/summary"
+	"github.com/exler/rekord/internal/transcriber"
+	"github.com/exler/rekord/internal/transcript"
+	"github.com/exler/rekord/internal/ui"
+	"github.com/exler/rekord/pkg/rekord"
+)
+
+// mediaExtensions are the audio and video files batch transcribes
+var mediaExtensions = []string{
+	".wav", ".mp3", ".m4a", ".aac", ".flac", ".ogg", ".oga", ".opus", ".wma",
+	".mp4", ".m4v", ".mkv", ".mov", ".webm", ".avi",
+}
+
+// isMediaFile reports whether path looks like an audio or video file
+func isMediaFile(path string) bool {
+	return slices.Contains(mediaExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+// runBatch implements the transcribe (formerly batch) subcommand, which
+// transcribes every audio and video file in a directory and writes the
+// transcripts next to them
+func runBatch(args []string) int {
+	fs := flag.NewFlagSet("transcribe", flag.ExitOnError)
+	jobs := fs.Int("jobs", 2, "Files to transcribe in parallel")
+	force := fs.Bool("force", false, "Transcribe files again that already have a transcript")
+	fs.StringVar(&modelPath, "model", modelPath, "Path to the whisper model file")
+	fs.StringVar(&backendName, "backend", backendName, "Transcription backend: cli, cgo, openai, deepgram or fake")
+	fs.StringVar(&serverAddr, "server", serverAddr, "Transcribe on a rekord model server (host:port, or auto)")
+	fs.StringVar(&serverKey, "server-key", serverKey, "API key of the -server (default $REKORD_API_KEY)")
+	fs.DurationVar(&serverWait, "server-wait", serverWait, "How long a chunk may wait for a -server busy with other clients")
+	fs.StringVar(&language, "language", language, "Spoken language code passed to whisper")
+	fs.IntVar(&whisperThreads, "whisper-threads", 0, "Threads per whisper process (0 = whisper default)")
+	fs.BoolVar(&exportSRT, "srt", false, "Also write SRT subtitles next to each file")
+	fs.BoolVar(&exportMarkdown, "markdown", false, "Also write Markdown next to each file")
+	fs.BoolVar(&exportJSON, "json", false, "Also write JSON next to each file")
+	fs.Func("json-format", "Schema of the JSON export: rekord (default), aws or gcp", parseJSONFormat)
+	fs.BoolVar(&exportHTML, "html", false, "Also write an HTML page next to each file that plays it, seeking to a segment when it is clicked")
+	fs.BoolVar(&exportDOCX, "docx", false, "Also write a Word document formatted as meeting minutes next to each file")
+	fs.BoolVar(&exportPDF, "pdf", false, "Also write a PDF document formatted as meeting minutes next to each file")
+	fs.BoolVar(&embedSubtitles, "embed-srt", false, "Remux the SRT subtitles into each video file, replacing its subtitle tracks (implies -srt)")
+	addTimeFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rekord transcribe [flags] <directory>\n\n")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&configFile, "config", configFile, "Config file with the defaults of the flags")
+	fs.Parse(args)
+
+	if err := applyConfig(fs); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	// Batch transcripts are timed from the start of each file
+	timeFormat.Mode = transcriber.Elapsed
+
+	entries, err := os.ReadDir(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading directory: %v\n", err)
+		return 1
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && isMediaFile(e.Name()) {
+			files = append(files, filepath.Join(fs.Arg(0), e.Name()))
+		}
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "No audio or video files in %s\n", fs.Arg(0))
+		return 1
+	}
+
+	backend, err := newBackend()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating transcription backend: %v\n", err)
+		return 1
+	}
+	defer backend.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Show a progress view on a terminal and plain lines otherwise
+	var report func(ui.BatchFileMsg)
+	var program *tea.Program
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		program = tea.NewProgram(ui.NewBatch(files, cancel))
+		report = func(msg ui.BatchFileMsg) { program.Send(msg) }
+	} else {
+		report = func(msg ui.BatchFileMsg) {
+			switch {
+			case !msg.Done:
+			case msg.Err != nil:
+				fmt.Printf("✗ %s: %v\n", files[msg.Index], msg.Err)
+			case msg.Skipped:
+				fmt.Printf("- %s: already transcribed\n", files[msg.Index])
+			default:
+				fmt.Printf("✓ %s → %s\n", files[msg.Index], msg.Output)
+			}
+		}
+	}
+
+	var failed atomic.Bool
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for range max(*jobs, 1) {
+		wg.Go(func() {
+			for i := range work {
+				out, skipped, err := transcribeToFile(ctx, backend, files[i], *force, func(p float64) {
+					report(ui.BatchFileMsg{Index: i, Progress: p})
+				})
+				if err != nil {
+					failed.Store(true)
+				}
+				report(ui.BatchFileMsg{Index: i, Done: true, Skipped: skipped, Output: out, Err: err})
+			}
+		})
+	}
+	go func() {
+		defer close(work)
+		for i := range files {
+			select {
+			case work <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	if program != nil {
+		go func() {
+			wg.Wait()
+			program.Quit()
+		}()
+		if _, err := program.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
+		}
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "Batch cancelled")
+		return 1
+	}
+	if failed.Load() {
+		return 1
+	}
+	return 0
+}
+
+// transcriptFor returns where the transcript of the media file at path is
+// written
+func transcriptFor(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".txt"
+}
+
+// transcribeToFile transcribes the media file at path and writes the
+// transcript, plus the SRT, Markdown, JSON, HTML, DOCX and PDF exports if
+// enabled, next to it. It returns the transcript path, or skipped if a
+// transcript already exists and force is not set. progress is called with
+// the fraction transcribed.
+func transcribeToFile(ctx context.Context, backend transcriber.Backend, path string, force bool, progress func(float64)) (string, bool, error) {
+	out := transcriptFor(path)
+	if _, err := os.Stat(out); err == nil && !force {
+		return out, true, nil
+	}
+
+	segments, err := transcribeFile(ctx, backend, path, progress)
+	if err != nil {
+		return "", false, err
+	}
+	var text strings.Builder
+	for _, seg := range segments {
+		text.WriteString(seg.Text + "\n")
+	}
+	sum := summarizeWith(summary.Extractive{}, text.String())
+	if err := writeFileTranscript(out, path, sum, segments); err != nil {
+		return "", false, err
+	}
+	if exportJSON {
+		if err := saveJSON(out, segments); err != nil {
+			return "", false, err
+		}
+	}
+	if exportDOCX || exportPDF {
+		metadata := []transcript.Field{
+			{Label: "Source", Value: filepath.Base(path)},
+			{Label: "Duration", Value: recordedDuration(segments).String()},
+			{Label: "Model", Value: filepath.Base(modelPath)},
+		}
+		if err := saveDocuments(out, filepath.Base(path), metadata, segments); err != nil {
+			return "", false, err
+		}
+	}
+	if exportSRT || exportMarkdown || exportHTML || embedSubtitles {
+		chapters := chapters(segments, nil)
+		if exportSRT || embedSubtitles {
+			if err := saveSRT(out, segments, chapters); err != nil {
+				return "", false, err
+			}
+		}
+		if exportMarkdown {
+			if err := saveMarkdown(out, sum, segments, chapters); err != nil {
+				return "", false, err
+			}
+		}
+		if exportHTML {
+			if err := saveHTML(out, path, segments, chapters); err != nil {
+				return "", false, err
+			}
+		}
+		if embedSubtitles {
+			if err := embedSRT(ctx, path, strings.TrimSuffix(out, ".txt")+".srt"); err != nil {
+				return "", false, err
+			}
+		}
+	}
+	return out, false, nil
+}
+
+// subtitleCodecs maps the video containers subtitles can be remuxed into
+// to the subtitle codec they take
+var subtitleCodecs = map[string]string{
+	".mp4":  "mov_text",
+	".m4v":  "mov_text",
+	".mov":  "mov_text",
+	".mkv":  "srt",
+	".webm": "webvtt",
+}
+
+// embedSRT remuxes the subtitles at srt into the video file at path as its
+// subtitle track, without re-encoding the other streams. Subtitle tracks
+// already in the file are dropped, so transcribing it again replaces the
+// track instead of adding another copy. The video is replaced only once
+// ffmpeg succeeded and keeps its modification time, which the segment
+// times are derived from. Audio files and containers without subtitle
+// support are left alone; players pick up the SRT next to them.
+func embedSRT(ctx context.Context, path, srt string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	codec, ok := subtitleCodecs[ext]
+	if !ok {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("embedding subtitles: %w", err)
+	}
+
+	tmp := strings.TrimSuffix(path, filepath.Ext(path)) + ".rekord-tmp" + filepath.Ext(path)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-nostdin", "-y",
+		"-v", "error",
+		"-i", path,
+		"-i", srt,
+		"-map", "0", "-map", "-0:s", "-map", "1",
+		"-c", "copy", "-c:s", codec,
+		tmp,
+	)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(tmp)
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("embedding subtitles: %w: %s", err, msg)
+		}
+		return fmt.Errorf("embedding subtitles: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("embedding subtitles: %w", err)
+	}
+	if err := os.Chtimes(path, time.Time{}, info.ModTime()); err != nil {
+		return fmt.Errorf("embedding subtitles: %w", err)
+	}
+	return nil
+}
+
+// transcribeFile decodes a media file and transcribes it in chunks cut at
+// quiet points, returning segments timed from the start of the file
+func transcribeFile(ctx context.Context, backend transcriber.Backend, path string, progress func(float64)) ([]transcriber.Segment, error) {
+	samples, err := audio.DecodeFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, errors.New("no audio")
+	}
+
+	// Segments get wall clock times as if the recording ended when the file
+	// was last written
+	start := time.Now()
+	if info, err := os.Stat(path); err == nil {
+		start = info.ModTime()
+	}
+	start = start.Add(-time.Duration(len(samples)) * time.Second / audio.SampleRate)
+
+	var segments []transcriber.Segment
+	for pos := 0; pos < len(samples); {
+		end := min(pos+rekord.MaxChunkSamples, len(samples))
+		if end < len(samples) {
+			end = audio.FindQuietestPoint(samples, max(end-rekord.SilenceSearchSamples, pos+audio.FrameSize), end)
+		}
+		chunk := samples[pos:end]
+
+		chunkCtx, cancel := context.WithTimeout(ctx, transcriber.TimeoutFor(backend, len(chunk)))
+		segs, err := backend.Transcribe(chunkCtx, chunk)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		offset := time.Duration(pos) * time.Second / audio.SampleRate
+		for _, seg := range segs {
+			seg.Shift(offset)
+			seg.Timestamp = start.Add(seg.StartTime)
+			if seg.Text != "" {
+				segments = append(segments, seg)
+			}
+		}
+
+		pos = end
+		progress(float64(pos) / float64(len(samples)))
+	}
+	return segments, nil
+}
+
+// writeFileTranscript writes the transcript of the media file source to path
+func writeFileTranscript(path, source string, sum transcript.Summary, segments []transcriber.Segment) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "Rekord Meeting Transcript\n")
+	fmt.Fprintf(w, "Generated: %s\n", time.Now().Format(time.RFC1123))
+	fmt.Fprintf(w, "Source: %s\n", filepath.Base(source))
+	fmt.Fprintf(w, "Model: %s\n", modelPath)
+	writeSummaryHeader(w, sum)
+	fmt.Fprintf(w, "----------------------------------------\n\n")
+	for _, seg := range segments {
+		fmt.Fprintf(w, "[%s] %s\n", timeFormat.Format(seg), seg.Label())
+	}
+	err = w.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/exler/rekord/internal/transcript"
+)
+
+// runCaptions implements the captions subcommand, which takes speaker names
+// from a meeting platform's captions or chat export for a transcript saved
+// with -json
+func runCaptions(args []string) int {
+	fs := flag.NewFlagSet("captions", flag.ExitOnError)
+	offset := fs.Duration("offset", 0, "Time to add to caption times to match the transcript (default: estimated from the wording)")
+	markdown := fs.Bool("markdown", false, "Print the merged transcript as Markdown instead of JSON")
+	addTimeFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rekord captions [flags] <transcript.json> <captions>\n\n")
+		fmt.Fprintf(fs.Output(), "Captions may be WebVTT or SRT files, a Zoom transcript or a Zoom chat export.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening transcript: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	segments, err := transcript.ReadJSON(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading transcript (expected rekord -json output): %v\n", err)
+		return 1
+	}
+	if len(segments) == 0 {
+		fmt.Fprintf(os.Stderr, "Transcript %s has no segments\n", fs.Arg(0))
+		return 1
+	}
+
+	captions, err := transcript.LoadCaptions(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading captions: %v\n", err)
+		return 1
+	}
+
+	explicit := false
+	fs.Visit(func(f *flag.Flag) {
+		explicit = explicit || f.Name == "offset"
+	})
+	if !explicit {
+		estimated, ok := transcript.CaptionOffset(segments, captions)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: no captions match the transcript's wording; set -offset\n")
+			return 1
+		}
+		*offset = estimated
+		fmt.Fprintf(os.Stderr, "Captions offset: %v\n", estimated)
+	}
+
+	assigned := transcript.AssignSpeakers(segments, captions, *offset)
+	fmt.Fprintf(os.Stderr, "Assigned speakers to %d of %d segments\n", assigned, len(segments))
+
+	if *markdown {
+		title := "Meeting Transcript " + timeFormat.Stamp(segments[0].Timestamp)
+		err = transcript.WriteMarkdown(os.Stdout, title, transcript.Summary{}, segments, nil, timeFormat)
+	} else {
+		err = transcript.WriteJSON(os.Stdout, segments)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing transcript: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// command is a subcommand of rekord, run with the arguments after its name
+type command struct {
+	name    string
+	aliases []string // former or alternative names
+	summary string
+	// run is nil for record, whose flags are the global ones so they keep
+	// working without naming the command
+	run func(args []string) int
+}
+
+// commands lists the subcommands in the order help shows them
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "record", summary: "Record and transcribe system audio and the microphone live (the default)"},
+		{name: "transcribe", aliases: []string{"batch"}, summary: "Transcribe the audio and video files in a directory", run: runBatch},
+		{name: "watch", summary: "Transcribe audio and video files as they appear in a directory", run: runWatch},
+		{name: "models", summary: "List installed whisper models and download new ones", run: runModels},
+		{name: "devices", summary: "List the audio devices that can be captured", run: runDevices},
+		{name: "test-audio", summary: "Record a few seconds from each device to check the setup", run: runTestAudio},
+		{name: "setup", summary: "Choose the model, devices and output directory in the setup wizard", run: runSetup},
+		{name: "doctor", summary: "Check the tools, model, devices and disk space and suggest fixes", run: runDoctor},
+		{name: "install-whisper", summary: "Install whisper.cpp, and the model if it is missing", run: runInstallWhisper},
+		{name: "serve", aliases: []string{"serve-model"}, summary: "Share this machine's whisper model with other rekord instances", run: runServeModel},
+		{name: "ctl", summary: "Send a command to a running recorder", run: runCtl},
+		{name: "status", summary: "Print the state of a running recorder for status bars", run: runStatus},
+		{name: "history", summary: "List the sessions recorded with -store or print one", run: runHistory},
+		{name: "search", summary: "Find saved transcripts mentioning all words of a query", run: runSearch},
+		{name: "ask", summary: "Answer a question about the saved transcripts with a language model", run: runAsk},
+		{name: "summarize", summary: "Print key points and action items of a transcript", run: runSummarize},
+		{name: "captions", summary: "Take speaker names from meeting captions for a transcript", run: runCaptions},
+		{name: "stats", summary: "Summarize pipeline performance from a session log", run: runStats},
+		{name: "version", aliases: []string{"-version", "--version"}, summary: "Print the version, whisper executable and available backends", run: runVersion},
+		{name: "help", summary: "Show the commands, or the flags of one command", run: runHelp},
+	}
+	flag.Usage = usage
+}
+
+// findCommand returns the command called name or one of its aliases
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name || slices.Contains(cmd.aliases, name) {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// usage prints the commands and the flags of record
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: rekord [command] [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun rekord help <command> for the flags of a command.\n\nFlags of record:\n")
+	flag.PrintDefaults()
+}
+
+// runHelp implements the help subcommand, which lists the commands or shows
+// the flags of one
+func runHelp(args []string) int {
+	if len(args) == 0 {
+		flag.CommandLine.SetOutput(os.Stdout)
+		usage()
+		return 0
+	}
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q, see rekord help\n", args[0])
+		return 2
+	}
+	if cmd.run == nil || cmd.name == "help" {
+		flag.CommandLine.SetOutput(os.Stdout)
+		usage()
+		return 0
+	}
+	fmt.Printf("rekord %s: %s\n\n", cmd.name, cmd.summary)
+	return cmd.run([]string{"-h"})
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/exler/rekord/internal/config"
+)
+
+// applyConfig sets the flags in the -config file that were neither given on
+// the command line of fs nor set by the profile. Flags the command does not
+// define set the defaults of record's flags, which the shared code reads.
+func applyConfig(fs *flag.FlagSet) error {
+	settings, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to read config from %s: %w", configFile, err)
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for _, s := range settings {
+		switch s.Flag {
+		case "config", "profile", "profiles":
+			return fmt.Errorf("config %s: -%s cannot be set in the config file", configFile, s.Flag)
+		}
+		if flag.Lookup(s.Flag) == nil {
+			return fmt.Errorf("config %s: unknown flag -%s", configFile, s.Flag)
+		}
+		if explicit[s.Flag] {
+			continue
+		}
+		set := fs.Set
+		if fs.Lookup(s.Flag) == nil {
+			set = flag.Set
+		}
+		if err := set(s.Flag, s.Value); err != nil {
+			return fmt.Errorf("config %s: invalid value %q for -%s: %w", configFile, s.Value, s.Flag, err)
+		}
+	}
+	return nil
+}
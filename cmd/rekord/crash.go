@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/exler/rekord/internal/crash"
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/stats"
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// recentChunks is how many of the last chunk timings a crash report lists
+const recentChunks = 10
+
+// setupCrashReports adds the configuration and the recent chunk timings to
+// crash reports
+func setupCrashReports() {
+	crash.AddSection("config.txt", writeCrashConfig)
+	crash.AddSection("chunks.txt", writeCrashChunks)
+}
+
+// redacted replaces the values of secret flags in crash reports
+const redacted = "<redacted>"
+
+// writeCrashConfig writes the version, the command line, the value of every
+// flag and the whisper executable in use. Crash reports are attached to
+// public bug reports, so the values of secret flags are left out.
+func writeCrashConfig(w io.Writer) error {
+	v, rev, date := buildVersion()
+	fmt.Fprintf(w, "version: %s (commit %s, built %s)\n", v, orUnknown(rev), orUnknown(date))
+	fmt.Fprintf(w, "command: %s\n", strings.Join(redactArgs(os.Args), " "))
+	fmt.Fprintf(w, "whisper: %s\n\nflags:\n", transcriber.FindWhisperExecutable())
+	flag.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if secretFlag(f.Name) && value != "" {
+			value = redacted
+		}
+		fmt.Fprintf(w, "-%s=%s\n", f.Name, value)
+	})
+	return nil
+}
+
+// secretFlag reports whether the flag called name holds a secret such as an
+// API key or token
+func secretFlag(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "key") || strings.Contains(name, "token")
+}
+
+// redactArgs returns args with the values of secret flags replaced, given
+// either as -flag=value or as -flag value
+func redactArgs(args []string) []string {
+	out := make([]string, len(args))
+	secretNext := false
+	for i, arg := range args {
+		if secretNext {
+			out[i] = redacted
+			secretNext = false
+			continue
+		}
+		out[i] = arg
+		name, ok := strings.CutPrefix(arg, "-")
+		if !ok || arg == "--" {
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimPrefix(name, "-"), "=")
+		if !secretFlag(name) {
+			continue
+		}
+		if hasValue {
+			out[i] = arg[:strings.Index(arg, "=")+1] + redacted
+		} else {
+			secretNext = true
+		}
+	}
+	return out
+}
+
+// writeCrashChunks writes a summary of the chunk timings in the log and the
+// last few of them
+func writeCrashChunks(w io.Writer) error {
+	path := logging.GetLogPath()
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	timings, err := stats.ParseLog(f)
+	if err != nil {
+		return err
+	}
+	stats.Summarize(timings).Write(w)
+	fmt.Fprintln(w, "\nlast chunks:")
+	for _, t := range timings[max(len(timings)-recentChunks, 0):] {
+		fmt.Fprintf(w, "source=%q audio=%s queue=%s whisper=%s segments=%d failed=%t\n",
+			t.Source, t.Audio, t.Queue, t.Whisper, t.Segments, t.Failed)
+	}
+	return nil
+}
+
+// crashModel records panics in the Update and View of the model it wraps
+// for a crash report. Bubble Tea still recovers from them to restore the
+// terminal, after which crash.ReportCaught writes the report.
+type crashModel struct {
+	tea.Model
+}
+
+func (m crashModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer crash.Capture()
+	model, cmd := m.Model.Update(msg)
+	return crashModel{model}, cmd
+}
+
+func (m crashModel) View() tea.View {
+	defer crash.Capture()
+	return m.Model.View()
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCrashConfigRedactsSecrets(t *testing.T) {
+	const secret = "s3cret-api-key"
+	oldArgs, oldKey := os.Args, serverKey
+	t.Cleanup(func() { os.Args, serverKey = oldArgs, oldKey })
+
+	if err := flag.Set("server-key", secret); err != nil {
+		t.Fatal(err)
+	}
+	os.Args = []string{"rekord", "-server", "gpu-box:7777", "-server-key", secret, "--server-key=" + secret, "-language", "de"}
+
+	var b strings.Builder
+	if err := writeCrashConfig(&b); err != nil {
+		t.Fatalf("writeCrashConfig: %v", err)
+	}
+	config := b.String()
+	if strings.Contains(config, secret) {
+		t.Errorf("config.txt contains the -server-key value:\n%s", config)
+	}
+	for _, want := range []string{
+		"command: rekord -server gpu-box:7777 -server-key <redacted> --server-key=<redacted> -language de\n",
+		"-server-key=<redacted>\n",
+		"-server=",
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("config.txt lacks %q:\n%s", want, config)
+		}
+	}
+}
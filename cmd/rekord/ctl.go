@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/exler/rekord/internal/control"
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/ui"
+)
+
+// startControl serves the control socket so a running instance can be driven
+// from outside the terminal
+func (a *App) startControl(path string) (*control.Server, error) {
+	server := control.NewServer()
+	server.Handle("start", func([]string) (any, error) {
+		if a.session.Recording() {
+			return nil, errors.New("already recording")
+		}
+		a.program.Send(ui.StartRecordingMsg{})
+		return nil, nil
+	})
+	server.Handle("stop", func([]string) (any, error) {
+		if !a.session.Recording() {
+			return nil, errors.New("not recording")
+		}
+		a.program.Send(ui.StopRecordingMsg{})
+		return nil, nil
+	})
+	server.Handle("toggle", func([]string) (any, error) {
+		if a.session.Recording() {
+			a.program.Send(ui.StopRecordingMsg{})
+		} else {
+			a.program.Send(ui.StartRecordingMsg{})
+		}
+		return nil, nil
+	})
+
+	server.Handle("save", func(args []string) (any, error) {
+		filename := fmt.Sprintf("transcript_%s.txt", time.Now().Format("2006-01-02_15-04-05"))
+		if len(args) > 0 {
+			filename = args[0]
+		}
+		path, err := a.writeTranscript(filename)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"path": path}, nil
+	})
+	server.Handle("status", func([]string) (any, error) {
+		return a.status(), nil
+	})
+	server.Handle("segments", func(args []string) (any, error) {
+		segments := a.session.Segments()
+		if len(args) > 0 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid segment count %q", args[0])
+			}
+			segments = segments[max(len(segments)-n, 0):]
+		}
+		out := make([]control.Segment, len(segments))
+		for i, seg := range segments {
+			out[i] = control.Segment{Time: seg.Timestamp, Offset: seg.StartTime, Source: seg.Source, Text: seg.Text}
+		}
+		return out, nil
+	})
+
+	server.Handle("duplicate", func(args []string) (any, error) {
+		if len(args) == 0 {
+			dup := a.pendingDuplicate()
+			if dup == nil {
+				return nil, errors.New("no possible duplicate to resolve")
+			}
+			return control.Duplicate{Path: dup.Path, Other: dup.Other, Similarity: dup.Similarity}, nil
+		}
+		notice, err := a.resolveDuplicate(ui.DuplicateAction(args[0]))
+		if err != nil {
+			return nil, err
+		}
+		a.program.Send(ui.DuplicateResolvedMsg{Text: notice})
+		return map[string]string{"result": notice}, nil
+	})
+
+	if err := server.Listen(path); err != nil {
+		return nil, err
+	}
+	logging.Info("Control socket listening on %s", path)
+	return server, nil
+}
+
+// status reports the current recording state
+func (a *App) status() control.Status {
+	meta := a.session.Metadata()
+	device, _ := a.devices()
+	st := control.Status{
+		Recording: meta.Recording,
+		Segments:  a.session.Len(),
+		Device:    device,
+		Model:     filepath.Base(meta.Model),
+	}
+	if st.Recording {
+		st.Elapsed = time.Since(meta.Started)
+	}
+	return st
+}
+
+// runCtl implements the ctl subcommand, which sends a command to a running
+// instance
+func runCtl(args []string) int {
+	fs := flag.NewFlagSet("ctl", flag.ExitOnError)
+	socket := fs.String("socket", control.DefaultSocketPath(), "Control socket of the running instance")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rekord ctl [flags] start|stop|toggle|save [file]|status|segments [n]|duplicate [merge|discard|discard-other|keep]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	resp, err := control.Send(*socket, fs.Arg(0), fs.Args()[1:]...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if !resp.OK {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", resp.Error)
+		return 1
+	}
+	if len(resp.Data) > 0 {
+		fmt.Println(string(resp.Data))
+	}
+	return 0
+}
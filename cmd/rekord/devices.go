@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/exler/rekord/internal/audio"
+)
+
+// runDevices implements the devices subcommand, which lists the system audio
+// and input sources rekord can capture
+func runDevices(args []string) int {
+	fs := flag.NewFlagSet("devices", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rekord devices\n\nLists the sources to pass to -device and -mic; * marks the defaults.\n")
+	}
+	fs.Parse(args)
+
+	sources, err := audio.ListMonitorSources()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing audio devices: %v\n", err)
+		return 1
+	}
+	if len(sources) == 0 {
+		fmt.Println("No audio devices found")
+		return 0
+	}
+	printDevices(os.Stdout, sources)
+	return 0
+}
+
+// printDevices writes sources as a table, marking the default system audio
+// monitor and input with *
+func printDevices(w io.Writer, sources []audio.MonitorSource) {
+	defaultMonitor, _ := audio.GetDefaultMonitorSource()
+	defaultInput, _ := audio.GetDefaultInputSource()
+
+	fmt.Fprintf(w, "  %-8s %-12s %-40s %s\n", "TYPE", "FORMAT", "DESCRIPTION", "NAME")
+	for _, s := range sources {
+		marker := " "
+		if s.Name == defaultMonitor || s.Name == defaultInput {
+			marker = "*"
+		}
+		kind := "input"
+		if s.IsMonitor {
+			kind = "monitor"
+		}
+		format := "-"
+		if s.SampleRate > 0 {
+			format = fmt.Sprintf("%dHz %dch", s.SampleRate, s.Channels)
+		}
+		description := s.Description
+		if description == s.Name {
+			description = "-"
+		}
+		fmt.Fprintf(w, "%s %-8s %-12s %-40s %s\n", marker, kind, format, truncateRunes(description, 40), s.Name)
+	}
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with …
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:n-1])) + "…"
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/ui"
+)
+
+// diskCheckInterval is how often free space is checked while recording
+const diskCheckInterval = 30 * time.Second
+
+// lowestFreeSpace returns the directory rekord writes to during a recording
+// with the least free space: the transcript output, the logs, or the
+// temporary directory audio spills to while transcription is behind
+func lowestFreeSpace() (string, uint64, error) {
+	var lowestDir string
+	var lowest uint64
+	for _, dir := range []string{outputDir, logDir, os.TempDir()} {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		free, err := freeSpace(dir)
+		if err != nil {
+			// The log directory may not exist yet, and freeSpace is not
+			// supported everywhere
+			continue
+		}
+		if lowestDir == "" || free < lowest {
+			lowestDir, lowest = dir, free
+		}
+	}
+	if lowestDir == "" {
+		return "", 0, errors.New("free disk space unknown")
+	}
+	return lowestDir, lowest, nil
+}
+
+// checkDiskBeforeRecording refuses to start a recording when a directory
+// rekord writes to is nearly full, and warns when it is getting low
+func (a *App) checkDiskBeforeRecording() error {
+	dir, free, err := lowestFreeSpace()
+	if err != nil {
+		return nil
+	}
+	if free < minFreeSpace {
+		logging.Error("Not starting recording, only %d MB free in %s", free>>20, dir)
+		return fmt.Errorf("only %d MB free in %s, free up space before recording", free>>20, dir)
+	}
+	if free < lowFreeSpace {
+		logging.Warn("Low disk space: %d MB free in %s", free>>20, dir)
+		go a.program.Send(ui.NoticeMsg{Text: fmt.Sprintf("Low disk space: %d MB free in %s", free>>20, dir)})
+	}
+	return nil
+}
+
+// diskGuard checks free space while recording until stop is closed. It warns
+// once when space gets low and stops the recording, keeping the transcript
+// so far, before the disk fills up.
+func (a *App) diskGuard(stop <-chan struct{}) {
+	ticker := time.NewTicker(diskCheckInterval)
+	defer ticker.Stop()
+
+	warned := false
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		dir, free, err := lowestFreeSpace()
+		if err != nil {
+			continue
+		}
+		switch {
+		case free < minFreeSpace:
+			logging.Error("Stopping recording, only %d MB free in %s", free>>20, dir)
+			a.program.Send(ui.StopRecordingMsg{})
+			a.program.Send(ui.NoticeMsg{Text: fmt.Sprintf("Recording stopped: only %d MB free in %s", free>>20, dir)})
+			return
+		case free < lowFreeSpace && !warned:
+			logging.Warn("Low disk space: %d MB free in %s", free>>20, dir)
+			a.program.Send(ui.NoticeMsg{Text: fmt.Sprintf("Low disk space: %d MB free in %s", free>>20, dir)})
+			warned = true
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package main
+
+import "golang.org/x/sys/unix"
+
+// freeSpace returns the bytes available to unprivileged users on the
+// filesystem containing dir. NetBSD has statvfs instead of statfs.
+func freeSpace(dir string) (uint64, error) {
+	var st unix.Statvfs_t
+	if err := unix.Statvfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Frsize), nil
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd || dragonfly || openbsd || netbsd)
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// freeSpace is not supported on the remaining platforms
+func freeSpace(dir string) (uint64, error) {
+	return 0, fmt.Errorf("checking free disk space is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+
+	"github.com/exler/rekord/internal/audio"
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// Free disk space thresholds for the output directory
+const (
+	minFreeSpace = 100 << 20
+	lowFreeSpace = 1 << 30
+)
+
+// doctor collects the results of the pre-flight checks
+type doctor struct {
+	failed int
+	warned int
+}
+
+// pass reports a successful check
+func (d *doctor) pass(name, detail string) {
+	fmt.Printf("✓ %s: %s\n", name, detail)
+}
+
+// warn reports a problem that does not prevent recording
+func (d *doctor) warn(name, problem, fix string) {
+	d.warned++
+	fmt.Printf("! %s: %s\n", name, problem)
+	if fix != "" {
+		fmt.Printf("  → %s\n", fix)
+	}
+}
+
+// fail reports a problem that prevents recording
+func (d *doctor) fail(name, problem, fix string) {
+	d.failed++
+	fmt.Printf("✗ %s: %s\n", name, problem)
+	if fix != "" {
+		fmt.Printf("  → %s\n", fix)
+	}
+}
+
+// runDoctor implements the doctor subcommand, which checks the tools,
+// model, audio devices and disk space rekord needs and suggests fixes
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	fs.StringVar(&modelPath, "model", modelPath, "Path to the whisper model file")
+	fs.StringVar(&deviceName, "device", "", "System audio device to check (default: the default monitor)")
+	fs.StringVar(&micDevice, "mic", "", "Microphone device to check (default: the default input)")
+	fs.BoolVar(&noMic, "no-mic", false, "Skip the microphone check")
+	fs.StringVar(&outputDir, "output", ".", "Output directory for transcripts")
+	fs.StringVar(&configFile, "config", configFile, "Config file with the defaults of the flags")
+	fs.Parse(args)
+
+	if err := applyConfig(fs); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	var d doctor
+	d.checkTools()
+	d.checkModel()
+	d.checkDevices()
+	d.checkDiskSpace()
+
+	fmt.Println()
+	switch {
+	case d.failed > 0:
+		fmt.Printf("%d problem(s) found, rekord will not work until they are fixed\n", d.failed)
+		return 1
+	case d.warned > 0:
+		fmt.Printf("Ready to record, with %d warning(s)\n", d.warned)
+	default:
+		fmt.Println("Ready to record")
+	}
+	return 0
+}
+
+// checkTools looks for the external programs rekord runs
+func (d *doctor) checkTools() {
+	if runtime.GOOS == "darwin" {
+		d.checkDarwin()
+	} else {
+		for _, tool := range audio.Tools {
+			if path, err := exec.LookPath(tool); err == nil {
+				d.pass(tool, path)
+			} else {
+				d.fail(tool, "not found", audio.ToolsHint)
+			}
+		}
+	}
+
+	if path := transcriber.FindWhisperExecutable(); path != "" {
+		d.pass("whisper", path)
+	} else {
+		d.fail("whisper", "whisper.cpp executable not found",
+			"Run rekord install-whisper, or install whisper.cpp yourself and set WHISPER_PATH (not needed with -server or a cloud -backend)")
+	}
+
+	if path, err := exec.LookPath("ffmpeg"); err == nil {
+		d.pass("ffmpeg", path)
+	} else {
+		d.warn("ffmpeg", "not found (optional)", "Install ffmpeg to convert recordings from other tools to WAV for whisper")
+	}
+}
+
+// checkDarwin checks the macOS build tools and permissions
+func (d *doctor) checkDarwin() {
+	if path, err := exec.LookPath("swiftc"); err == nil {
+		d.pass("swiftc", path)
+	} else {
+		d.fail("swiftc", "not found", "Install the Xcode command line tools: xcode-select --install")
+	}
+	// The permission can only be queried from a signed app bundle
+	d.warn("screen recording", "permission cannot be checked from the command line",
+		"Grant your terminal access in System Settings > Privacy & Security > Screen Recording")
+}
+
+// checkModel checks that the model file exists and is a whisper.cpp model
+func (d *doctor) checkModel() {
+	fix := fmt.Sprintf("Download a model: wget -P %s https://huggingface.co/ggerganov/whisper.cpp/resolve/main/ggml-base.en.bin", transcriber.GetModelsDir())
+	info, err := os.Stat(modelPath)
+	if err != nil {
+		d.fail("model", fmt.Sprintf("%s not found", modelPath), fix)
+		return
+	}
+	if err := transcriber.ValidateModel(modelPath); err != nil {
+		d.fail("model", err.Error(), "The download may be incomplete; delete the file and download it again")
+		return
+	}
+	d.pass("model", fmt.Sprintf("%s (%d MB)", modelPath, info.Size()>>20))
+}
+
+// checkDevices checks that the system audio and microphone sources exist
+func (d *doctor) checkDevices() {
+	if runtime.GOOS == "darwin" {
+		return
+	}
+	sources, err := audio.ListMonitorSources()
+	if err != nil {
+		d.fail("audio devices", err.Error(), "Check that PulseAudio or PipeWire is running: pactl info")
+		return
+	}
+	exists := func(name string) bool {
+		return slices.ContainsFunc(sources, func(s audio.MonitorSource) bool { return s.Name == name })
+	}
+
+	system := deviceName
+	if system == "" {
+		system, err = audio.GetDefaultMonitorSource()
+	}
+	switch {
+	case err != nil:
+		d.fail("system audio", err.Error(), "Select an output device in your sound settings, or pass -device")
+	case !exists(system):
+		d.fail("system audio", fmt.Sprintf("%s not found", system), "List the available sources with: rekord devices")
+	default:
+		d.pass("system audio", system)
+	}
+
+	if noMic {
+		return
+	}
+	mic := micDevice
+	if mic == "" {
+		mic, err = audio.GetDefaultInputSource()
+	}
+	switch {
+	case err != nil:
+		d.warn("microphone", err.Error(), "Connect a microphone, pass -mic, or record system audio only with -no-mic")
+	case !exists(mic):
+		d.warn("microphone", fmt.Sprintf("%s not found", mic), "List the available sources with: rekord devices")
+	default:
+		d.pass("microphone", mic)
+	}
+}
+
+// checkDiskSpace checks the free space in the output directory
+func (d *doctor) checkDiskSpace() {
+	dir, err := filepath.Abs(outputDir)
+	if err != nil {
+		dir = outputDir
+	}
+	free, err := freeSpace(dir)
+	if err != nil {
+		d.warn("disk space", err.Error(), "")
+		return
+	}
+	detail := fmt.Sprintf("%d MB free in %s", free>>20, dir)
+	switch {
+	case free < minFreeSpace:
+		d.fail("disk space", detail, "Free up space or choose another directory with -output")
+	case free < lowFreeSpace:
+		d.warn("disk space", detail, "Transcripts are small, but logs and exports may fill the disk during long sessions")
+	default:
+		d.pass("disk space", detail)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/transcript"
+	"github.com/exler/rekord/internal/ui"
+)
+
+// checkDuplicate looks for another transcript saved during this session
+// that looks like a recording of the same meeting as the one at path, e.g.
+// from a second rekord instance left running. A match is kept until it is
+// resolved with resolveDuplicate and returned.
+func (a *App) checkDuplicate(path, text string, since time.Time) *ui.DuplicateMsg {
+	dup, err := transcript.FindDuplicate(a.dir, filepath.Base(path), text, since)
+	if err != nil {
+		logging.Warn("Duplicate transcript check failed: %v", err)
+		return nil
+	}
+	if dup == nil {
+		return nil
+	}
+
+	logging.Warn("Transcript %s is %.0f%% similar to %s", path, dup.Similarity*100, dup.Path)
+	msg := &ui.DuplicateMsg{Path: path, Other: dup.Path, Similarity: dup.Similarity}
+	a.dupMu.Lock()
+	a.duplicate = msg
+	a.dupMu.Unlock()
+	return msg
+}
+
+// pendingDuplicate returns the possible duplicate waiting to be resolved,
+// or nil if there is none
+func (a *App) pendingDuplicate() *ui.DuplicateMsg {
+	a.dupMu.Lock()
+	defer a.dupMu.Unlock()
+	return a.duplicate
+}
+
+// resolveDuplicate merges, discards or keeps the transcripts of the pending
+// possible duplicate and returns a notice describing the outcome
+func (a *App) resolveDuplicate(action ui.DuplicateAction) (string, error) {
+	a.dupMu.Lock()
+	defer a.dupMu.Unlock()
+	dup := a.duplicate
+	if dup == nil {
+		return "", errors.New("no possible duplicate to resolve")
+	}
+
+	var notice string
+	switch action {
+	case ui.DuplicateMerge:
+		if err := transcript.Merge(dup.Path, dup.Other); err != nil {
+			return "", fmt.Errorf("failed to merge transcripts: %w", err)
+		}
+		notice = fmt.Sprintf("Merged %s into %s", filepath.Base(dup.Other), filepath.Base(dup.Path))
+	case ui.DuplicateDiscard:
+		if err := transcript.Discard(dup.Path); err != nil {
+			return "", fmt.Errorf("failed to discard transcript: %w", err)
+		}
+		notice = "Discarded " + filepath.Base(dup.Path)
+	case ui.DuplicateDiscardOther:
+		if err := transcript.Discard(dup.Other); err != nil {
+			return "", fmt.Errorf("failed to discard transcript: %w", err)
+		}
+		notice = "Discarded " + filepath.Base(dup.Other)
+	case ui.DuplicateKeep:
+		notice = "Kept both transcripts"
+	default:
+		return "", fmt.Errorf("unknown duplicate action %q (merge, discard, discard-other or keep)", action)
+	}
+
+	logging.Info("Possible duplicate %s of %s: %s", dup.Path, dup.Other, notice)
+	a.duplicate = nil
+	return notice, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/exler/rekord/internal/store"
+	"github.com/exler/rekord/internal/transcript"
+)
+
+// runHistory implements the history subcommand, which lists the sessions
+// recorded with -store or prints the transcript of one of them
+func runHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	db := fs.String("db", store.DefaultPath(), "Session database written by rekord -store")
+	limit := fs.Int("limit", 20, "Number of sessions to list")
+	markdown := fs.Bool("markdown", false, "Print the session as Markdown with bookmarks as chapters")
+	addTimeFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rekord history [flags] [session-id]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+	if _, err := os.Stat(*db); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: no session database at %s (record with rekord -store)\n", *db)
+		return 1
+	}
+
+	s, err := store.Open(*db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening session database: %v\n", err)
+		return 1
+	}
+	defer s.Close()
+
+	if fs.NArg() == 0 {
+		return listSessions(s, *limit)
+	}
+
+	id, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid session ID %q\n", fs.Arg(0))
+		return 2
+	}
+	segments, err := s.Segments(id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading session: %v\n", err)
+		return 1
+	}
+	if len(segments) == 0 {
+		fmt.Fprintf(os.Stderr, "Session %d has no segments\n", id)
+		return 1
+	}
+
+	if *markdown {
+		bookmarks, err := s.Bookmarks(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading bookmarks: %v\n", err)
+			return 1
+		}
+		title := "Meeting Transcript " + timeFormat.Stamp(segments[0].Timestamp)
+		transcript.WriteMarkdown(os.Stdout, title, transcript.Summary{}, segments, bookmarks, timeFormat)
+		return 0
+	}
+	for _, seg := range segments {
+		fmt.Printf("[%s] %s\n", timeFormat.Format(seg), seg.Label())
+	}
+	return 0
+}
+
+// listSessions prints the newest sessions, one per line
+func listSessions(s *store.Store, limit int) int {
+	sessions, err := s.Sessions(limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing sessions: %v\n", err)
+		return 1
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No sessions recorded yet")
+		return 0
+	}
+
+	fmt.Printf("%-6s %-16s %-9s %-9s %s\n", "ID", "STARTED", "DURATION", "SEGMENTS", "DEVICE")
+	for _, sess := range sessions {
+		duration := "-"
+		if !sess.Ended.IsZero() {
+			duration = sess.Ended.Sub(sess.Started).Round(time.Second).String()
+		}
+		fmt.Printf("%-6d %-16s %-9s %-9d %s\n", sess.ID, timeFormat.In(sess.Started).Format("2006-01-02 15:04"), duration, sess.Segments, shortenDeviceName(sess.Device))
+	}
+	return 0
+}
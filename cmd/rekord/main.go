@@ -1,94 +1,415 @@
 package main
 
 import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
 
+	"github.com/exler/rekord/internal/alert"
 	"github.com/exler/rekord/internal/audio"
+	"github.com/exler/rekord/internal/config"
+	"github.com/exler/rekord/internal/control"
+	"github.com/exler/rekord/internal/crash"
+	"github.com/exler/rekord/internal/diarize"
+	"github.com/exler/rekord/internal/feed"
 	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/media"
+	"github.com/exler/rekord/internal/metrics"
+	"github.com/exler/rekord/internal/profile"
+	"github.com/exler/rekord/internal/session"
+	"github.com/exler/rekord/internal/speakers"
+	"github.com/exler/rekord/internal/store"
+	"github.com/exler/rekord/internal/summary"
 	"github.com/exler/rekord/internal/transcriber"
+	"github.com/exler/rekord/internal/transcript"
+	"github.com/exler/rekord/internal/translate"
 	"github.com/exler/rekord/internal/ui"
+	"github.com/exler/rekord/pkg/rekord"
 )
 
 var (
-	modelPath  string
-	deviceName string
-	micDevice  string
-	noMic      bool
-	outputDir  string
-	logDir     string
+	modelPath   string
+	deviceName  string
+	micDevice   string
+	noMic       bool
+	outputDir   string
+	logDir      string
+	logLevel    string
+	logFormat   string
+	logMaxSize  int
+	logKeep     int
+	logMaxFiles int
+	feedFile    string
+	feedAddr    string
+	metricsAddr string
+	ctlSocket   string
+	storeDB     bool
+	dbPath      string
+	serverAddr  string
+	serverKey   string
+	serverWait  time.Duration
+	backendName string
+	prompt      string
+	vocabulary  string
+	cloudModel  string
+	profileName string
+	profileFile string
+	configFile  string
+
+	annotationsFile string
+	series          string
+	speakersFile    string
+	overlayFile     string
+	overlayLines    int
+	exportSRT       bool
+	exportMarkdown  bool
+	exportJSON      bool
+	jsonFormat      = transcript.JSONRekord
+	exportDOCX      bool
+	exportPDF       bool
+	embedSubtitles  bool
+	exportHTML      bool
+	autoChapters    bool
+	timeFormat      = transcriber.TimeFormat{Mode: transcriber.WallClock}
+	stereoSplit     bool
+	smartChunks     bool
+	bufferMemory    int
+	bufferSpill     bool
+	language        string
+	hallucinations  string
+	replacements    string
+	watchWords      string
+	notifyWatch     bool
+	pauseMedia      bool
+	excludeApps     string
+	stallTimeout    time.Duration
+	translateTo     string
+	translatorName  string
+	translateURL    string
+	redact          bool
+	minSegmentDBFS  float64
+	diarizeSystem   bool
+	diarizeMax      int
+	diarizeDist     float64
+	talkWarn        float64
+	noAltScreen     bool
+
+	workers        int
+	modelFallback  bool
+	whisperThreads int
+	whisperCPUs    string
+	whisperSlice   string
+	whisperNoGPU   bool
+	whisperGPU     int
+	flashAttn      bool
+	beamSize       int
+	warmup         bool
+
+	workspaces []workspaceSpec
+
+	simulateFile string
 )
 
 func init() {
 	defaultModel := filepath.Join(transcriber.GetModelsDir(), "ggml-base.en.bin")
 	defaultLogDir := filepath.Join(os.TempDir(), "rekord", "logs")
 
+	flag.StringVar(&profileName, "profile", "", "Recording profile from -profiles to take flag values from (pick to choose one from a list, default: the profile scheduled for now)")
+	flag.StringVar(&profileFile, "profiles", profile.DefaultPath(), "File defining the recording profiles")
+	flag.StringVar(&configFile, "config", config.DefaultPath(), "File with the defaults of flags, written by rekord setup")
+	flag.StringVar(&series, "series", "", "Meeting series whose speaker names are remembered in -speakers (default: the -profile name)")
+	flag.StringVar(&speakersFile, "speakers", speakers.DefaultPath(), "File remembering the speaker names of each meeting series")
 	flag.StringVar(&modelPath, "model", defaultModel, "Path to the whisper model file")
 	flag.StringVar(&deviceName, "device", "", "System audio device name (leave empty for default monitor)")
 	flag.StringVar(&micDevice, "mic", "", "Microphone device name (leave empty for default input)")
 	flag.BoolVar(&noMic, "no-mic", false, "Disable microphone capture (system audio only)")
+	flag.StringVar(&simulateFile, "simulate", "", "Replay this WAV file at real-time speed instead of capturing audio devices")
 	flag.StringVar(&outputDir, "output", ".", "Output directory for transcripts")
+	flag.Func("workspace", "Record another session side by side in its own tab, as name=device[,mic] (repeatable; transcripts go to <output>/<name>)", parseWorkspace)
 	flag.StringVar(&logDir, "logdir", defaultLogDir, "Directory for log files")
+	flag.StringVar(&logLevel, "loglevel", "info", "Minimum log level: debug, info, warn or error")
+	flag.StringVar(&logFormat, "log-format", "text", "Log format: text (key=value lines) or json")
+	flag.IntVar(&logMaxSize, "log-max-size", 10, "Rotate the log file once it reaches this many MB (0 = no limit)")
+	flag.IntVar(&logMaxFiles, "log-max-files", 5, "Keep at most this many rotated parts of the log, deleting the oldest (0 = keep all)")
+	flag.IntVar(&logKeep, "log-retention", 14, "Delete log files older than this many days at startup (0 = keep all)")
+	flag.BoolVar(&smartChunks, "smart-chunks", true, "Cut audio chunks at the quietest point near the boundary instead of mid-word")
+	flag.IntVar(&bufferMemory, "buffer-memory", 64, "MB of untranscribed audio to keep in memory per source while transcription falls behind")
+	flag.BoolVar(&bufferSpill, "buffer-spill", true, "Spill audio beyond -buffer-memory to a temporary file instead of dropping the oldest audio")
+	flag.BoolVar(&stereoSplit, "stereo-split", false, "Capture system audio in stereo and transcribe left/right channels as separate speakers")
+	flag.StringVar(&serverAddr, "server", "", "Transcribe on a rekord model server (host:port, or auto to discover one via mDNS)")
+	flag.StringVar(&serverKey, "server-key", os.Getenv("REKORD_API_KEY"), "API key of the -server, required when it runs on another machine (default $REKORD_API_KEY)")
+	flag.DurationVar(&serverWait, "server-wait", transcriber.DefaultServerWait, "How long a chunk may wait for a -server busy with other clients, on top of the time to transcribe it")
+	flag.StringVar(&backendName, "backend", "cli", "Transcription backend: cli (whisper-cli process), cgo (in-process whisper.cpp bindings), openai or deepgram (cloud APIs, keys from OPENAI_API_KEY/DEEPGRAM_API_KEY), or fake (canned phrases for tests)")
+	flag.StringVar(&cloudModel, "cloud-model", "", "Model name for the openai or deepgram backends (default whisper-1 or nova-2)")
+	flag.StringVar(&prompt, "prompt", "", "Initial prompt for whisper, e.g. a sentence using the meeting's jargon")
+	flag.StringVar(&vocabulary, "vocabulary", "", "File of domain terms (product and attendee names), one per line, added to the initial prompt")
+	flag.StringVar(&language, "language", "en", "Spoken language code passed to whisper")
+	flag.StringVar(&hallucinations, "hallucinations", "", "File of extra phrases to drop as whisper hallucinations, one per line ([xx] prefix for a language)")
+	flag.StringVar(&replacements, "replacements", "", "File of \"regex => replacement\" rules fixing systematic mis-transcriptions, one per line")
+	flag.BoolVar(&redact, "redact", false, "Mask emails, phone numbers, card numbers and profanity before segments are shown or saved")
+	flag.Float64Var(&minSegmentDBFS, "min-segment-dbfs", transcriber.DefaultMinSegmentDBFS, "Drop segments whose audio is quieter than this RMS level in dBFS")
+	flag.BoolVar(&diarizeSystem, "diarize", false, "Label the system audio's segments Speaker 1, Speaker 2, ... by the voice that spoke them")
+	flag.IntVar(&diarizeMax, "diarize-speakers", 0, "Most speakers -diarize tells apart, e.g. the number of remote participants (0 = no limit)")
+	flag.Float64Var(&diarizeDist, "diarize-threshold", diarize.DefaultThreshold, "Voice distance above which -diarize starts a new speaker (lower tells more speakers apart)")
+	flag.Float64Var(&talkWarn, "talk-warn", 0, "Warn when you have talked more than this percentage of the time (e.g. 60, 0 to disable)")
+	flag.BoolVar(&noAltScreen, "no-altscreen", false, "Render a compact UI inline (status line and the last transcript lines) instead of full screen, e.g. in a small tmux pane")
+	flag.StringVar(&annotationsFile, "annotations", "", "CSV file of time,label annotations to import into the session")
+	flag.BoolVar(&storeDB, "store", false, "Record sessions, segments and bookmarks in a SQLite database for rekord history")
+	flag.StringVar(&dbPath, "db", store.DefaultPath(), "SQLite database used by -store")
+	flag.BoolVar(&exportSRT, "srt", false, "Also save transcripts as SRT subtitles, timed per word when the backend provides word timestamps")
+	flag.BoolVar(&exportMarkdown, "markdown", false, "Also save transcripts as Markdown, with bookmarks and annotations as chapter headings")
+	flag.BoolVar(&exportJSON, "json", false, "Also save transcripts as JSON, with the times and source of each segment")
+	flag.Func("json-format", "Schema of the -json export: rekord (default), aws (Amazon Transcribe) or gcp (Google Speech-to-Text)", parseJSONFormat)
+	flag.BoolVar(&exportDOCX, "docx", false, "Also save transcripts as Word documents formatted as meeting minutes")
+	flag.BoolVar(&exportPDF, "pdf", false, "Also save transcripts as PDF documents formatted as meeting minutes")
+	flag.BoolVar(&autoChapters, "chapters", false, "Group the Markdown and SRT exports into topical chapters with generated headings")
+	flag.Func("timestamps", "How segment times are shown and exported: wall (time of day, default) or elapsed (offset into the recording)", func(s string) (err error) {
+		timeFormat.Mode, err = transcriber.ParseTimeMode(s)
+		return err
+	})
+	addTimeFlags(flag.CommandLine)
+	flag.StringVar(&watchWords, "watch", "", "Comma-separated watch-words (e.g. pricing,deadline,your name) to highlight when spoken")
+	flag.BoolVar(&notifyWatch, "notify", false, "Send a desktop notification when a watch-word is spoken")
+	flag.DurationVar(&stallTimeout, "stall-timeout", 10*time.Second, "Restart an audio source that delivers no audio for this long while recording (0 to disable)")
+	flag.StringVar(&excludeApps, "exclude-apps", "", "Comma-separated applications (e.g. Spotify,firefox) whose audio is kept out of the system audio capture")
+	flag.BoolVar(&pauseMedia, "pause-media", false, "Pause playing media players (via MPRIS and playerctl) while recording and resume them after")
+	flag.StringVar(&translateTo, "translate-to", "", "Show a live translation of the transcript into this language (e.g. de)")
+	flag.StringVar(&translatorName, "translator", "libretranslate", "Translation service for -translate-to: libretranslate or deepl")
+	flag.StringVar(&translateURL, "translate-url", translate.DefaultLibreTranslateURL, "LibreTranslate server URL")
+	flag.StringVar(&ctlSocket, "control-socket", control.DefaultSocketPath(), "Unix socket for rekord ctl and scripts (empty to disable)")
+	flag.StringVar(&feedFile, "feed-file", "", "Append finalized segments to this file as they arrive")
+	flag.StringVar(&feedAddr, "feed-addr", "", "Stream finalized segments to TCP clients on this address (e.g. localhost:7070)")
+	flag.StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics of the capture and transcription pipeline at /metrics on this address (e.g. localhost:9477)")
+	flag.StringVar(&overlayFile, "overlay-file", "", "Keep the latest transcript lines in this file or named pipe for OBS text sources and status bars")
+	flag.IntVar(&overlayLines, "overlay-lines", 2, "Transcript lines shown in -overlay-file")
+	flag.IntVar(&workers, "workers", 1, "Chunks to transcribe in parallel, e.g. 2 on a machine with many cores when one whisper process cannot keep up")
+	flag.BoolVar(&modelFallback, "model-fallback", true, "Switch to a smaller installed model (large, medium, small, base, tiny) when transcription keeps falling behind")
+	flag.IntVar(&whisperThreads, "whisper-threads", 0, "Threads per whisper process (0 = match pinned CPUs or whisper default)")
+	flag.StringVar(&whisperCPUs, "whisper-cpus", "auto", "CPUs to pin whisper to, e.g. 4-7 (auto = efficiency cores if detected, none = no pinning)")
+	flag.StringVar(&whisperSlice, "whisper-slice", "", "Run whisper in this systemd user slice, e.g. background.slice")
+	flag.BoolVar(&whisperNoGPU, "whisper-no-gpu", false, "Run whisper on the CPU even if it was built with GPU support")
+	flag.IntVar(&whisperGPU, "whisper-gpu", 0, "Index of the GPU whisper should use")
+	flag.BoolVar(&flashAttn, "whisper-flash-attn", false, "Enable whisper flash attention (faster on most GPUs)")
+	flag.IntVar(&beamSize, "whisper-beam-size", 0, "Whisper beam search width (0 = whisper default, lower is faster)")
+	flag.BoolVar(&warmup, "warmup", true, "Run a short warm-up transcription at startup and report baseline latency")
 }
 
-// App holds the application state
+// talkTimeInterval is how often the talk time is updated while recording
+const talkTimeInterval = 5 * time.Second
+
+// Transcription counts as falling behind once this many results in a row
+// arrive more than modelFallbackLag after their audio was captured. After
+// switching to a smaller model, the backlog cut for the old one is given
+// modelFallbackCooldown to clear before switching again.
+const (
+	modelFallbackLag      = 30 * time.Second
+	modelFallbackChunks   = 3
+	modelFallbackCooldown = 2 * time.Minute
+)
+
+// signalDrainTimeout bounds how long shutting down on SIGTERM or SIGHUP
+// waits for the remaining audio, staying below the 90 seconds systemd and
+// most session managers give before killing the process
+const signalDrainTimeout = 60 * time.Second
+
+// translationBacklog is how many segments may wait for translation before
+// new ones are skipped
+const translationBacklog = 64
+
+// App holds the application state of a recording session
 type App struct {
-	capture     *audio.Capture
-	transcriber *transcriber.Transcriber
-	whisper     *transcriber.WhisperCLI
-	program     *tea.Program
+	// Workspace name, empty without -workspace
+	name string
+
+	// Captured system audio device and microphone, empty without one, and
+	// whether they were picked automatically and should follow the system
+	// defaults if they disappear. Guarded by devicesMu once recording, as
+	// lost sources move to new devices from the capture goroutine; read
+	// them through devices.
+	device        string
+	mic           string
+	defaultDevice bool
+	defaultMic    bool
+	devicesMu     sync.Mutex
+
+	// Samples replayed in place of the devices with -simulate
+	replay []float32
+
+	// Directory transcripts are saved to
+	dir string
+
+	pipeline    *rekord.Session
+	exclusion   *audio.Exclusion // routes -exclude-apps around the capture
+	backend     transcriber.Backend
+	feed        *feed.Feed
+	overlay     *feed.Overlay
+	metrics     *pipelineMetrics // nil without -metrics-addr
+	db          *store.Store
+	watcher     *alert.Watcher
+	translator  translate.Translator
+	toTranslate chan transcriber.Segment
+	program     messenger
 	model       ui.Model
+	meter       *audio.Meter
+	filter      *transcriber.HallucinationFilter
+	corrections transcriber.Replacements
+	summarizer  summary.Summarizer
+	players     media.Players // paused while recording with -pause-media
+
+	running   []runningChunk // chunks being transcribed, oldest first
+	runningMu sync.Mutex
+
+	// Consecutive results that lagged behind and when the model was last
+	// switched for it, only touched by onResult
+	lagging  int
+	fellBack time.Time
 
-	audioBuffer []float32
-	bufferMu    sync.Mutex
-	segments    []transcriber.Segment
+	// Meeting series and the names given to its speakers, applied to the
+	// source labels of new segments
+	series       string
+	speakerNames speakers.Names
+	speakersMu   sync.Mutex
 
-	// Control channels for transcription loop
-	stopTranscription chan struct{}
-	transcriptionDone chan struct{}
+	// Segments, bookmarks and state of the recording, shared with the UI
+	// callbacks and the control socket
+	session *session.SessionStore
+
+	// Cancelled at shutdown to kill running transcriptions
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Closed when the recording stops, ending the disk and talk time checks
+	stopMonitors chan struct{}
+
+	// Closed once the audio left over from the last recording is transcribed
+	drained chan struct{}
+
+	// Number of segments written by the last save, so quitting only saves
+	// again if more arrived
+	saved atomic.Int64
+
+	// Saved transcript that may duplicate another run, until it is merged,
+	// discarded or kept
+	dupMu     sync.Mutex
+	duplicate *ui.DuplicateMsg
 }
 
 func main() {
-	flag.Parse()
+	defer crash.Recover()
+	setupCrashReports()
+
+	// Recording is the default command, so its flags also work without
+	// naming it
+	args := os.Args[1:]
+	if len(args) > 0 {
+		if cmd, ok := findCommand(args[0]); ok {
+			if cmd.run != nil {
+				os.Exit(cmd.run(args[1:]))
+			}
+			args = args[1:]
+		} else if !strings.HasPrefix(args[0], "-") {
+			fmt.Fprintf(os.Stderr, "Unknown command %q, see rekord help\n", args[0])
+			os.Exit(2)
+		}
+	}
+
+	flag.CommandLine.Parse(args)
+	if err := applyProfile(); err != nil {
+		if errors.Is(err, errNoProfile) {
+			os.Exit(0)
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := applyConfig(flag.CommandLine); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Initialize logging first
-	if err := logging.Init(logDir); err != nil {
+	logOpts, err := logOptions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := logging.Init(logDir, logOpts); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: Failed to initialize logging: %v\n", err)
 	}
 	defer logging.Close()
 
 	logging.Info("Rekord starting up")
+	if profileName != "" {
+		logging.Info("Profile: %s", profileName)
+	}
+	if series == "" {
+		series = profileName
+	}
+
+	// On the first run, choose the model, devices and output directory in
+	// the setup wizard before recording with them
+	if needsSetupWizard() {
+		saved, err := runSetupWizard()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !saved {
+			fmt.Println("Setup cancelled. Run rekord setup to start it again, or pass -model to skip it.")
+			os.Exit(0)
+		}
+		if err := applyConfig(flag.CommandLine); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	logging.Info("Model: %s", modelPath)
 	logging.Info("Log directory: %s", logDir)
 
+	// A simulated recording replays the file in place of the devices
+	var replay []float32
+	if simulateFile != "" {
+		replay, err = audio.ReadWAV(simulateFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading -simulate file: %v\n", err)
+			logging.Error("Failed to read simulation file: %v", err)
+			os.Exit(1)
+		}
+		deviceName, noMic = simulateFile, true
+		logging.Info("Simulating capture with %s (%s)", simulateFile,
+			(time.Duration(len(replay)) * time.Second / audio.SampleRate).Round(time.Second))
+	}
+
 	// Get default monitor if no device specified
+	defaultDevice, defaultMic := false, false
 	if deviceName == "" {
 		monitor, err := audio.GetDefaultMonitorSource()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error getting default audio monitor: %v\n", err)
 			fmt.Fprintf(os.Stderr, "Please specify a device with -device flag\n")
 			fmt.Fprintf(os.Stderr, "\nAvailable sources:\n")
-			sources, _ := audio.ListMonitorSources()
-			for _, s := range sources {
-				if s.IsMonitor {
-					fmt.Fprintf(os.Stderr, "  %s (monitor)\n", s.Name)
-				} else if s.IsInput {
-					fmt.Fprintf(os.Stderr, "  %s (input)\n", s.Name)
-				} else {
-					fmt.Fprintf(os.Stderr, "  %s\n", s.Name)
-				}
+			if sources, err := audio.ListMonitorSources(); err == nil {
+				printDevices(os.Stderr, sources)
 			}
 			logging.Error("No default audio monitor found")
 			os.Exit(1)
 		}
 		deviceName = monitor
+		defaultDevice = true
 	}
 	logging.Info("System audio device: %s", deviceName)
 
@@ -101,6 +422,7 @@ func main() {
 			fmt.Fprintf(os.Stderr, "Continuing with system audio only. Use -mic to specify a microphone.\n")
 		} else {
 			micDevice = mic
+			defaultMic = true
 		}
 	}
 
@@ -110,295 +432,1506 @@ func main() {
 		logging.Info("Microphone device: %s", micDevice)
 	}
 
-	// Check model exists
-	if !transcriber.ModelExists(modelPath) {
-		fmt.Fprint(os.Stderr, "Model not found. Please download a Whisper model as per the README instructions.")
-		logging.Error("Model not found: %s", modelPath)
+	// Without whisper.cpp or the model, start anyway and offer to install
+	// them from the setup wizard
+	missing := missingSetup()
+	var backend transcriber.Backend
+	if missing.Whisper || missing.Model != "" {
+		backend, err = newPendingBackend(missing)
+	} else {
+		backend, err = newBackend()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Create whisper CLI wrapper
-	whisper, err := transcriber.NewWhisperCLI(modelPath)
+	initialPrompt, err := buildInitialPrompt()
 	if err != nil {
-		fmt.Fprint(os.Stderr, "Error initializing whisper.cpp. Please ensure whisper-cli is in your PATH.")
-		logging.Error("Whisper initialization failed: %v", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-	logging.Info("Whisper CLI initialized")
+	if _, inProcess := backend.(*transcriber.WhisperCgo); inProcess && workers > 1 {
+		logging.Warn("The cgo backend transcribes one chunk at a time, ignoring -workers %d", workers)
+		fmt.Fprintf(os.Stderr, "Warning: the cgo backend transcribes one chunk at a time, -workers has no effect\n")
+	}
+	prompter, canPrompt := backend.(transcriber.Prompter)
+	if canPrompt {
+		prompter.SetPrompt(initialPrompt)
+	} else if initialPrompt != "" {
+		logging.Warn("Backend does not support an initial prompt, ignoring it")
+		fmt.Fprintf(os.Stderr, "Warning: the selected backend does not support -prompt or -vocabulary\n")
+	}
 
 	// Create application
+	ctx, cancel := context.WithCancel(context.Background())
 	app := &App{
-		whisper:     whisper,
-		audioBuffer: make([]float32, 0, audio.SampleRate*60), // 1 minute buffer
-		segments:    make([]transcriber.Segment, 0),
-	}
-
-	// Create transcriber
-	app.transcriber, err = transcriber.New(transcriber.Config{
-		ModelPath:  modelPath,
-		SampleRate: audio.SampleRate,
-		OnSegment: func(seg transcriber.Segment) {
-			if app.program != nil {
-				app.program.Send(ui.NewSegmentMsg{Segment: seg})
-			}
-		},
-	})
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating transcriber: %v\n", err)
-		logging.Error("Transcriber creation failed: %v", err)
+		device:        deviceName,
+		replay:        replay,
+		defaultDevice: defaultDevice,
+		defaultMic:    defaultMic,
+		dir:           outputDir,
+		ctx:           ctx,
+		cancel:        cancel,
+		backend:       backend,
+		meter:         audio.NewMeter(),
+		summarizer:    summary.Extractive{},
+		session:       session.New(),
+		series:        series,
+	}
+	if app.speakerNames, err = loadSpeakerNames(series); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-
-	// Build device info string for UI
-	deviceInfo := deviceName
-	if micDevice != "" {
-		deviceInfo = fmt.Sprintf("System: %s | Mic: %s", shortenDeviceName(deviceName), shortenDeviceName(micDevice))
+	if !noMic {
+		app.mic = micDevice
 	}
+	app.session.SetModel(modelPath)
 
-	// Create UI model
-	app.model = ui.New(filepath.Base(modelPath), deviceInfo)
-	app.model.SetCallbacks(app.startRecording, app.stopRecording, app.saveTranscript)
+	// Set up hallucination filtering
+	app.filter = transcriber.NewHallucinationFilter(language)
+	app.filter.MinDBFS = minSegmentDBFS
+	if hallucinations != "" {
+		if err := app.filter.LoadPhrases(hallucinations); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading hallucination phrases: %v\n", err)
+			logging.Error("Hallucination phrase import failed: %v", err)
+			os.Exit(1)
+		}
+	}
 
-	// Create and run program
-	app.program = tea.NewProgram(app.model)
+	// Load the correction dictionary
+	if replacements != "" {
+		app.corrections, err = transcriber.LoadReplacements(replacements)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading replacements: %v\n", err)
+			logging.Error("Replacement dictionary import failed: %v", err)
+			os.Exit(1)
+		}
+		logging.Info("Loaded %d replacement rules from %s", len(app.corrections), replacements)
+	}
 
-	logging.Info("Starting TUI")
-	if _, err := app.program.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
-		logging.Error("Program error: %v", err)
+	app.pipeline, err = app.newPipeline()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		logging.Error("Pipeline creation failed: %v", err)
 		os.Exit(1)
 	}
 
-	// Cleanup
-	logging.Info("Shutting down")
-	if app.capture != nil {
-		app.capture.Close()
+	// Import annotations from other tools
+	if annotationsFile != "" {
+		annotations, err := transcript.LoadAnnotationsCSV(annotationsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading annotations: %v\n", err)
+			logging.Error("Annotation import failed: %v", err)
+			os.Exit(1)
+		}
+		app.session.SetMarkers(annotations)
+		logging.Info("Imported %d annotations from %s", len(annotations), annotationsFile)
 	}
-	app.whisper.Close()
-}
 
-// shortenDeviceName shortens a device name for display
-func shortenDeviceName(name string) string {
-	// Remove common prefixes for cleaner display
-	name = strings.TrimPrefix(name, "alsa_output.")
-	name = strings.TrimPrefix(name, "alsa_input.")
-	name = strings.TrimSuffix(name, ".monitor")
+	// Open the session database
+	if storeDB {
+		app.db, err = store.Open(dbPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening session database: %v\n", err)
+			logging.Error("Session database open failed: %v", err)
+			os.Exit(1)
+		}
+		logging.Info("Storing sessions in %s", dbPath)
+	}
 
-	// Truncate if too long
-	if len(name) > 30 {
-		name = name[:27] + "..."
+	// Create segment feed for external consumers
+	if feedFile != "" || feedAddr != "" {
+		app.feed, err = feed.New(feed.Config{FilePath: feedFile, Addr: feedAddr, TimeFormat: timeFormat})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating segment feed: %v\n", err)
+			logging.Error("Feed creation failed: %v", err)
+			os.Exit(1)
+		}
+		logging.Info("Segment feed enabled (file: %q, addr: %q)", feedFile, feedAddr)
+	}
+	if overlayFile != "" {
+		app.overlay, err = feed.NewOverlay(overlayFile, overlayLines)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating caption overlay: %v\n", err)
+			logging.Error("Overlay creation failed: %v", err)
+			os.Exit(1)
+		}
+		logging.Info("Caption overlay enabled (%s, %d lines)", overlayFile, overlayLines)
 	}
-	return name
-}
 
-// startRecording starts audio capture
-func (a *App) startRecording() error {
-	logging.Info("Starting recording")
+	// Set up watch-word alerts
+	app.watcher = alert.NewWatcher(alert.ParseWords(watchWords))
+
+	// Set up live translation
+	if translateTo != "" {
+		app.translator, err = translate.New(translate.Config{Provider: translatorName, URL: translateURL})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating translator: %v\n", err)
+			logging.Error("Translator creation failed: %v", err)
+			os.Exit(1)
+		}
+		app.toTranslate = make(chan transcriber.Segment, translationBacklog)
+		logging.Info("Translating segments to %s via %s", translateTo, translatorName)
+	}
 
-	// Build list of devices to capture
-	devices := []string{deviceName}
-	if micDevice != "" && !noMic {
-		devices = append(devices, micDevice)
+	app.model = app.newModel(missing, initialPrompt)
+
+	var registry *metrics.Registry
+	if metricsAddr != "" {
+		registry = metrics.NewRegistry()
+		app.metrics = newPipelineMetrics(registry)
 	}
 
-	var err error
-	a.capture, err = audio.NewMultiCapture(devices, a.onAudioData)
-	if err != nil {
-		logging.Error("Failed to create audio capture: %v", err)
-		return fmt.Errorf("failed to create audio capture: %w", err)
+	// Further workspaces record their own devices into separate sessions
+	apps := []*App{app}
+	for _, spec := range workspaces {
+		w, err := app.workspace(spec, missing, initialPrompt)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error setting up workspace %s: %v\n", spec.name, err)
+			logging.Error("Workspace %s setup failed: %v", spec.name, err)
+			os.Exit(1)
+		}
+		apps = append(apps, w)
+	}
+	for _, a := range apps {
+		if a.toTranslate != nil {
+			go a.translationLoop()
+		}
+	}
+	if registry != nil {
+		if err := serveMetrics(metricsAddr, registry, apps); err != nil {
+			fmt.Fprintf(os.Stderr, "Error serving metrics: %v\n", err)
+			logging.Error("Metrics server failed: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	// Create and run program
+	var program *tea.Program
+	if len(apps) == 1 {
+		program = tea.NewProgram(crashModel{app.model})
+		app.program = program
+	} else {
+		app.name = primaryWorkspace
+		names := make([]string, len(apps))
+		models := make([]ui.Model, len(apps))
+		for i, a := range apps {
+			names[i], models[i] = a.name, a.model
+			a.dir = filepath.Join(outputDir, a.name)
+			logging.Info("Workspace %s: %s", a.name, a.deviceInfo())
+		}
+		program = tea.NewProgram(crashModel{ui.NewWorkspaces(names, models)})
+		for i, a := range apps {
+			a.program = workspaceProgram{Program: program, index: i}
+		}
 	}
 
-	if err := a.capture.Start(); err != nil {
-		logging.Error("Failed to start audio capture: %v", err)
-		return fmt.Errorf("failed to start audio capture: %w", err)
+	// Remote control is optional, so failing to set it up is not fatal
+	var ctlServer *control.Server
+	if ctlSocket != "" {
+		ctlServer, err = app.startControl(ctlSocket)
+		if err != nil {
+			logging.Warn("Control socket unavailable: %v", err)
+		}
 	}
 
-	// Clear buffers
-	a.bufferMu.Lock()
-	a.audioBuffer = a.audioBuffer[:0]
-	a.bufferMu.Unlock()
+	// Cloud backends have no model to load, and warming up would bill a request
+	if warmup && !isCloudBackend() && !missing.Whisper && missing.Model == "" {
+		go app.warmUp()
+	}
 
-	// Create control channels
-	a.stopTranscription = make(chan struct{})
-	a.transcriptionDone = make(chan struct{})
+	// Closing the terminal or being killed must not lose the recording. The
+	// program quits on SIGTERM by itself; SIGHUP would end the process.
+	// After the program ends, a signal cuts waiting for transcription short.
+	signals := make(chan os.Signal, 1)
+	caught := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		for sig := range signals {
+			logging.Warn("Received %s, shutting down", sig)
+			select {
+			case caught <- sig:
+			default:
+			}
+			program.Quit()
+		}
+	}()
 
-	// Start transcription goroutine
-	go a.transcriptionLoop()
+	// A panic elsewhere must not leave the terminal in raw mode
+	crash.SetCrashHandler(program.Kill)
 
-	logging.Info("Recording started successfully with %d device(s)", len(devices))
-	return nil
+	logging.Info("Starting TUI")
+	_, err = program.Run()
+	if crash.ReportCaught() {
+		os.Exit(2)
+	}
+	var sig os.Signal
+	select {
+	case sig = <-caught:
+	default:
+	}
+	// The terminal is likely gone after a hangup, so restoring it can fail
+	if err != nil && sig == nil {
+		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
+		logging.Error("Program error: %v", err)
+		os.Exit(1)
+	}
+	stamp := time.Now().Format("2006-01-02_15-04-05")
+	// The last chunk is still being transcribed after quitting while
+	// recording
+	if sig == nil && slices.ContainsFunc(apps, func(a *App) bool { return a.session.Recording() || a.draining() }) {
+		fmt.Println("Finishing transcription of the remaining audio (Ctrl+C to skip)...")
+	}
+	// A signal while waiting stops every workspace from waiting
+	skip := make(chan struct{})
+	go func() {
+		<-caught
+		close(skip)
+	}()
+	// Workspaces finish in parallel, sharing the time left after a signal
+	var wg sync.WaitGroup
+	for _, a := range apps {
+		wg.Go(func() { a.finish(sig, skip, stamp) })
+	}
+	wg.Wait()
+
+	// Cleanup
+	logging.Info("Shutting down")
+	app.cancel()
+	for _, a := range apps {
+		a.pipeline.Close()
+		a.resumePlayers()
+		a.closeExclusion()
+	}
+	if app.feed != nil {
+		app.feed.Close()
+	}
+	if app.overlay != nil {
+		app.overlay.Close()
+	}
+	if ctlServer != nil {
+		ctlServer.Close()
+	}
+	if app.db != nil {
+		app.db.Close()
+	}
+	app.backend.Close()
 }
 
-// stopRecording stops audio capture
-func (a *App) stopRecording() error {
-	logging.Info("Stopping recording")
+// finish saves the transcript after the program ended: after a signal,
+// nobody is left to save it and the process will be killed if it takes too
+// long, so it goes to an emergency file within signalDrainTimeout. Closing
+// skip cuts waiting for the remaining audio short.
+func (a *App) finish(sig os.Signal, skip <-chan struct{}, stamp string) {
+	if sig != nil {
+		path, err := a.finishSession(signalDrainTimeout, nil, fmt.Sprintf("rekord-emergency-%s.txt", stamp))
+		if err != nil {
+			logging.Error("Failed to write emergency transcript: %v", err)
+			fmt.Fprintf(os.Stderr, "Error writing emergency transcript: %v\n", err)
+		} else if path != "" {
+			logging.Info("Emergency transcript written to %s", path)
+			fmt.Fprintf(os.Stderr, "Received %s, transcript saved to %s\n", sig, path)
+		}
+		return
+	}
 
-	// Signal transcription loop to stop
-	if a.stopTranscription != nil {
-		close(a.stopTranscription)
+	path, err := a.finishSession(0, skip, fmt.Sprintf("transcript_%s.txt", stamp))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving transcript: %v\n", err)
+		logging.Error("Failed to save transcript on quit: %v", err)
+	} else if path != "" {
+		fmt.Printf("Unsaved transcript saved to %s\n", path)
+		logging.Info("Transcript saved on quit to %s", path)
 	}
+}
 
-	// Stop audio capture
-	if a.capture != nil {
-		if err := a.capture.Stop(); err != nil {
-			logging.Error("Failed to stop audio capture: %v", err)
-			return fmt.Errorf("failed to stop audio capture: %w", err)
+// finishSession stops a running recording, waits for its remaining audio to
+// be transcribed and saves the transcript under filename if it has segments
+// that were not saved yet. Waiting ends early after timeout, unless it is 0,
+// or when interrupt is closed. It returns the path written, or "" if there
+// was nothing to save.
+func (a *App) finishSession(timeout time.Duration, interrupt <-chan struct{}, filename string) (string, error) {
+	if a.session.Recording() {
+		if err := a.stopRecording(); err != nil {
+			logging.Error("Failed to stop recording: %v", err)
 		}
 	}
-
-	// Wait for transcription loop to finish (with timeout)
-	if a.transcriptionDone != nil {
+	if a.drained != nil {
+		var expired <-chan time.Time
+		if timeout > 0 {
+			expired = time.After(timeout)
+		}
 		select {
-		case <-a.transcriptionDone:
-			logging.Debug("Transcription loop finished")
-		case <-time.After(2 * time.Second):
-			logging.Warn("Transcription loop did not finish in time")
+		case <-a.drained:
+		case <-expired:
+			logging.Warn("Remaining audio not transcribed within %s", timeout)
+		case <-interrupt:
+			logging.Warn("Interrupted, not waiting for the remaining audio")
 		}
 	}
 
-	// Process remaining audio in background to not block UI
-	go func() {
-		a.processRemainingAudio()
-		logging.Info("Recording stopped, total segments: %d", len(a.segments))
-	}()
+	if a.session.Len() <= int(a.saved.Load()) {
+		return "", nil
+	}
+	return a.writeTranscript(filename)
+}
 
-	return nil
+// draining reports whether audio of the last recording is still being
+// transcribed
+func (a *App) draining() bool {
+	if a.drained == nil {
+		return false
+	}
+	select {
+	case <-a.drained:
+		return false
+	default:
+		return true
+	}
 }
 
-// onAudioData handles incoming audio data
-func (a *App) onAudioData(samples []float32) {
-	a.bufferMu.Lock()
-	a.audioBuffer = append(a.audioBuffer, samples...)
-	a.bufferMu.Unlock()
-
-	// Calculate audio level for visualization
-	var sum float32
-	for _, s := range samples {
-		if s < 0 {
-			sum -= s
-		} else {
-			sum += s
+// logOptions builds the logging options from the -loglevel, -log-format and
+// log rotation flags
+func logOptions() (logging.Options, error) {
+	level, err := logging.ParseLevel(logLevel)
+	if err != nil {
+		return logging.Options{}, err
+	}
+	if logFormat != "text" && logFormat != "json" {
+		return logging.Options{}, fmt.Errorf("unknown log format %q (want text or json)", logFormat)
+	}
+	return logging.Options{
+		Level:      level,
+		JSON:       logFormat == "json",
+		MaxSize:    int64(logMaxSize) << 20,
+		MaxAge:     24 * time.Hour,
+		MaxBackups: logMaxFiles,
+		Retention:  time.Duration(logKeep) * 24 * time.Hour,
+	}, nil
+}
+
+// newBackend creates the transcription backend: a remote model server if
+// -server is set, otherwise the local whisper.cpp CLI
+func newBackend() (transcriber.Backend, error) {
+	if serverAddr != "" {
+		addr := serverAddr
+		if addr == "auto" {
+			var err error
+			addr, err = transcriber.DiscoverServer(5 * time.Second)
+			if err != nil {
+				logging.Error("Model server discovery failed: %v", err)
+				return nil, fmt.Errorf("finding a model server: %w", err)
+			}
 		}
+		logging.Info("Using model server at %s", addr)
+		client := transcriber.NewRemoteClient(addr)
+		client.SetAPIKey(serverKey)
+		client.SetWait(serverWait)
+		return client, nil
 	}
-	level := sum / float32(len(samples))
 
-	if a.program != nil {
-		a.program.Send(ui.AudioLevelMsg{Level: level * 10}) // Scale for visibility
+	switch backendName {
+	case "cli":
+		return newWhisperCLI()
+	case "cgo":
+		return newWhisperCgo()
+	case "openai":
+		logging.Info("Using OpenAI transcription API")
+		return transcriber.NewOpenAIClient(transcriber.CloudOptions{Model: cloudModel, Language: language})
+	case "deepgram":
+		logging.Info("Using Deepgram transcription API")
+		return transcriber.NewDeepgramClient(transcriber.CloudOptions{Model: cloudModel, Language: language})
+	case "fake":
+		logging.Info("Using the fake backend, segments are canned phrases")
+		return transcriber.NewFake(language), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q, expected cli, cgo, openai, deepgram or fake", backendName)
 	}
 }
 
-// transcriptionLoop periodically transcribes accumulated audio
-func (a *App) transcriptionLoop() {
-	defer close(a.transcriptionDone)
-
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+// isCloudBackend reports whether audio is sent to a third-party API
+func isCloudBackend() bool {
+	return serverAddr == "" && (backendName == "openai" || backendName == "deepgram")
+}
 
-	for {
-		select {
-		case <-a.stopTranscription:
-			logging.Debug("Transcription loop received stop signal")
-			return
-		case <-ticker.C:
-			a.processAudioBuffer()
+// buildInitialPrompt combines -prompt with the terms from -vocabulary
+func buildInitialPrompt() (string, error) {
+	var terms []string
+	if vocabulary != "" {
+		var err error
+		terms, err = transcriber.LoadVocabulary(vocabulary)
+		if err != nil {
+			return "", fmt.Errorf("loading vocabulary: %w", err)
 		}
+		logging.Info("Loaded %d vocabulary terms from %s", len(terms), vocabulary)
 	}
+	return transcriber.BuildPrompt(prompt, terms), nil
 }
 
-// processAudioBuffer transcribes the current audio buffer
-func (a *App) processAudioBuffer() {
-	a.bufferMu.Lock()
-	if len(a.audioBuffer) < audio.SampleRate*3 { // Need at least 3 seconds
-		a.bufferMu.Unlock()
-		return
+// newWhisperCgo checks the model and loads it into the in-process backend
+func newWhisperCgo() (*transcriber.WhisperCgo, error) {
+	if !transcriber.ModelExists(modelPath) {
+		logging.Error("Model not found: %s", modelPath)
+		return nil, fmt.Errorf("model not found at %s, please download a Whisper model as per the README instructions", modelPath)
 	}
 
-	// Copy buffer
-	audioData := make([]float32, len(a.audioBuffer))
-	copy(audioData, a.audioBuffer)
+	whisper, err := transcriber.NewWhisperCgo(modelPath, transcriber.WhisperOptions{
+		Language: language,
+		Threads:  whisperThreads,
+		BeamSize: beamSize,
+	})
+	if err != nil {
+		logging.Error("Whisper initialization failed: %v", err)
+		return nil, fmt.Errorf("initializing in-process whisper.cpp: %w", err)
+	}
+	logging.Info("Whisper cgo backend initialized")
+	return whisper, nil
+}
 
-	// Keep last 2 seconds for context
-	overlapSamples := audio.SampleRate * 2
-	if len(a.audioBuffer) > overlapSamples {
-		a.audioBuffer = a.audioBuffer[len(a.audioBuffer)-overlapSamples:]
-	} else {
-		a.audioBuffer = a.audioBuffer[:0]
+// newWhisperCLI checks the model and creates the local whisper.cpp backend
+func newWhisperCLI() (*transcriber.WhisperCLI, error) {
+	// Check model exists
+	if !transcriber.ModelExists(modelPath) {
+		logging.Error("Model not found: %s", modelPath)
+		return nil, fmt.Errorf("model not found at %s, please download a Whisper model as per the README instructions", modelPath)
 	}
-	a.bufferMu.Unlock()
 
-	logging.Debug("Processing audio buffer: %d samples", len(audioData))
+	// Resolve whisper process placement
+	whisperOpts, err := whisperOptions()
+	if err != nil {
+		return nil, err
+	}
+	logging.Info("Whisper threads: %d, CPUs: %v, slice: %q", whisperOpts.Threads, whisperOpts.CPUs, whisperOpts.Slice)
 
-	// Transcribe
-	segments, err := a.whisper.TranscribeCLI(audioData)
+	// Create whisper CLI wrapper
+	whisper, err := transcriber.NewWhisperCLI(modelPath, whisperOpts)
 	if err != nil {
-		logging.Error("Transcription failed: %v", err)
-		if a.program != nil {
-			a.program.Send(ui.ErrorMsg{Error: err})
-		}
-		return
+		logging.Error("Whisper initialization failed: %v", err)
+		return nil, fmt.Errorf("initializing whisper.cpp: %w, please ensure whisper-cli is in your PATH", err)
+	}
+	if accel := whisper.Acceleration(); len(accel) > 0 && !whisperNoGPU {
+		logging.Info("Whisper CLI initialized with %s acceleration", strings.Join(accel, ", "))
+	} else {
+		logging.Info("Whisper CLI initialized (CPU only)")
 	}
+	return whisper, nil
+}
 
-	// Send segments to UI
-	for _, seg := range segments {
-		a.segments = append(a.segments, seg)
-		logging.Debug("New segment: %s", seg.Text)
-		if a.program != nil {
-			a.program.Send(ui.NewSegmentMsg{Segment: seg})
+// whisperOptions builds the whisper process options from the command-line flags
+func whisperOptions() (transcriber.WhisperOptions, error) {
+	opts := transcriber.WhisperOptions{
+		Language:  language,
+		Threads:   whisperThreads,
+		Slice:     whisperSlice,
+		NoGPU:     whisperNoGPU,
+		GPUDevice: whisperGPU,
+		FlashAttn: flashAttn,
+		BeamSize:  beamSize,
+	}
+
+	switch whisperCPUs {
+	case "auto":
+		opts.CPUs = transcriber.DefaultWhisperCPUs()
+	case "", "none":
+	default:
+		cpus, err := transcriber.ParseCPUList(whisperCPUs)
+		if err != nil {
+			return opts, fmt.Errorf("invalid -whisper-cpus: %w", err)
 		}
+		opts.CPUs = cpus
+	}
+
+	// Use one thread per pinned CPU unless told otherwise
+	if opts.Threads == 0 && len(opts.CPUs) > 0 {
+		opts.Threads = len(opts.CPUs)
 	}
+
+	return opts, nil
 }
 
-// processRemainingAudio transcribes any remaining audio in the buffer
-func (a *App) processRemainingAudio() {
-	a.bufferMu.Lock()
-	if len(a.audioBuffer) < audio.SampleRate { // Need at least 1 second
-		a.bufferMu.Unlock()
-		return
+// newModel creates the UI of the app
+func (a *App) newModel(missing ui.Setup, initialPrompt string) ui.Model {
+	model := ui.New(filepath.Base(modelPath), a.deviceInfo())
+	model.SetCallbacks(a.startRecording, a.stopRecording, a.saveTranscript)
+	model.SetRestoreCallback(a.restoreSegment)
+	model.SetRenameSpeakerCallback(a.renameSpeaker)
+	model.SetDuplicateCallback(a.resolveDuplicate)
+	model.SetWatcher(a.watcher)
+	model.SetTimeFormat(timeFormat)
+	model.SetTalkWarning(talkWarn)
+	if noAltScreen {
+		model.SetInline()
+	}
+	model.SetBookmarkCallback(a.addBookmark)
+	model.SetSummaryCallback(a.liveSummary)
+	model.SetDeviceCallbacks(a.listDevices, a.selectDevice)
+	model.SetSetup(missing, a.installSetup)
+	if translateTo != "" {
+		model.SetTranslation(translateTo)
 	}
+	if prompter, ok := a.backend.(transcriber.Prompter); ok {
+		model.SetPromptCallback(initialPrompt, func(p string) error {
+			logging.Info("Initial prompt changed: %q", p)
+			prompter.SetPrompt(p)
+			return nil
+		})
+	}
+	return model
+}
 
-	audioData := make([]float32, len(a.audioBuffer))
-	copy(audioData, a.audioBuffer)
-	a.audioBuffer = a.audioBuffer[:0]
-	a.bufferMu.Unlock()
+// devices returns the captured system audio device and microphone
+func (a *App) devices() (device, mic string) {
+	a.devicesMu.Lock()
+	defer a.devicesMu.Unlock()
+	return a.device, a.mic
+}
 
-	segments, err := a.whisper.TranscribeCLI(audioData)
-	if err != nil {
-		if a.program != nil {
-			a.program.Send(ui.ErrorMsg{Error: err})
-		}
-		return
+// deviceInfo describes the captured devices for the UI header
+func (a *App) deviceInfo() string {
+	device, mic := a.devices()
+	if mic != "" {
+		return fmt.Sprintf("System: %s | Mic: %s", shortenDeviceName(device), shortenDeviceName(mic))
 	}
+	return device
+}
 
-	for _, seg := range segments {
-		a.segments = append(a.segments, seg)
-		if a.program != nil {
-			a.program.Send(ui.NewSegmentMsg{Segment: seg})
+// listDevices lists the audio sources for the devices tab
+func (a *App) listDevices() ([]ui.Device, error) {
+	sources, err := audio.ListMonitorSources()
+	if err != nil {
+		return nil, err
+	}
+	device, mic := a.devices()
+	devices := make([]ui.Device, len(sources))
+	for i, s := range sources {
+		devices[i] = ui.Device{
+			Name:        s.Name,
+			Description: s.Description,
+			Monitor:     s.IsMonitor,
+			Active:      s.Name == device || s.Name == mic,
 		}
 	}
+	return devices, nil
 }
 
-// saveTranscript saves the transcript to a file
-func (a *App) saveTranscript(filename string) error {
-	path := filepath.Join(outputDir, filename)
-
-	f, err := os.Create(path)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+// selectDevice switches the system or microphone device picked in the
+// devices tab. While recording, capture moves over to it right away and the
+// new audio continues in the same buffers and session.
+func (a *App) selectDevice(d ui.Device) (string, error) {
+	if a.replay != nil {
+		return "", errors.New("devices cannot be switched with -simulate")
+	}
+	if a.session.Recording() {
+		old, mic := a.devices()
+		switch {
+		case !d.Monitor && mic == "":
+			return "", errors.New("stop recording to add a microphone")
+		case !d.Monitor:
+			old = mic
+		case a.exclusion != nil:
+			return "", errors.New("stop recording to switch the system audio device with -exclude-apps")
+		}
+		if d.Name == old {
+			return a.deviceInfo(), nil
+		}
+		if err := a.pipeline.SwitchDevice(old, d.Name); err != nil {
+			logging.Error("Failed to switch from %s to %s: %v", old, d.Name, err)
+			return "", err
+		}
 	}
-	defer f.Close()
 
-	// Write header
-	fmt.Fprintf(f, "Rekord Meeting Transcript\n")
-	fmt.Fprintf(f, "Generated: %s\n", time.Now().Format(time.RFC1123))
-	fmt.Fprintf(f, "Device: %s\n", deviceName)
-	fmt.Fprintf(f, "Model: %s\n", modelPath)
-	fmt.Fprintf(f, "----------------------------------------\n\n")
+	a.devicesMu.Lock()
+	if d.Monitor {
+		a.device = d.Name
+		a.defaultDevice = false
+	} else {
+		a.mic = d.Name
+		a.defaultMic = false
+	}
+	a.devicesMu.Unlock()
+	if d.Monitor {
+		logging.Info("System audio device switched to %s", d.Name)
+	} else {
+		logging.Info("Microphone switched to %s", d.Name)
+	}
+	return a.deviceInfo(), nil
+}
 
-	// Write segments
-	for _, seg := range a.segments {
-		timestamp := seg.Timestamp.Format("15:04:05")
-		fmt.Fprintf(f, "[%s] %s\n", timestamp, seg.Text)
+// liveSummary summarizes the transcript so far for the summary tab
+func (a *App) liveSummary() (ui.Summary, error) {
+	var text strings.Builder
+	for _, seg := range a.session.Segments() {
+		text.WriteString(seg.Text + "\n")
 	}
+	points, err := a.summarizer.Summarize(text.String(), 5)
+	if err != nil {
+		return ui.Summary{}, err
+	}
+	return ui.Summary{
+		ReadingTime: summary.ReadingTime(text.String()),
+		KeyPoints:   points,
+		ActionItems: summary.ActionItems(text.String()),
+	}, nil
+}
 
-	return nil
+// shortenDeviceName shortens a device name for display
+func shortenDeviceName(name string) string {
+	// Remove common prefixes for cleaner display
+	name = strings.TrimPrefix(name, "alsa_output.")
+	name = strings.TrimPrefix(name, "alsa_input.")
+	name = strings.TrimSuffix(name, ".monitor")
+
+	// Truncate if too long
+	if len(name) > 30 {
+		name = name[:27] + "..."
+	}
+	return name
+}
+
+// warmUp runs a warm-up transcription and reports the baseline latency
+func (a *App) warmUp() {
+	latency, err := transcriber.WarmUp(a.ctx, a.backend)
+	if err != nil {
+		logging.Warn("%v", err)
+		return
+	}
+
+	logging.Info("Whisper warm-up took %s", latency)
+	a.program.Send(ui.LatencyMsg{Latency: latency, Baseline: true})
+}
+
+// startRecording starts audio capture
+func (a *App) startRecording() error {
+	logging.Info("Starting recording")
+
+	if err := a.checkDiskBeforeRecording(); err != nil {
+		return err
+	}
+
+	// Blocked applications are kept out by capturing a null sink that all
+	// other applications play to. Other workspaces capture their devices
+	// as they are.
+	device, mic := a.devices()
+	captured := device
+	if apps := parseAppList(excludeApps); len(apps) > 0 && a.replay == nil && (a.name == "" || a.name == primaryWorkspace) {
+		exclusion, err := audio.NewExclusion(device, apps)
+		if err != nil {
+			logging.Error("Failed to exclude applications: %v", err)
+			return fmt.Errorf("failed to exclude applications: %w", err)
+		}
+		a.exclusion = exclusion
+		captured = exclusion.Monitor()
+		logging.Info("Excluding %s from capture via %s", strings.Join(apps, ", "), captured)
+	}
+	a.pipeline.SetDevices(captured, mic)
+
+	// Music stops before capture starts so none of it is transcribed
+	if pauseMedia {
+		a.pausePlayers()
+	}
+
+	if err := a.pipeline.Start(); err != nil {
+		a.resumePlayers()
+		a.closeExclusion()
+		logging.Error("Failed to start recording: %v", err)
+		return err
+	}
+	segments := a.pipeline.Segments()
+
+	a.stopMonitors = make(chan struct{})
+	go a.diskGuard(a.stopMonitors)
+	go a.reportTalkTime(a.stopMonitors)
+
+	now := time.Now()
+	var id int64
+	if a.db != nil {
+		var err error
+		id, err = a.db.StartSession(store.Session{Started: now, Device: device, Model: a.session.Metadata().Model, Language: language})
+		if err != nil {
+			logging.Error("Failed to store session: %v", err)
+		}
+	}
+	a.session.Start(id, now)
+
+	drained := make(chan struct{})
+	a.drained = drained
+	go a.recordSegments(segments, id, drained)
+
+	logging.Info("Recording started successfully on %s", a.deviceInfo())
+	return nil
+}
+
+// recordSegments records the segments of a recording as they arrive, until
+// its remaining audio is transcribed after it stopped
+func (a *App) recordSegments(segments <-chan transcriber.Segment, id int64, drained chan<- struct{}) {
+	defer crash.Recover()
+	defer close(drained)
+	for seg := range segments {
+		seg.Source = a.speakerName(seg.Source)
+		a.emitSegment(seg, id)
+	}
+	// Captions of a finished recording would otherwise stay on screen
+	if a.overlay != nil {
+		a.overlay.Clear()
+	}
+	logging.Info("Recording stopped, total segments: %d", a.session.Len())
+}
+
+// onReplayEnd stops a simulated recording once the whole file was replayed
+func (a *App) onReplayEnd() {
+	device, _ := a.devices()
+	logging.Info("Replay of %s finished", device)
+	a.program.Send(ui.StopRecordingMsg{})
+}
+
+// stopRecording stops audio capture. The audio captured so far is still
+// transcribed in the background.
+func (a *App) stopRecording() error {
+	logging.Info("Stopping recording")
+	a.session.Stop()
+	if a.stopMonitors != nil {
+		close(a.stopMonitors)
+		a.stopMonitors = nil
+	}
+
+	// Give the playback streams back to their sink and resume the media
+	// players even if capture fails to stop
+	defer a.closeExclusion()
+	defer a.resumePlayers()
+
+	if err := a.pipeline.Stop(); err != nil {
+		logging.Error("Failed to stop audio capture: %v", err)
+		return err
+	}
+
+	if id := a.session.ID(); a.db != nil && id != 0 {
+		if err := a.db.EndSession(id, time.Now()); err != nil {
+			logging.Error("Failed to store session end: %v", err)
+		}
+	}
+	return nil
+}
+
+// pausePlayers pauses the media players that are playing. Failing to do so
+// does not stop the recording, so it is only reported.
+func (a *App) pausePlayers() {
+	if err := a.players.Pause(); err != nil {
+		logging.Warn("Failed to pause media players: %v", err)
+		go a.program.Send(ui.NoticeMsg{Text: fmt.Sprintf("Media players not paused: %v", err)})
+	}
+	if paused := a.players.Paused(); len(paused) > 0 {
+		logging.Info("Paused media players: %s", strings.Join(paused, ", "))
+	}
+}
+
+// closeExclusion restores the routing of the applications moved for
+// -exclude-apps
+func (a *App) closeExclusion() {
+	if a.exclusion == nil {
+		return
+	}
+	if err := a.exclusion.Close(); err != nil {
+		logging.Warn("Failed to restore audio routing: %v", err)
+	}
+	a.exclusion = nil
+}
+
+// parseAppList splits a comma-separated list of application names
+func parseAppList(list string) []string {
+	var apps []string
+	for app := range strings.SplitSeq(list, ",") {
+		if app = strings.TrimSpace(app); app != "" {
+			apps = append(apps, app)
+		}
+	}
+	return apps
+}
+
+// resumePlayers resumes the media players paused by pausePlayers
+func (a *App) resumePlayers() {
+	if err := a.players.Resume(); err != nil {
+		logging.Warn("Failed to resume media players: %v", err)
+	}
+}
+
+// resolveLostDevice picks the device to restart a lost source on. Devices
+// that were chosen automatically follow the current system default.
+func (a *App) resolveLostDevice(lost string) (string, error) {
+	a.devicesMu.Lock()
+	followSystem := lost == a.device && a.defaultDevice
+	followInput := lost == a.mic && a.defaultMic
+	a.devicesMu.Unlock()
+
+	switch {
+	case followSystem:
+		return audio.GetDefaultMonitorSource()
+	case followInput:
+		return audio.GetDefaultInputSource()
+	}
+	return lost, nil
+}
+
+// onSourceRestart reports automatic audio source restarts to the log and UI
+func (a *App) onSourceRestart(oldDevice, newDevice string, err error) {
+	if err != nil {
+		logging.Warn("Audio source %s: %v", oldDevice, err)
+		if a.program != nil {
+			a.program.Send(ui.SourceLostMsg{Device: shortenDeviceName(oldDevice), Lost: true})
+		}
+		return
+	}
+
+	logging.Info("Audio source %s restarted on %s", oldDevice, newDevice)
+
+	// Track the new device so later losses resolve from it
+	a.devicesMu.Lock()
+	switch oldDevice {
+	case a.device:
+		a.device = newDevice
+	case a.mic:
+		a.mic = newDevice
+	}
+	a.devicesMu.Unlock()
+
+	if a.program != nil {
+		a.program.Send(ui.SourceLostMsg{Device: shortenDeviceName(oldDevice)})
+		a.program.Send(ui.NoticeMsg{Text: fmt.Sprintf("Audio resumed on %s", shortenDeviceName(newDevice))})
+	}
+}
+
+// onAudioData handles incoming audio data
+func (a *App) onAudioData(samples []float32) {
+	level := a.meter.Process(samples)
+	if a.program != nil {
+		a.program.Send(ui.AudioLevelMsg{Level: level})
+	}
+}
+
+// newPipeline creates the capture and transcription pipeline recording the
+// devices of the app
+func (a *App) newPipeline() (*rekord.Session, error) {
+	device, mic := a.devices()
+	cfg := rekord.Config{
+		Device:       device,
+		Mic:          mic,
+		Backend:      a.backend,
+		Workers:      workers,
+		StereoSplit:  stereoSplit,
+		SmartChunks:  smartChunks,
+		BufferMemory: bufferMemory,
+		BufferSpill:  bufferSpill,
+		StallTimeout: stallTimeout,
+		Filter:       a.filter,
+		Corrections:  a.corrections,
+		Redact:       redact,
+		Resolve:      a.resolveLostDevice,
+		Hooks: rekord.Hooks{
+			Audio:        a.onAudioData,
+			Restart:      a.onSourceRestart,
+			Ended:        a.onReplayEnd,
+			Dropped:      a.onAudioDropped,
+			ChunkStarted: a.onChunkStart,
+			Result:       a.onResult,
+			Filtered:     a.reportFiltered,
+		},
+	}
+	if a.replay != nil {
+		cfg.Runner = rekord.NewReplayRunner(a.replay)
+	}
+	if diarizeSystem {
+		cfg.Diarizer = rekord.NewDiarizer(diarizeMax, diarizeDist)
+	}
+	return rekord.New(cfg)
+}
+
+// onAudioDropped warns that transcription fell too far behind to keep all
+// the audio
+func (a *App) onAudioDropped(err error) {
+	if a.program != nil {
+		a.program.Send(ui.NoticeMsg{Text: "Transcription is falling behind, audio is being dropped"})
+	}
+}
+
+// reportTalkTime sends the talk time of the user and the other participants
+// to the UI until stop is closed. Without a microphone there is nothing to
+// compare.
+func (a *App) reportTalkTime(stop <-chan struct{}) {
+	if _, mic := a.devices(); a.program == nil || mic == "" {
+		return
+	}
+	ticker := time.NewTicker(talkTimeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		you, them := a.pipeline.TalkTime()
+		a.program.Send(ui.TalkTimeMsg{You: you, Them: them})
+	}
+}
+
+// runningChunk is a chunk a queue worker is transcribing
+type runningChunk struct {
+	chunk   transcriber.Chunk
+	started time.Time
+}
+
+// onChunkStart shows which chunk the backend is working on
+func (a *App) onChunkStart(c transcriber.Chunk) {
+	a.runningMu.Lock()
+	a.running = append(a.running, runningChunk{chunk: c, started: time.Now()})
+	a.runningMu.Unlock()
+	a.reportRunning()
+}
+
+// reportRunning shows the longest running chunk and how many are running
+func (a *App) reportRunning() {
+	if a.program == nil {
+		return
+	}
+	a.runningMu.Lock()
+	var msg ui.TranscribingMsg
+	if len(a.running) > 0 {
+		oldest := a.running[0]
+		msg = ui.TranscribingMsg{
+			Audio:   time.Duration(len(oldest.chunk.Samples)) * time.Second / audio.SampleRate,
+			Started: oldest.started,
+			Chunks:  len(a.running),
+		}
+	}
+	a.runningMu.Unlock()
+	a.program.Send(msg)
+}
+
+// onResult shows how transcribing a chunk went
+func (a *App) onResult(r transcriber.Result) {
+	a.runningMu.Lock()
+	a.running = slices.DeleteFunc(a.running, func(rc runningChunk) bool {
+		return rc.chunk.Seq == r.Chunk.Seq
+	})
+	a.runningMu.Unlock()
+	a.reportRunning()
+	if a.metrics != nil {
+		a.metrics.observe(r)
+	}
+
+	if r.Err != nil {
+		if a.program != nil {
+			a.program.Send(ui.ErrorMsg{Error: r.Err})
+		}
+		return
+	}
+	if a.program != nil {
+		audioLength := time.Duration(len(r.Chunk.Samples)) * time.Second / audio.SampleRate
+		a.program.Send(ui.LatencyMsg{Latency: r.Took, Lag: r.Wait + r.Took, Audio: audioLength})
+	}
+	a.checkFallback(r.Wait + r.Took)
+}
+
+// checkFallback switches to a smaller model when transcription keeps falling
+// behind the recording with the current one
+func (a *App) checkFallback(lag time.Duration) {
+	switcher, ok := a.backend.(transcriber.ModelSwitcher)
+	if !modelFallback || !ok {
+		return
+	}
+	if lag < modelFallbackLag || time.Since(a.fellBack) < modelFallbackCooldown {
+		a.lagging = 0
+		return
+	}
+	a.lagging++
+	if a.lagging < modelFallbackChunks {
+		return
+	}
+	a.lagging = 0
+
+	current := switcher.Model()
+	smaller, ok := transcriber.SmallerModel(current)
+	if !ok {
+		logging.Warn("Transcription is %s behind, but there is no smaller model than %s installed", lag.Round(time.Second), filepath.Base(current))
+		a.fellBack = time.Now()
+		if a.program != nil {
+			a.program.Send(ui.NoticeMsg{Text: fmt.Sprintf("Transcription is %s behind and no smaller model is installed", lag.Round(time.Second))})
+		}
+		return
+	}
+	if err := switcher.SetModel(smaller); err != nil {
+		logging.Error("Failed to switch to model %s: %v", smaller, err)
+		return
+	}
+	a.fellBack = time.Now()
+	a.session.SetModel(smaller)
+	logging.Warn("Transcription is %s behind, switched from %s to %s", lag.Round(time.Second), filepath.Base(current), filepath.Base(smaller))
+	if a.program != nil {
+		a.program.Send(ui.ModelChangedMsg{
+			Model:  filepath.Base(smaller),
+			Reason: fmt.Sprintf("Transcription fell %s behind, switched to the smaller %s model", lag.Round(time.Second), filepath.Base(smaller)),
+		})
+	}
+}
+
+// reportFiltered lets the UI offer a segment changed by post-processing for
+// review. text is what was kept, empty if the segment was dropped.
+func (a *App) reportFiltered(original transcriber.Segment, text, reason string) {
+	original.Source = a.speakerName(original.Source)
+	if a.program != nil {
+		a.program.Send(ui.FilteredMsg{Filtered: ui.FilteredSegment{
+			Original: original,
+			Text:     text,
+			Reason:   reason,
+		}})
+	}
+}
+
+// restoreSegment undoes post-processing of a segment at the user's request
+func (a *App) restoreSegment(f ui.FilteredSegment) error {
+	orig := f.Original
+	if f.Dropped() {
+		a.session.InsertSegment(orig)
+		logging.Info("Restored dropped segment: %s", orig.Text)
+		return nil
+	}
+
+	if a.session.SetText(orig.Timestamp, orig.Source, orig.Text) {
+		logging.Info("Restored segment text: %s", orig.Text)
+		return nil
+	}
+	return errors.New("segment to restore is no longer in the transcript")
+}
+
+// emitSegment records a finalized segment of the recording with database ID
+// id and forwards it to the UI, feed and overlay
+func (a *App) emitSegment(seg transcriber.Segment, id int64) {
+	a.session.AddSegment(seg)
+	if a.program != nil {
+		a.program.Send(ui.NewSegmentMsg{Segment: seg})
+	}
+	if a.feed != nil {
+		a.feed.Write(seg)
+	}
+	if a.overlay != nil {
+		a.overlay.Write(seg)
+	}
+	if a.metrics != nil {
+		a.metrics.segments.Inc()
+	}
+	if a.db != nil && id != 0 {
+		if err := a.db.AddSegment(id, seg); err != nil {
+			logging.Error("Failed to store segment: %v", err)
+		}
+	}
+	if words := a.watcher.Match(seg.Text); len(words) > 0 {
+		logging.Info("Watch-word spoken: %s", strings.Join(words, ", "))
+		if notifyWatch {
+			go func() {
+				if err := alert.Notify("rekord: "+words[0], seg.Text); err != nil {
+					logging.Warn("Desktop notification failed: %v", err)
+				}
+			}()
+		}
+	}
+	if a.toTranslate != nil {
+		select {
+		case a.toTranslate <- seg:
+		default:
+			logging.Warn("Translation backlog full, skipping segment")
+		}
+	}
+}
+
+// translationLoop translates segments in order as they are emitted
+func (a *App) translationLoop() {
+	defer crash.Recover()
+	for seg := range a.toTranslate {
+		source := seg.Language
+		if source == "" {
+			source = language
+		}
+		text, err := a.translator.Translate(seg.Text, source, translateTo)
+		if err != nil {
+			logging.Error("Translation failed: %v", err)
+			text = "(translation failed)"
+		}
+		if a.program != nil {
+			a.program.Send(ui.TranslationMsg{Segment: seg, Text: text})
+		}
+	}
+}
+
+// saveTranscript saves the transcript to a file
+func (a *App) saveTranscript(filename string) error {
+	_, err := a.writeTranscript(filename)
+	return err
+}
+
+// writeTranscript saves the transcript under filename, or a numbered variant
+// if it exists, and returns the path written
+func (a *App) writeTranscript(filename string) (string, error) {
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	f, err := transcript.CreateUnique(a.dir, transcript.SanitizeFilename(filename))
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	path := f.Name()
+	filename = filepath.Base(path)
+
+	// Work on a snapshot, segments keep arriving while saving
+	segments := a.session.Segments()
+	markers := a.session.Markers()
+	var text strings.Builder
+	for _, seg := range segments {
+		text.WriteString(seg.Text + "\n")
+	}
+
+	// Write header
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "Rekord Meeting Transcript\n")
+	fmt.Fprintf(w, "Generated: %s\n", time.Now().Format(time.RFC1123))
+	device, _ := a.devices()
+	fmt.Fprintf(w, "Device: %s\n", device)
+	fmt.Fprintf(w, "Model: %s\n", a.session.Metadata().Model)
+	sum := a.summarize(text.String())
+	writeSummaryHeader(w, sum)
+	fmt.Fprintf(w, "----------------------------------------\n\n")
+
+	// Write segments
+	for _, seg := range segments {
+		fmt.Fprintf(w, "[%s] %s\n", timeFormat.Format(seg), seg.Label())
+	}
+	err = w.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write transcript: %w", err)
+	}
+	// Only a transcript on disk lets quitting skip the save
+	a.saved.Store(int64(len(segments)))
+
+	if len(markers) > 0 {
+		if err := saveAnnotations(path, markers); err != nil {
+			return "", err
+		}
+	}
+	if exportSRT || exportMarkdown {
+		chapters := chapters(segments, markers)
+		if exportSRT {
+			if err := saveSRT(path, segments, chapters); err != nil {
+				return "", err
+			}
+		}
+		if exportMarkdown {
+			if err := saveMarkdown(path, sum, segments, chapters); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if exportJSON {
+		if err := saveJSON(path, segments); err != nil {
+			return "", err
+		}
+	}
+	if exportDOCX || exportPDF {
+		started := a.session.Metadata().Started
+		metadata := []transcript.Field{
+			{Label: "Date", Value: timeFormat.Stamp(started)},
+			{Label: "Duration", Value: recordedDuration(segments).String()},
+			{Label: "Device", Value: device},
+			{Label: "Model", Value: filepath.Base(a.session.Metadata().Model)},
+		}
+		title := "Meeting Minutes " + timeFormat.Date(started)
+		if err := saveDocuments(path, title, metadata, segments); err != nil {
+			return "", err
+		}
+	}
+
+	logging.Info("Transcript saved to %s", path)
+	var msg tea.Msg = ui.NoticeMsg{Text: "Saved transcript to " + path}
+	if len(segments) > 0 {
+		if dup := a.checkDuplicate(path, text.String(), segments[0].Timestamp); dup != nil {
+			msg = *dup
+		}
+	}
+	if a.program != nil {
+		// Saving runs inside the UI update loop, so send asynchronously
+		go a.program.Send(msg)
+	}
+
+	return path, nil
+}
+
+// saveAnnotations writes the session annotations to a CSV file next to the
+// transcript at transcriptPath
+func saveAnnotations(transcriptPath string, annotations []transcript.Annotation) error {
+	path := strings.TrimSuffix(transcriptPath, filepath.Ext(transcriptPath)) + ".annotations.csv"
+
+	// Named after the transcript, which was already made unique
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create annotations file: %w", err)
+	}
+
+	err = transcript.WriteAnnotationsCSV(f, annotations)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write annotations: %w", err)
+	}
+	return nil
+}
+
+// saveSRT writes the transcript as subtitles next to the transcript at
+// transcriptPath
+func saveSRT(transcriptPath string, segments []transcriber.Segment, chapters []transcript.Annotation) error {
+	path := strings.TrimSuffix(transcriptPath, filepath.Ext(transcriptPath)) + ".srt"
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create SRT file: %w", err)
+	}
+
+	err = transcript.WriteSRT(f, segments, chapters)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write SRT file: %w", err)
+	}
+	return nil
+}
+
+// saveMarkdown writes the transcript as Markdown, headed by sum, next to the
+// transcript at transcriptPath
+func saveMarkdown(transcriptPath string, sum transcript.Summary, segments []transcriber.Segment, chapters []transcript.Annotation) error {
+	path := strings.TrimSuffix(transcriptPath, filepath.Ext(transcriptPath)) + ".md"
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create Markdown file: %w", err)
+	}
+
+	title := "Meeting Transcript " + timeFormat.Stamp(time.Now())
+	err = transcript.WriteMarkdown(f, title, sum, segments, chapters, timeFormat)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write Markdown file: %w", err)
+	}
+	return nil
+}
+
+// saveHTML writes the transcript as an HTML page with a player for the
+// media file it was transcribed from next to the transcript at
+// transcriptPath
+func saveHTML(transcriptPath, media string, segments []transcriber.Segment, chapters []transcript.Annotation) error {
+	path := strings.TrimSuffix(transcriptPath, filepath.Ext(transcriptPath)) + ".html"
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create HTML file: %w", err)
+	}
+
+	// The page sits next to the media file and refers to it by name
+	err = transcript.WriteHTML(f, filepath.Base(media), filepath.Base(media), segments, chapters)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write HTML file: %w", err)
+	}
+	return nil
+}
+
+// saveJSON writes the transcript as JSON in the -json-format schema next to
+// the transcript at transcriptPath
+func saveJSON(transcriptPath string, segments []transcriber.Segment) error {
+	name := strings.TrimSuffix(transcriptPath, filepath.Ext(transcriptPath))
+	path := name + ".json"
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create JSON file: %w", err)
+	}
+
+	switch jsonFormat {
+	case transcript.JSONAWS:
+		err = transcript.WriteAWSJSON(f, filepath.Base(name), segments)
+	case transcript.JSONGCP:
+		err = transcript.WriteGCPJSON(f, segments)
+	default:
+		err = transcript.WriteJSON(f, segments)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+	return nil
+}
+
+// parseJSONFormat sets the -json-format flag
+func parseJSONFormat(s string) (err error) {
+	jsonFormat, err = transcript.ParseJSONFormat(s)
+	return err
+}
+
+// addTimeFlags registers the flags setting how wall-clock times are shown
+// and exported on fs
+func addTimeFlags(fs *flag.FlagSet) {
+	fs.Func("time-format", "Layout of wall-clock times: 24h (default), 12h, locale (from LC_TIME) or a Go time layout such as \"3:04 PM\"", func(s string) (err error) {
+		timeFormat.Layout, timeFormat.DateLayout, err = transcriber.ParseTimeLayout(s)
+		return err
+	})
+	fs.BoolVar(&timeFormat.WithDate, "time-date", false, "Include the date in wall-clock segment times")
+	fs.Func("timezone", "Time zone of wall-clock times: an IANA name such as Europe/Berlin, UTC or Local (default)", func(s string) (err error) {
+		timeFormat.Location, err = transcriber.ParseLocation(s)
+		return err
+	})
+}
+
+// saveDocuments writes the transcript as meeting minutes, with a summary
+// and action items, in the DOCX and PDF formats enabled by -docx and -pdf
+// next to the transcript at transcriptPath
+func saveDocuments(transcriptPath, title string, metadata []transcript.Field, segments []transcriber.Segment) error {
+	var text strings.Builder
+	for _, seg := range segments {
+		text.WriteString(seg.Text + "\n")
+	}
+	doc := transcript.Document{
+		Title:       title,
+		Metadata:    metadata,
+		ActionItems: summary.ActionItems(text.String()),
+		Segments:    segments,
+		Format:      timeFormat,
+	}
+	points, err := summary.Extractive{}.Summarize(text.String(), 5)
+	if err != nil {
+		logging.Warn("Summarization failed: %v", err)
+	}
+	doc.Summary = points
+
+	base := strings.TrimSuffix(transcriptPath, filepath.Ext(transcriptPath))
+	if exportDOCX {
+		if err := saveDocument(base+".docx", "DOCX", doc, transcript.WriteDOCX); err != nil {
+			return err
+		}
+	}
+	if exportPDF {
+		if err := saveDocument(base+".pdf", "PDF", doc, transcript.WritePDF); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveDocument writes doc to path with write
+func saveDocument(path, format string, doc transcript.Document, write func(io.Writer, transcript.Document) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s file: %w", format, err)
+	}
+
+	err = write(f, doc)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s file: %w", format, err)
+	}
+	return nil
+}
+
+// recordedDuration returns how much audio the segments span, to the second
+func recordedDuration(segments []transcriber.Segment) time.Duration {
+	var end time.Duration
+	for _, seg := range segments {
+		end = max(end, seg.EndTime)
+	}
+	return end.Round(time.Second)
+}
+
+// chapters returns the chapter points of the exports: the bookmarks and
+// annotations, plus topical chapters if -chapters is set
+func chapters(segments []transcriber.Segment, markers []transcript.Annotation) []transcript.Annotation {
+	if !autoChapters || len(segments) == 0 {
+		return markers
+	}
+
+	lines := make([]string, len(segments))
+	for i, seg := range segments {
+		lines[i] = seg.Text
+	}
+	chapters := slices.Clone(markers)
+	for _, c := range summary.Chapters(lines, summary.MinChapterLines) {
+		chapters = append(chapters, transcript.Annotation{Offset: segments[c.Start].StartTime, Label: c.Heading})
+	}
+	slices.SortStableFunc(chapters, func(x, y transcript.Annotation) int {
+		return cmp.Compare(x.Offset, y.Offset)
+	})
+	logging.Debug("Generated %d topical chapters", len(chapters)-len(markers))
+	return chapters
+}
+
+// addBookmark marks the current point of the recording with label
+func (a *App) addBookmark(label string) (transcript.Annotation, error) {
+	if !a.session.Recording() {
+		return transcript.Annotation{}, errors.New("bookmarks can only be added while recording")
+	}
+	mark := transcript.Annotation{Offset: a.pipeline.Position(), Label: label}
+	a.session.AddMarker(mark)
+	if id := a.session.ID(); a.db != nil && id != 0 {
+		if err := a.db.AddBookmark(id, mark); err != nil {
+			logging.Error("Failed to store bookmark: %v", err)
+		}
+	}
+	logging.Info("Bookmark %q at %s", label, transcript.FormatOffset(mark.Offset))
+	return mark, nil
+}
+
+// summarize estimates the reading time of the transcript text and picks a
+// short TL;DR for the top of the exports
+func (a *App) summarize(text string) transcript.Summary {
+	return summarizeWith(a.summarizer, text)
+}
+
+// summarizeWith is summarize with the given summarizer, for transcripts of
+// files that have no App
+func summarizeWith(summarizer summary.Summarizer, text string) transcript.Summary {
+	if strings.TrimSpace(text) == "" {
+		return transcript.Summary{}
+	}
+
+	sum := transcript.Summary{ReadingTime: summary.ReadingTime(text)}
+	points, err := summarizer.Summarize(text, 3)
+	if err != nil {
+		logging.Warn("Summarization failed: %v", err)
+		return sum
+	}
+	sum.Points = points
+	return sum
+}
+
+// writeSummaryHeader writes the reading time estimate and TL;DR of sum to
+// the plain text transcript
+func writeSummaryHeader(w io.Writer, sum transcript.Summary) {
+	if sum.ReadingTime == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "Reading time: ~%d min\n", summary.Minutes(sum.ReadingTime))
+	if len(sum.Points) > 0 {
+		fmt.Fprintf(w, "\nTL;DR:\n")
+		for _, p := range sum.Points {
+			fmt.Fprintf(w, "  - %s\n", p)
+		}
+	}
 }
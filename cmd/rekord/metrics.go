@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/exler/rekord/internal/audio"
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/metrics"
+	"github.com/exler/rekord/internal/transcriber"
+	"github.com/exler/rekord/pkg/rekord"
+)
+
+// pipelineMetrics are the metrics of transcribed chunks and segments served
+// with -metrics-addr, shared by all workspaces
+type pipelineMetrics struct {
+	transcription *metrics.Histogram
+	wait          *metrics.Histogram
+	audio         *metrics.Counter
+	failures      *metrics.Counter
+	segments      *metrics.Counter
+	realTime      *metrics.Gauge
+}
+
+// newPipelineMetrics registers the metrics recorded as chunks are
+// transcribed
+func newPipelineMetrics(reg *metrics.Registry) *pipelineMetrics {
+	return &pipelineMetrics{
+		transcription: reg.Histogram("rekord_transcription_seconds", "Time the backend took to transcribe a chunk.", metrics.DurationBuckets),
+		wait:          reg.Histogram("rekord_queue_wait_seconds", "Time a chunk waited for a transcription worker.", metrics.DurationBuckets),
+		audio:         reg.Counter("rekord_transcribed_audio_seconds_total", "Audio transcribed, including the overlap repeated between chunks."),
+		failures:      reg.Counter("rekord_transcription_failures_total", "Chunks whose transcription failed."),
+		segments:      reg.Counter("rekord_segments_total", "Segments added to the transcript."),
+		realTime:      reg.Gauge("rekord_real_time_factor", "Transcription time divided by audio length of the last chunk; above 1 falls behind."),
+	}
+}
+
+// observe records how transcribing a chunk went
+func (m *pipelineMetrics) observe(r transcriber.Result) {
+	m.wait.Observe(r.Wait.Seconds())
+	if r.Err != nil {
+		m.failures.Inc()
+		return
+	}
+	audioLength := time.Duration(len(r.Chunk.Samples)) * time.Second / audio.SampleRate
+	m.transcription.Observe(r.Took.Seconds())
+	m.audio.Add(audioLength.Seconds())
+	if audioLength > 0 {
+		m.realTime.Set(r.Took.Seconds() / audioLength.Seconds())
+	}
+}
+
+// serveMetrics registers the capture metrics of apps and serves all metrics
+// of reg on addr in the background
+func serveMetrics(addr string, reg *metrics.Registry, apps []*App) error {
+	stats := func(field func(rekord.Stats) float64) func() float64 {
+		return func() float64 {
+			var sum float64
+			for _, a := range apps {
+				sum += field(a.pipeline.Stats())
+			}
+			return sum
+		}
+	}
+	reg.CounterFunc("rekord_capture_bytes_total", "Raw audio delivered by the capture programs.",
+		stats(func(s rekord.Stats) float64 { return float64(s.CaptureBytes) }))
+	reg.CounterFunc("rekord_dropped_samples_total", "Audio samples lost because transcription fell too far behind.",
+		stats(func(s rekord.Stats) float64 { return float64(s.DroppedSamples) }))
+	reg.GaugeFunc("rekord_queued_chunks", "Chunks waiting for a transcription worker.",
+		stats(func(s rekord.Stats) float64 { return float64(s.QueuedChunks) }))
+	reg.GaugeFunc("rekord_recording", "Workspaces currently recording.", func() float64 {
+		var n float64
+		for _, a := range apps {
+			if a.pipeline.Recording() {
+				n++
+			}
+		}
+		return n
+	})
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET "+metrics.Path, reg)
+	logging.Info("Serving metrics on http://%s%s", ln.Addr(), metrics.Path)
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			logging.Error("Metrics server failed: %v", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/exler/rekord/internal/setup"
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// runModels implements the models subcommand, which lists the whisper
+// models in the models directory or downloads one into it
+func runModels(args []string) int {
+	fs := flag.NewFlagSet("models", flag.ExitOnError)
+	dir := fs.String("dir", transcriber.GetModelsDir(), "Directory whisper models are kept in")
+	fs.StringVar(&modelPath, "model", modelPath, "Model used by default, marked with *")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rekord models [flags]\n       rekord models download <name>, e.g. base.en, small or large-v3-turbo\n\n")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&configFile, "config", configFile, "Config file with the defaults of the flags")
+	fs.Parse(args)
+
+	if err := applyConfig(fs); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	switch fs.Arg(0) {
+	case "":
+		return listModels(*dir)
+	case "download":
+		if fs.NArg() != 2 {
+			fs.Usage()
+			return 2
+		}
+		return downloadModel(*dir, fs.Arg(1))
+	default:
+		fs.Usage()
+		return 2
+	}
+}
+
+// listModels prints the models in dir with their size
+func listModels(dir string) int {
+	paths, err := filepath.Glob(filepath.Join(dir, "ggml-*.bin"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing models: %v\n", err)
+		return 1
+	}
+	if len(paths) == 0 {
+		fmt.Printf("No models in %s. Download one with: rekord models download base.en\n", dir)
+		return 0
+	}
+
+	defaultModel, _ := filepath.Abs(modelPath)
+	fmt.Printf("Models in %s:\n", dir)
+	for _, path := range paths {
+		mark := " "
+		if abs, _ := filepath.Abs(path); abs == defaultModel {
+			mark = "*"
+		}
+		var size int64
+		if info, err := os.Stat(path); err == nil {
+			size = info.Size()
+		}
+		status := ""
+		if err := transcriber.ValidateModel(path); err != nil {
+			status = "  (invalid: " + err.Error() + ")"
+		}
+		fmt.Printf("%s %-28s %6d MB%s\n", mark, filepath.Base(path), size>>20, status)
+	}
+	return 0
+}
+
+// downloadModel downloads the model called name, with or without the ggml-
+// prefix and .bin suffix, into dir
+func downloadModel(dir, name string) int {
+	path := modelFile(dir, name)
+	if transcriber.ModelExists(path) {
+		fmt.Printf("%s is already installed\n", path)
+		return 0
+	}
+
+	err := setup.DownloadModel(path, func(status string) {
+		fmt.Printf("\r\033[K%s", status)
+	})
+	fmt.Println()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error downloading model: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Downloaded %s\n", path)
+	return 0
+}
+
+// modelFile returns the path of the model called name in dir
+func modelFile(dir, name string) string {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "ggml-"), ".bin")
+	return filepath.Join(dir, "ggml-"+name+".bin")
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/exler/rekord/internal/profile"
+	"github.com/exler/rekord/internal/ui"
+)
+
+// pickProfile is the -profile value that asks for the profile in a picker
+const pickProfile = "pick"
+
+// errNoProfile reports that the profile picker was cancelled
+var errNoProfile = errors.New("no profile chosen")
+
+// applyProfile sets the flags of the profile selected with -profile, or
+// without it of the first profile scheduled for now. Flags given on the
+// command line take precedence over the profile.
+func applyProfile() error {
+	profiles, err := profile.Load(profileFile)
+	if err != nil {
+		return fmt.Errorf("failed to read profiles from %s: %w", profileFile, err)
+	}
+	if len(profiles) == 0 && profileName != "" {
+		return fmt.Errorf("no profiles defined in %s", profileFile)
+	}
+
+	name := profileName
+	switch name {
+	case "":
+		p := profile.Scheduled(profiles, time.Now())
+		if p == nil {
+			return nil
+		}
+		name = p.Name
+	case pickProfile:
+		if name, err = runProfilePicker(profiles); err != nil {
+			return err
+		}
+	}
+	p := profile.Find(profiles, name)
+	if p == nil {
+		return fmt.Errorf("unknown profile %q (defined: %s)", name, strings.Join(profile.Names(profiles), ", "))
+	}
+
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for _, s := range p.Settings {
+		if s.Flag == "profile" || s.Flag == "profiles" {
+			return fmt.Errorf("profile %s: profiles cannot select other profiles", p.Name)
+		}
+		if flag.Lookup(s.Flag) == nil {
+			return fmt.Errorf("profile %s: unknown flag -%s", p.Name, s.Flag)
+		}
+		if explicit[s.Flag] {
+			continue
+		}
+		if err := flag.Set(s.Flag, s.Value); err != nil {
+			return fmt.Errorf("profile %s: invalid value %q for -%s: %w", p.Name, s.Value, s.Flag, err)
+		}
+	}
+	profileName = p.Name
+	return nil
+}
+
+// runProfilePicker asks for one of profiles in a terminal picker and
+// returns its name
+func runProfilePicker(profiles []profile.Profile) (string, error) {
+	choices := make([]ui.ProfileChoice, len(profiles))
+	for i, p := range profiles {
+		choices[i] = ui.ProfileChoice{Name: p.Name, Description: p.Description}
+	}
+	final, err := tea.NewProgram(ui.NewProfilePicker(choices)).Run()
+	if err != nil {
+		return "", fmt.Errorf("profile picker failed: %w", err)
+	}
+	name := final.(ui.ProfilePicker).Chosen()
+	if name == "" {
+		return "", errNoProfile
+	}
+	return name, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/exler/rekord/internal/search"
+)
+
+// runSearch implements the search subcommand, which finds saved transcripts
+// mentioning all words of a query
+func runSearch(args []string) int {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	dir := fs.String("dir", ".", "Directory of saved transcripts to search")
+	limit := fs.Int("limit", 20, "Maximum number of matching lines to show")
+	indexPath := fs.String("index", search.DefaultIndexPath(), "Search index database, updated before every search")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rekord search [flags] <query>\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	query := strings.Join(fs.Args(), " ")
+
+	index, err := search.Open(*indexPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening search index: %v\n", err)
+		return 1
+	}
+	defer index.Close()
+
+	if _, err := index.Update(*dir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error indexing transcripts: %v\n", err)
+		return 1
+	}
+
+	// Highlight matches in bold on a terminal, with brackets otherwise
+	mark := [2]string{"[", "]"}
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		mark = [2]string{"\x1b[1m", "\x1b[0m"}
+	}
+	hits, err := index.Search(*dir, query, *limit, mark)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(hits) == 0 {
+		fmt.Printf("No transcripts in %s mention %q\n", *dir, query)
+		return 1
+	}
+
+	// Group the lines by transcript, best matching transcript first
+	var order []string
+	byFile := make(map[string][]search.Hit)
+	for _, h := range hits {
+		if _, ok := byFile[h.Path]; !ok {
+			order = append(order, h.Path)
+		}
+		byFile[h.Path] = append(byFile[h.Path], h)
+	}
+	for i, path := range order {
+		if i > 0 {
+			fmt.Println()
+		}
+		name := path
+		if abs, err := filepath.Abs(*dir); err == nil {
+			if rel, err := filepath.Rel(abs, path); err == nil {
+				name = rel
+			}
+		}
+		fmt.Println(name)
+		for _, h := range byFile[path] {
+			if h.Time != "" {
+				fmt.Printf("  [%s] %s\n", h.Time, h.Snippet)
+			} else {
+				fmt.Printf("  %s\n", h.Snippet)
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/modelserver"
+)
+
+// runServeModel implements the serve (formerly serve-model) subcommand,
+// which shares this machine's whisper model with other rekord instances on
+// the network
+func runServeModel(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":7777", "Address to listen on")
+	advertise := fs.Bool("mdns", false, "Advertise the server on the local network via mDNS, for -server auto (default true with -api-key; requires it, as without a key other machines are refused)")
+	apiKey := fs.String("api-key", os.Getenv("REKORD_API_KEY"), "API key clients must send to use /v1 from other machines, as -server-key or their OpenAI API key (default $REKORD_API_KEY; without one, /v1 only answers this machine)")
+	fs.StringVar(&modelPath, "model", modelPath, "Path to the whisper model file")
+	fs.StringVar(&language, "language", "en", "Spoken language code passed to whisper")
+	fs.IntVar(&whisperThreads, "whisper-threads", 0, "Threads per whisper process (0 = match pinned CPUs or whisper default)")
+	fs.StringVar(&whisperCPUs, "whisper-cpus", "none", "CPUs to pin whisper to, e.g. 4-7 (auto = efficiency cores if detected, none = no pinning)")
+	fs.StringVar(&whisperSlice, "whisper-slice", "", "Run whisper in this systemd user slice, e.g. background.slice")
+	fs.BoolVar(&whisperNoGPU, "whisper-no-gpu", false, "Run whisper on the CPU even if it was built with GPU support")
+	fs.IntVar(&whisperGPU, "whisper-gpu", 0, "Index of the GPU whisper should use")
+	fs.BoolVar(&flashAttn, "whisper-flash-attn", false, "Enable whisper flash attention (faster on most GPUs)")
+	fs.IntVar(&beamSize, "whisper-beam-size", 0, "Whisper beam search width (0 = whisper default, lower is faster)")
+	fs.StringVar(&logDir, "logdir", logDir, "Directory for log files")
+	fs.StringVar(&logLevel, "loglevel", "info", "Minimum log level: debug, info, warn or error")
+	fs.StringVar(&logFormat, "log-format", "text", "Log format: text (key=value lines) or json")
+	fs.StringVar(&configFile, "config", configFile, "Config file with the defaults of the flags")
+	fs.Parse(args)
+
+	if err := applyConfig(fs); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// Without a key the server only answers this machine, so advertising
+	// it would lead -server auto to a server it cannot use
+	mdnsSet := false
+	fs.Visit(func(f *flag.Flag) { mdnsSet = mdnsSet || f.Name == "mdns" })
+	if !mdnsSet {
+		*advertise = *apiKey != ""
+	} else if *advertise && *apiKey == "" {
+		fmt.Fprintln(os.Stderr, "Error: -mdns requires -api-key, without one the server refuses other machines")
+		return 2
+	}
+
+	logOpts, err := logOptions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := logging.Init(logDir, logOpts); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to initialize logging: %v\n", err)
+	}
+	defer logging.Close()
+
+	whisper, err := newWhisperCLI()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer whisper.Close()
+
+	server := modelserver.New(whisper)
+	if *apiKey != "" {
+		server.SetAPIKey(*apiKey)
+	} else {
+		logging.Info("No -api-key set, /v1 only answers clients on this machine and is not advertised via mDNS")
+	}
+
+	fmt.Printf("Serving %s on %s\n", modelPath, *addr)
+	if err := server.ListenAndServe(*addr, *advertise); err != nil {
+		fmt.Fprintf(os.Stderr, "Error running model server: %v\n", err)
+		logging.Error("Model server failed: %v", err)
+		return 1
+	}
+	return 0
+}
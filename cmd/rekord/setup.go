@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/setup"
+	"github.com/exler/rekord/internal/transcriber"
+	"github.com/exler/rekord/internal/ui"
+)
+
+// missingSetup reports which parts of the local whisper setup are missing.
+// Only the default CLI backend can be set up from the wizard.
+func missingSetup() ui.Setup {
+	var missing ui.Setup
+	if serverAddr != "" || backendName != "cli" {
+		return missing
+	}
+	missing.Whisper = transcriber.FindWhisperExecutable() == ""
+	if !transcriber.ModelExists(modelPath) {
+		missing.Model = modelPath
+	}
+	return missing
+}
+
+// newPendingBackend creates the CLI backend before whisper.cpp or the model
+// are installed; it finds them once the setup wizard is done
+func newPendingBackend(missing ui.Setup) (transcriber.Backend, error) {
+	logging.Warn("Whisper setup incomplete (whisper.cpp missing: %t, model missing: %q), starting the setup wizard", missing.Whisper, missing.Model)
+	whisperOpts, err := whisperOptions()
+	if err != nil {
+		return nil, err
+	}
+	return transcriber.NewPendingWhisperCLI(modelPath, whisperOpts), nil
+}
+
+// installSetup installs the missing parts of the whisper setup in the
+// background, reporting progress to the UI
+func (a *App) installSetup() error {
+	missing := missingSetup()
+	wait := startInstall(missing.Whisper, missing.Model, a.program.Send)
+	go func() {
+		if wait() && warmup {
+			a.warmUp()
+		}
+	}()
+	return nil
+}
+
+// startInstall installs whisper.cpp if whisper is set, and downloads the
+// model at model unless it is empty, in the background, reporting progress
+// with SetupProgressMsg. The returned function waits for both and reports
+// whether they succeeded.
+func startInstall(whisper bool, model string, send func(tea.Msg)) func() bool {
+	var wg sync.WaitGroup
+	var failed atomic.Bool
+	run := func(task ui.SetupTask, install func(setup.Progress) (string, error)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, err := install(func(status string) {
+				send(ui.SetupProgressMsg{Task: task, Status: status})
+			})
+			if err != nil {
+				logging.Error("Setup failed: %v", err)
+				failed.Store(true)
+			}
+			send(ui.SetupProgressMsg{Task: task, Status: status, Done: true, Err: err})
+		}()
+	}
+
+	if whisper {
+		run(ui.SetupWhisper, func(progress setup.Progress) (string, error) {
+			path, err := setup.InstallWhisper(progress)
+			return "installed to " + path, err
+		})
+	}
+	if model != "" {
+		run(ui.SetupModel, func(progress setup.Progress) (string, error) {
+			return "downloaded to " + model, setup.DownloadModel(model, progress)
+		})
+	}
+
+	return func() bool {
+		wg.Wait()
+		return !failed.Load()
+	}
+}
+
+// runInstallWhisper implements the install-whisper subcommand, which
+// installs whisper.cpp into rekord's cache directory, and the model if it
+// is missing
+func runInstallWhisper(args []string) int {
+	fs := flag.NewFlagSet("install-whisper", flag.ExitOnError)
+	fromSource := fs.Bool("from-source", false, "Build whisper.cpp from source instead of downloading a prebuilt executable")
+	fs.StringVar(&modelPath, "model", modelPath, "Path of the whisper model to download if missing")
+	fs.StringVar(&configFile, "config", configFile, "Config file with the defaults of the flags")
+	fs.Parse(args)
+
+	if err := applyConfig(fs); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	progress := func(status string) {
+		fmt.Printf("\r\033[K%s", status)
+	}
+
+	install := setup.InstallWhisper
+	if *fromSource {
+		install = setup.BuildWhisper
+	}
+	path, err := install(progress)
+	fmt.Println()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error installing whisper.cpp: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Installed whisper.cpp to %s\n", path)
+
+	if !transcriber.ModelExists(modelPath) {
+		if err := setup.DownloadModel(modelPath, progress); err != nil {
+			fmt.Println()
+			fmt.Fprintf(os.Stderr, "Error downloading model: %v\n", err)
+			return 1
+		}
+		fmt.Printf("\nDownloaded model to %s\n", modelPath)
+	}
+	return 0
+}
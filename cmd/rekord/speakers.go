@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/speakers"
+)
+
+// loadSpeakerNames reads the speaker names remembered for the meeting
+// series, none without a series
+func loadSpeakerNames(series string) (speakers.Names, error) {
+	if series == "" {
+		return speakers.Names{}, nil
+	}
+	all, err := speakers.Load(speakersFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read speaker names from %s: %w", speakersFile, err)
+	}
+	names := all[series]
+	if names == nil {
+		names = speakers.Names{}
+	}
+	if len(names) > 0 {
+		logging.Info("Speaker names of %s: %d", series, len(names))
+	}
+	return names, nil
+}
+
+// speakerName returns the name given to the source label of a segment
+func (a *App) speakerName(label string) string {
+	a.speakersMu.Lock()
+	defer a.speakersMu.Unlock()
+	return a.speakerNames.Name(label)
+}
+
+// renameSpeaker names the speaker shown as from to in the transcript, the
+// stored session and the segments still to come, and remembers the name for
+// the meeting series
+func (a *App) renameSpeaker(from, to string) error {
+	if strings.ContainsAny(to, "=[]\n") {
+		return errors.New("speaker names cannot contain =, [ or ]")
+	}
+
+	a.speakersMu.Lock()
+	names := maps.Clone(a.speakerNames)
+	a.speakersMu.Unlock()
+	labels := names.Rename(from, to)
+
+	if a.series != "" {
+		all, err := speakers.Load(speakersFile)
+		if err != nil {
+			return fmt.Errorf("failed to read speaker names: %w", err)
+		}
+		all[a.series] = names
+		if err := speakers.Save(speakersFile, all); err != nil {
+			return fmt.Errorf("failed to save speaker names: %w", err)
+		}
+		logging.Info("Remembered %s as %s for %s", strings.Join(labels, ", "), to, a.series)
+	}
+
+	a.speakersMu.Lock()
+	a.speakerNames = names
+	a.speakersMu.Unlock()
+
+	n := a.session.RenameSource(from, to)
+	logging.Info("Renamed speaker %s to %s (%d segments)", from, to, n)
+	if id := a.session.ID(); a.db != nil && id != 0 {
+		if err := a.db.RenameSource(id, from, to); err != nil {
+			logging.Error("Failed to rename speaker in the database: %v", err)
+		}
+	}
+	return nil
+}
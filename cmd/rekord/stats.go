@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/exler/rekord/internal/stats"
+)
+
+// runStats implements the stats subcommand, which summarizes pipeline
+// performance from the chunk timing recorded in a session log
+func runStats(args []string) int {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	fromLog := fs.String("from-log", "", "Session log to analyze (default: most recent log in -logdir)")
+	dir := fs.String("logdir", logDir, "Directory for log files")
+	fs.Parse(args)
+
+	path := *fromLog
+	if path == "" {
+		var err error
+		path, err = latestLog(*dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening log: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	timings, err := stats.ParseLog(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading log: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Log: %s\n\n", path)
+	stats.Summarize(timings).Write(os.Stdout)
+	return 0
+}
+
+// latestLog returns the most recent rekord log file in dir
+func latestLog(dir string) (string, error) {
+	// Log names embed a sortable timestamp
+	logs, err := filepath.Glob(filepath.Join(dir, "rekord_*.log"))
+	if err != nil {
+		return "", err
+	}
+	if len(logs) == 0 {
+		return "", fmt.Errorf("no logs found in %s", dir)
+	}
+	return slices.Max(logs), nil
+}
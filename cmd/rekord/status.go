@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/exler/rekord/internal/control"
+)
+
+// runStatus implements the status subcommand, which prints the state of a
+// running instance for embedding in status bars
+func runStatus(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	format := fs.String("format", "plain", "Output format: plain, json or waybar")
+	socket := fs.String("socket", control.DefaultSocketPath(), "Control socket of the running instance")
+	fs.Parse(args)
+
+	// A missing instance is a normal state for a status bar, not an error
+	var st control.Status
+	running := false
+	if resp, err := control.Send(*socket, "status"); err == nil && resp.OK {
+		if err := json.Unmarshal(resp.Data, &st); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid status: %v\n", err)
+			return 1
+		}
+		running = true
+	}
+
+	switch *format {
+	case "plain":
+		fmt.Println(plainStatus(running, st))
+	case "json":
+		json.NewEncoder(os.Stdout).Encode(struct {
+			Running bool    `json:"running"`
+			Elapsed float64 `json:"elapsed_seconds"`
+			control.Status
+		}{running, st.Elapsed.Seconds(), st})
+	case "waybar":
+		class := "offline"
+		switch {
+		case running && st.Recording:
+			class = "recording"
+		case running:
+			class = "stopped"
+		}
+		tooltip := "rekord is not running"
+		if running {
+			tooltip = fmt.Sprintf("Device: %s\nModel: %s\nSegments: %d", st.Device, st.Model, st.Segments)
+		}
+		json.NewEncoder(os.Stdout).Encode(map[string]string{
+			"text":    plainStatus(running, st),
+			"tooltip": tooltip,
+			"class":   class,
+			"alt":     class,
+		})
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q, expected plain, json or waybar\n", *format)
+		return 2
+	}
+	return 0
+}
+
+// plainStatus renders a one-line status such as "● REC 00:12:34 · 42 segments"
+func plainStatus(running bool, st control.Status) string {
+	switch {
+	case !running:
+		return ""
+	case st.Recording:
+		return fmt.Sprintf("● REC %s · %d segments", formatClock(st.Elapsed), st.Segments)
+	default:
+		return fmt.Sprintf("○ %d segments", st.Segments)
+	}
+}
+
+// formatClock formats a duration as hh:mm:ss
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
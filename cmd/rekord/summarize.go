@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/exler/rekord/internal/summary"
+	"github.com/exler/rekord/internal/transcript"
+)
+
+// runSummarize implements the summarize subcommand, which prints key points
+// and action items for an existing transcript file
+func runSummarize(args []string) int {
+	fs := flag.NewFlagSet("summarize", flag.ExitOnError)
+	points := fs.Int("points", 3, "Number of key points to extract")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rekord summarize [flags] <transcript>\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening transcript: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	text, err := transcript.ReadText(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading transcript: %v\n", err)
+		return 1
+	}
+
+	keyPoints, err := summary.Extractive{}.Summarize(text, *points)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error summarizing transcript: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Reading time: ~%d min\n", summary.Minutes(summary.ReadingTime(text)))
+
+	fmt.Printf("\nSummary:\n")
+	for _, p := range keyPoints {
+		fmt.Printf("  - %s\n", p)
+	}
+
+	fmt.Printf("\nAction items:\n")
+	items := summary.ActionItems(text)
+	if len(items) == 0 {
+		fmt.Printf("  (none found)\n")
+	}
+	for _, item := range items {
+		fmt.Printf("  - %s\n", item)
+	}
+
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if chapters := summary.Chapters(lines, summary.MinChapterLines); len(chapters) > 1 {
+		fmt.Printf("\nChapters:\n")
+		for _, c := range chapters {
+			fmt.Printf("  - %s (from line %d: %q)\n", c.Heading, c.Start+1, lines[c.Start])
+		}
+	}
+	return 0
+}
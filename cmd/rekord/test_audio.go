@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/exler/rekord/internal/audio"
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+const (
+	// levelBarWidth is the width of the live level meter in characters
+	levelBarWidth = 30
+	// levelRefresh is how often the live level meter is redrawn
+	levelRefresh = 100 * time.Millisecond
+)
+
+// runTestAudio implements the test-audio subcommand, which records a few
+// seconds from each configured device while showing its level, then
+// reports whether the device is usable and optionally plays the recording
+// back or transcribes it
+func runTestAudio(args []string) int {
+	fs := flag.NewFlagSet("test-audio", flag.ExitOnError)
+	fs.StringVar(&deviceName, "device", "", "System audio device name (leave empty for default monitor)")
+	fs.StringVar(&micDevice, "mic", "", "Microphone device name (leave empty for default input)")
+	fs.BoolVar(&noMic, "no-mic", false, "Only test system audio")
+	duration := fs.Duration("duration", 5*time.Second, "How long to record from each device")
+	play := fs.Bool("play", false, "Play each recording back")
+	transcribe := fs.Bool("transcribe", false, "Transcribe each recording with the configured backend")
+	fs.StringVar(&modelPath, "model", modelPath, "Path to the whisper model file")
+	fs.StringVar(&backendName, "backend", backendName, "Transcription backend: cli, cgo, openai, deepgram or fake")
+	fs.StringVar(&serverAddr, "server", serverAddr, "Transcribe on a rekord model server (host:port, or auto)")
+	fs.StringVar(&serverKey, "server-key", serverKey, "API key of the -server (default $REKORD_API_KEY)")
+	fs.DurationVar(&serverWait, "server-wait", serverWait, "How long a chunk may wait for a -server busy with other clients")
+	fs.StringVar(&configFile, "config", configFile, "Config file with the defaults of the flags")
+	fs.Parse(args)
+
+	if err := applyConfig(fs); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	type device struct{ label, name string }
+	var devices []device
+
+	system := deviceName
+	if system == "" {
+		var err error
+		system, err = audio.GetDefaultMonitorSource()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting default audio monitor: %v\n", err)
+			return 1
+		}
+	}
+	devices = append(devices, device{"System audio", system})
+
+	if !noMic {
+		mic := micDevice
+		if mic == "" {
+			var err error
+			mic, err = audio.GetDefaultInputSource()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Could not find default microphone: %v\n", err)
+			}
+		}
+		if mic != "" {
+			devices = append(devices, device{"Microphone", mic})
+		}
+	}
+
+	var backend transcriber.Backend
+	if *transcribe {
+		var err error
+		backend, err = newBackend()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating transcription backend: %v\n", err)
+			return 1
+		}
+		defer backend.Close()
+	}
+
+	status := 0
+	for i, d := range devices {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s: %s\n", d.label, d.name)
+		if d.label == "Microphone" {
+			fmt.Printf("Speak into the microphone for %s...\n", *duration)
+		} else {
+			fmt.Printf("Play something through your speakers for %s...\n", *duration)
+		}
+
+		samples, err := recordLevels(d.name, *duration, func(l audio.Level) {
+			fmt.Printf("\r  %s", levelBar(l))
+		})
+		fmt.Println()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error recording from %s: %v\n", d.name, err)
+			status = 1
+			continue
+		}
+		if !reportLevel(samples) {
+			status = 1
+			continue
+		}
+
+		if *play {
+			fmt.Println("Playing back...")
+			if err := audio.Play(samples); err != nil {
+				fmt.Fprintf(os.Stderr, "Error playing recording: %v\n", err)
+			}
+		}
+		if backend != nil {
+			fmt.Println("Transcribing...")
+			ctx, cancel := context.WithTimeout(context.Background(), transcriber.TimeoutFor(backend, len(samples)))
+			segments, err := backend.Transcribe(ctx, samples)
+			cancel()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error transcribing recording: %v\n", err)
+				status = 1
+				continue
+			}
+			if len(segments) == 0 {
+				fmt.Println("  (no speech recognized)")
+			}
+			for _, seg := range segments {
+				fmt.Printf("  %s\n", seg.Text)
+			}
+		}
+	}
+	return status
+}
+
+// recordLevels captures from device for d, passing the level to onLevel
+// every levelRefresh while it records, and returns the captured samples
+func recordLevels(device string, d time.Duration, onLevel func(audio.Level)) ([]float32, error) {
+	var (
+		mu      sync.Mutex
+		samples []float32
+		level   = audio.Level{RMS: audio.MinDBFS, Peak: audio.MinDBFS}
+	)
+	meter := audio.NewMeter()
+	capture, err := audio.NewCapture(device, func(s []float32) {
+		mu.Lock()
+		defer mu.Unlock()
+		samples = append(samples, s...)
+		level = meter.Process(s)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := capture.Start(); err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(levelRefresh)
+	defer ticker.Stop()
+	deadline := time.After(d)
+recording:
+	for {
+		select {
+		case <-ticker.C:
+			mu.Lock()
+			l := level
+			mu.Unlock()
+			onLevel(l)
+		case <-deadline:
+			break recording
+		}
+	}
+	capture.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	return samples, nil
+}
+
+// levelBar renders a meter reading as a bar with its RMS and peak levels
+func levelBar(l audio.Level) string {
+	filled := int((l.RMS - audio.MinDBFS) / -audio.MinDBFS * levelBarWidth)
+	filled = min(max(filled, 0), levelBarWidth)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", levelBarWidth-filled)
+	clip := "    "
+	if l.Clipping {
+		clip = "CLIP"
+	}
+	return fmt.Sprintf("%s %6.1f dBFS  peak %6.1f %s", bar, l.RMS, l.Peak, clip)
+}
+
+// reportLevel prints whether a recording is usable for transcription and
+// reports false if no audio arrived at all
+func reportLevel(samples []float32) bool {
+	if len(samples) == 0 {
+		fmt.Println("✗ No audio received; check that the device exists and is not suspended")
+		return false
+	}
+	l := audio.NewMeter().Process(samples)
+	switch {
+	case l.Clipping:
+		fmt.Printf("! Clipping (peak %.1f dBFS); lower the input volume to avoid distorted transcripts\n", l.Peak)
+	case l.RMS < audio.SpeechDBFS:
+		fmt.Printf("! Very quiet (%.1f dBFS); check that the device is not muted and is the one your meeting app uses\n", l.RMS)
+	default:
+		fmt.Printf("✓ Level OK (%.1f dBFS)\n", l.RMS)
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/exler/rekord/internal/audio"
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// Release builds set these with -ldflags "-X main.version=v1.2.0 -X
+// main.commit=<sha> -X main.buildDate=<RFC 3339 time>"; otherwise they are
+// taken from the Go build info
+var (
+	version   string
+	commit    string
+	buildDate string
+)
+
+// buildVersion returns the version, commit and build date of this binary.
+// A commit built with uncommitted changes ends in -dirty.
+func buildVersion() (v, rev, date string) {
+	v, rev, date = version, commit, buildDate
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return v, rev, date
+	}
+	if v == "" && info.Main.Version != "(devel)" {
+		v = info.Main.Version
+	}
+	var modified bool
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if rev == "" {
+				rev = s.Value
+			}
+		case "vcs.time":
+			if date == "" {
+				date = s.Value
+			}
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if rev != "" && commit == "" && modified {
+		rev += "-dirty"
+	}
+	if v == "" {
+		v = "dev"
+	}
+	return v, rev, date
+}
+
+// runVersion implements the version subcommand, which prints what support
+// needs to know about this build and the transcription backends it can use
+func runVersion(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	short := fs.Bool("short", false, "Print only the version")
+	fs.Parse(args)
+
+	v, rev, date := buildVersion()
+	if *short {
+		fmt.Println(v)
+		return 0
+	}
+
+	fmt.Printf("rekord %s\n", v)
+	fmt.Printf("commit:    %s\n", orUnknown(rev))
+	fmt.Printf("built:     %s\n", orUnknown(date))
+	fmt.Printf("go:        %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Printf("capture:   %s\n", audio.CaptureBackend)
+
+	whisper := transcriber.FindWhisperExecutable()
+	if whisper == "" {
+		fmt.Println("whisper:   not found")
+	} else {
+		details := []string{"version " + orUnknown(transcriber.WhisperVersion(whisper))}
+		if gpus := transcriber.DetectAcceleration(whisper); len(gpus) > 0 {
+			details = append(details, strings.Join(gpus, ", "))
+		} else {
+			details = append(details, "CPU only")
+		}
+		fmt.Printf("whisper:   %s (%s)\n", whisper, strings.Join(details, ", "))
+	}
+
+	fmt.Println("backends:")
+	backend := func(name, status string) {
+		fmt.Printf("  %-9s %s\n", name, status)
+	}
+	if whisper != "" {
+		backend("cli", "available")
+	} else {
+		backend("cli", "whisper executable not found (rekord install-whisper)")
+	}
+	if transcriber.WhisperCgoAvailable {
+		backend("cgo", "available")
+	} else {
+		backend("cgo", "not built in (-tags whisper_cgo)")
+	}
+	for _, cloud := range []struct{ name, env string }{
+		{"openai", transcriber.OpenAIKeyEnv},
+		{"deepgram", transcriber.DeepgramKeyEnv},
+	} {
+		if os.Getenv(cloud.env) != "" {
+			backend(cloud.name, "available")
+		} else {
+			backend(cloud.name, cloud.env+" not set")
+		}
+	}
+	backend("server", "available with -server")
+	backend("fake", "available")
+	return 0
+}
+
+// orUnknown returns s, or "unknown" if it is empty
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/exler/rekord/internal/alert"
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// watchPollInterval is how often the watched directory is scanned
+const watchPollInterval = 2 * time.Second
+
+// watchedFile is the last seen state of a media file in the watched
+// directory
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+	// since is when the size and modification time were last seen changing
+	since time.Time
+	// done is set once the file was transcribed or failed
+	done bool
+}
+
+// runWatch implements the watch subcommand, which transcribes audio and
+// video files as they appear in a directory, e.g. where OBS or Zoom save
+// recordings
+func runWatch(args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	settle := fs.Duration("settle", 10*time.Second, "How long a new file must stay unchanged before it is transcribed")
+	existing := fs.Bool("existing", false, "Also transcribe files already in the directory that have no transcript")
+	notify := fs.Bool("notify", true, "Show a desktop notification when a file is transcribed")
+	fs.StringVar(&modelPath, "model", modelPath, "Path to the whisper model file")
+	fs.StringVar(&backendName, "backend", backendName, "Transcription backend: cli, cgo, openai, deepgram or fake")
+	fs.StringVar(&serverAddr, "server", serverAddr, "Transcribe on a rekord model server (host:port, or auto)")
+	fs.StringVar(&serverKey, "server-key", serverKey, "API key of the -server (default $REKORD_API_KEY)")
+	fs.DurationVar(&serverWait, "server-wait", serverWait, "How long a chunk may wait for a -server busy with other clients")
+	fs.StringVar(&language, "language", language, "Spoken language code passed to whisper")
+	fs.IntVar(&whisperThreads, "whisper-threads", 0, "Threads per whisper process (0 = whisper default)")
+	fs.BoolVar(&exportSRT, "srt", false, "Also write SRT subtitles next to each file")
+	fs.BoolVar(&exportMarkdown, "markdown", false, "Also write Markdown next to each file")
+	fs.BoolVar(&exportJSON, "json", false, "Also write JSON next to each file")
+	fs.Func("json-format", "Schema of the JSON export: rekord (default), aws or gcp", parseJSONFormat)
+	fs.BoolVar(&exportHTML, "html", false, "Also write an HTML page next to each file that plays it, seeking to a segment when it is clicked")
+	fs.BoolVar(&exportDOCX, "docx", false, "Also write a Word document formatted as meeting minutes next to each file")
+	fs.BoolVar(&exportPDF, "pdf", false, "Also write a PDF document formatted as meeting minutes next to each file")
+	fs.BoolVar(&embedSubtitles, "embed-srt", false, "Remux the SRT subtitles into each video file, replacing its subtitle tracks (implies -srt)")
+	addTimeFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rekord watch [flags] <directory>\n\n")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&configFile, "config", configFile, "Config file with the defaults of the flags")
+	fs.Parse(args)
+
+	if err := applyConfig(fs); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	dir := fs.Arg(0)
+	timeFormat.Mode = transcriber.Elapsed
+
+	files := make(map[string]*watchedFile)
+	if err := scanWatched(dir, files); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading directory: %v\n", err)
+		return 1
+	}
+	// Files that were there before are left alone unless asked for; they
+	// count as settled right away
+	for path, f := range files {
+		_, err := os.Stat(transcriptFor(path))
+		f.done = !*existing || err == nil
+		f.since = time.Time{}
+	}
+
+	backend, err := newBackend()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating transcription backend: %v\n", err)
+		return 1
+	}
+	defer backend.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("Watching %s for new recordings (Ctrl+C to stop)\n", dir)
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+	for {
+		for path, f := range files {
+			if f.done || time.Since(f.since) < *settle {
+				continue
+			}
+			f.done = true
+			transcribeWatched(ctx, backend, path, *notify)
+			// Embedding subtitles rewrites the file, which is not a new recording
+			if info, err := os.Stat(path); err == nil {
+				f.size, f.modTime = info.Size(), info.ModTime()
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Println("Stopped watching")
+			return 0
+		case <-ticker.C:
+		}
+		if err := scanWatched(dir, files); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading directory: %v\n", err)
+		}
+	}
+}
+
+// scanWatched updates files with the media files in dir. A file that was
+// transcribed and is then written again, e.g. a recording that is resumed,
+// is transcribed again once it settles.
+func scanWatched(dir string, files map[string]*watchedFile) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !isMediaFile(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		f, ok := files[path]
+		if !ok {
+			files[path] = &watchedFile{size: info.Size(), modTime: info.ModTime(), since: time.Now()}
+			continue
+		}
+		if f.size != info.Size() || !f.modTime.Equal(info.ModTime()) {
+			f.size, f.modTime, f.since = info.Size(), info.ModTime(), time.Now()
+			f.done = false
+		}
+	}
+	return nil
+}
+
+// transcribeWatched transcribes one settled file and reports the outcome on
+// stdout and, with notify, as a desktop notification
+func transcribeWatched(ctx context.Context, backend transcriber.Backend, path string, notify bool) {
+	fmt.Printf("Transcribing %s\n", path)
+	// A file that was written again must not be skipped for its old transcript
+	out, _, err := transcribeToFile(ctx, backend, path, true, func(float64) {})
+	if ctx.Err() != nil {
+		return
+	}
+
+	title, body := "Transcript ready", filepath.Base(out)
+	if err != nil {
+		fmt.Printf("✗ %s: %v\n", path, err)
+		title, body = "Transcription failed", fmt.Sprintf("%s: %v", filepath.Base(path), err)
+	} else {
+		fmt.Printf("✓ %s → %s\n", path, out)
+	}
+	if notify {
+		if err := alert.Notify(title, body); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to show notification: %v\n", err)
+		}
+	}
+}
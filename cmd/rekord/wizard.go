@@ -0,0 +1,193 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/exler/rekord/internal/audio"
+	"github.com/exler/rekord/internal/config"
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/transcriber"
+	"github.com/exler/rekord/internal/ui"
+)
+
+const (
+	// wizardTestDuration is how long the setup wizard records from each
+	// device it tests
+	wizardTestDuration = 4 * time.Second
+	// wizardOutput is the output directory the setup wizard suggests
+	wizardOutput = "~/Transcripts"
+	// wizardModel is the model the setup wizard suggests
+	wizardModel = "base.en"
+)
+
+// wizardModels are the whisper models the setup wizard offers
+var wizardModels = []ui.WizardModel{
+	{Name: "tiny.en", Size: "75 MB", Description: "Fastest, for slow machines; English only"},
+	{Name: "base.en", Size: "142 MB", Description: "Fast and fine for clear speech; English only"},
+	{Name: "base", Size: "142 MB", Description: "Fast, multilingual"},
+	{Name: "small.en", Size: "466 MB", Description: "More accurate, needs a recent CPU; English only"},
+	{Name: "small", Size: "466 MB", Description: "More accurate, multilingual"},
+	{Name: "medium", Size: "1.5 GB", Description: "Accurate, best with a GPU; multilingual"},
+	{Name: "large-v3-turbo", Size: "1.6 GB", Description: "Most accurate, needs a GPU; multilingual"},
+}
+
+// needsSetupWizard reports whether rekord runs for the first time, with
+// neither a config file nor a model, in a terminal the setup wizard can run
+// in
+func needsSetupWizard() bool {
+	if config.Exists(configFile) || simulateFile != "" || missingSetup().Model == "" {
+		return false
+	}
+	explicit := false
+	flag.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "model" })
+	if explicit {
+		return false
+	}
+	for _, f := range []*os.File{os.Stdin, os.Stdout} {
+		if info, err := f.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// runSetupWizard runs the setup wizard and saves the choices to the -config
+// file. It reports false if the wizard was cancelled.
+func runSetupWizard() (bool, error) {
+	opts := ui.WizardOptions{
+		Whisper:    missingSetup().Whisper,
+		ConfigPath: configFile,
+		Initial: ui.WizardChoices{
+			Model:  wizardModel,
+			Device: deviceName,
+			Mic:    micDevice,
+			NoMic:  noMic,
+			Output: outputDir,
+		},
+	}
+	if flag.Lookup("output").DefValue == outputDir {
+		opts.Initial.Output = wizardOutput
+	}
+
+	modelsDir := transcriber.GetModelsDir()
+	current := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(modelPath), "ggml-"), ".bin")
+	for _, m := range wizardModels {
+		m.Installed = transcriber.ModelExists(modelFile(modelsDir, m.Name))
+		if m.Name == current {
+			opts.Initial.Model = current
+		}
+		opts.Models = append(opts.Models, m)
+	}
+
+	sources, err := audio.ListMonitorSources()
+	if err != nil {
+		logging.Warn("Could not list audio devices for the setup wizard: %v", err)
+	}
+	for _, s := range sources {
+		d := ui.WizardDevice{Name: s.Name, Description: s.Description}
+		if s.IsMonitor {
+			opts.Monitors = append(opts.Monitors, d)
+		} else {
+			opts.Inputs = append(opts.Inputs, d)
+		}
+	}
+	opts.DefaultDevice, _ = audio.GetDefaultMonitorSource()
+	opts.DefaultMic, _ = audio.GetDefaultInputSource()
+
+	var program *tea.Program
+	opts.Test = func(device string, mic bool) error {
+		if device == "" {
+			var err error
+			if mic {
+				device, err = audio.GetDefaultInputSource()
+			} else {
+				device, err = audio.GetDefaultMonitorSource()
+			}
+			if err != nil {
+				return err
+			}
+		}
+		go func() {
+			samples, err := recordLevels(device, wizardTestDuration, func(l audio.Level) {
+				program.Send(ui.WizardLevelMsg{Level: l})
+			})
+			msg := ui.WizardTestMsg{Received: len(samples) > 0, Err: err}
+			if msg.Received {
+				msg.Level = audio.NewMeter().Process(samples)
+			}
+			program.Send(msg)
+		}()
+		return nil
+	}
+	opts.CheckOutput = func(dir string) error {
+		return os.MkdirAll(config.ExpandHome(dir), 0755)
+	}
+	opts.Install = func(c ui.WizardChoices) error {
+		model := modelFile(modelsDir, c.Model)
+		if transcriber.ModelExists(model) {
+			model = ""
+		}
+		startInstall(missingSetup().Whisper, model, program.Send)
+		return nil
+	}
+
+	program = tea.NewProgram(ui.NewWizard(opts))
+	final, err := program.Run()
+	if err != nil {
+		return false, fmt.Errorf("setup wizard failed: %w", err)
+	}
+	choices, ok := final.(ui.Wizard).Choices()
+	if !ok {
+		return false, nil
+	}
+
+	// Empty values remove the flag, so the defaults apply
+	disableMic := ""
+	if choices.NoMic {
+		disableMic = "true"
+	}
+	settings := []config.Setting{
+		{Flag: "model", Value: modelFile(modelsDir, choices.Model)},
+		{Flag: "device", Value: choices.Device},
+		{Flag: "mic", Value: choices.Mic},
+		{Flag: "no-mic", Value: disableMic},
+		{Flag: "output", Value: choices.Output},
+	}
+	if err := config.Update(configFile, settings); err != nil {
+		return false, fmt.Errorf("failed to save config to %s: %w", configFile, err)
+	}
+	logging.Info("Setup wizard saved config to %s", configFile)
+	return true, nil
+}
+
+// runSetup implements the setup subcommand, which runs the setup wizard to
+// choose the model, devices and output directory, prefilled from the
+// current config
+func runSetup(args []string) int {
+	fs := flag.NewFlagSet("setup", flag.ExitOnError)
+	fs.StringVar(&configFile, "config", configFile, "Config file to save the choices to")
+	fs.Parse(args)
+
+	if err := applyConfig(fs); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	saved, err := runSetupWizard()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if !saved {
+		fmt.Println("Setup cancelled, nothing was saved")
+		return 0
+	}
+	fmt.Printf("Saved settings to %s. Run rekord to start recording.\n", configFile)
+	return 0
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/exler/rekord/internal/alert"
+	"github.com/exler/rekord/internal/audio"
+	"github.com/exler/rekord/internal/session"
+	"github.com/exler/rekord/internal/transcriber"
+	"github.com/exler/rekord/internal/ui"
+)
+
+// primaryWorkspace names the workspace recording -device and -mic when
+// -workspace adds others
+const primaryWorkspace = "main"
+
+// workspaceSpec is a workspace added with -workspace
+type workspaceSpec struct {
+	name   string
+	device string
+	mic    string
+}
+
+// parseWorkspace parses a -workspace flag value, name=device[,mic], and
+// adds the workspace
+func parseWorkspace(s string) error {
+	name, devices, ok := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || devices == "" {
+		return fmt.Errorf("expected name=device[,mic], got %q", s)
+	}
+	if strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("workspace name %q must not contain slashes", name)
+	}
+	if name == primaryWorkspace {
+		return fmt.Errorf("workspace name %q is taken by the workspace recording -device and -mic", name)
+	}
+	for _, w := range workspaces {
+		if w.name == name {
+			return fmt.Errorf("workspace %q given twice", name)
+		}
+	}
+	device, mic, _ := strings.Cut(devices, ",")
+	workspaces = append(workspaces, workspaceSpec{
+		name:   name,
+		device: strings.TrimSpace(device),
+		mic:    strings.TrimSpace(mic),
+	})
+	return nil
+}
+
+// messenger delivers messages to the UI of an app
+type messenger interface {
+	Send(msg tea.Msg)
+}
+
+// workspaceProgram delivers messages to one workspace of the program
+type workspaceProgram struct {
+	*tea.Program
+	index int
+}
+
+// Send implements messenger
+func (p workspaceProgram) Send(msg tea.Msg) {
+	p.Program.Send(ui.WorkspaceMsg{Index: p.index, Msg: msg})
+}
+
+// workspace creates an app recording the devices of spec into a session of
+// its own. It shares the transcription backend, filters, database, feed,
+// overlay, metrics and translator of a.
+func (a *App) workspace(spec workspaceSpec, missing ui.Setup, initialPrompt string) (*App, error) {
+	w := &App{
+		name:        spec.name,
+		device:      spec.device,
+		mic:         spec.mic,
+		ctx:         a.ctx,
+		cancel:      a.cancel,
+		backend:     a.backend,
+		feed:        a.feed,
+		overlay:     a.overlay,
+		metrics:     a.metrics,
+		db:          a.db,
+		watcher:     alert.NewWatcher(alert.ParseWords(watchWords)),
+		translator:  a.translator,
+		meter:       audio.NewMeter(),
+		filter:      a.filter,
+		corrections: a.corrections,
+		summarizer:  a.summarizer,
+		session:     session.New(),
+		series:      spec.name,
+	}
+	w.session.SetModel(a.session.Metadata().Model)
+	if w.translator != nil {
+		w.toTranslate = make(chan transcriber.Segment, translationBacklog)
+	}
+	var err error
+	if w.speakerNames, err = loadSpeakerNames(w.series); err != nil {
+		return nil, err
+	}
+	w.pipeline, err = w.newPipeline()
+	if err != nil {
+		return nil, err
+	}
+	w.model = w.newModel(missing, initialPrompt)
+	return w, nil
+}
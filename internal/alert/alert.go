@@ -0,0 +1,62 @@
+// Package alert watches transcript segments for user-configured watch-words
+// and raises desktop notifications when one is spoken
+package alert
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Watcher matches watch-words in text, ignoring case and matching whole
+// words only
+type Watcher struct {
+	pattern *regexp.Regexp
+}
+
+// ParseWords splits a comma-separated watch-word list, dropping blanks
+func ParseWords(list string) []string {
+	var words []string
+	for word := range strings.SplitSeq(list, ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// NewWatcher creates a watcher for the given words. It returns nil if there
+// are no words, and a nil watcher never matches.
+func NewWatcher(words []string) *Watcher {
+	if len(words) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = regexp.QuoteMeta(w)
+	}
+	return &Watcher{
+		pattern: regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`),
+	}
+}
+
+// Match returns the watch-words found in text, in order of appearance
+func (w *Watcher) Match(text string) []string {
+	if w == nil {
+		return nil
+	}
+	return w.pattern.FindAllString(text, -1)
+}
+
+// Highlight returns text with every watch-word passed through style
+func (w *Watcher) Highlight(text string, style func(string) string) string {
+	if w == nil {
+		return text
+	}
+	return w.pattern.ReplaceAllStringFunc(text, style)
+}
+
+// Notify shows a desktop notification using notify-send
+func Notify(title, body string) error {
+	return exec.Command("notify-send", "--app-name=rekord", title, body).Run()
+}
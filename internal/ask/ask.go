@@ -0,0 +1,40 @@
+package ask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// systemPrompt keeps the model to the retrieved passages
+const systemPrompt = `You answer questions about the user's meetings using only the numbered transcript excerpts provided.
+Transcripts are automatic speech recognition output and may contain errors.
+Cite the excerpts you rely on by their number in brackets, e.g. [2].
+If the excerpts do not contain the answer, say so instead of guessing. Answer concisely.`
+
+// Answer asks the chat model to answer question from passages
+func (c *Client) Answer(ctx context.Context, question string, passages []Passage) (string, error) {
+	if len(passages) == 0 {
+		return "", errors.New("no transcript passages to answer from")
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Transcript excerpts:\n\n")
+	for i, p := range passages {
+		fmt.Fprintf(&prompt, "[%d] %s\n%s\n\n", i+1, Source(p), p.Text)
+	}
+	fmt.Fprintf(&prompt, "Question: %s", question)
+	return c.Complete(ctx, systemPrompt, prompt.String())
+}
+
+// Source names where a passage is from: the transcript file and the time
+// of its first line
+func Source(p Passage) string {
+	name := filepath.Base(p.Path)
+	if p.Time == "" {
+		return name
+	}
+	return name + " at " + p.Time
+}
@@ -0,0 +1,170 @@
+// Package ask answers questions about saved transcripts: passages are
+// embedded into a local index, the ones closest to the question are
+// retrieved and a language model answers from them. Models are reached over
+// the OpenAI API, which local runners such as Ollama, llama.cpp and LM
+// Studio also speak.
+package ask
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultURL is the OpenAI-compatible API of a local Ollama
+	DefaultURL = "http://localhost:11434/v1"
+
+	// DefaultEmbedModel and DefaultChatModel are small models Ollama can
+	// run on a laptop
+	DefaultEmbedModel = "nomic-embed-text"
+	DefaultChatModel  = "llama3.2"
+
+	// APIKeyEnv names the environment variable holding the API key
+	APIKeyEnv = "OPENAI_API_KEY"
+
+	// requestTimeout is generous since local models answer slowly
+	requestTimeout = 5 * time.Minute
+)
+
+// Config holds the model configuration
+type Config struct {
+	// URL is the base URL of the API (default DefaultURL)
+	URL string
+	// APIKey overrides the key read from the environment
+	APIKey string
+	// EmbedModel and ChatModel name the models to use
+	EmbedModel string
+	ChatModel  string
+}
+
+// Client talks to the embedding and chat models
+type Client struct {
+	url        string
+	apiKey     string
+	embedModel string
+	chatModel  string
+	client     *http.Client
+}
+
+// NewClient creates a client for cfg, filling in the defaults
+func NewClient(cfg Config) *Client {
+	c := &Client{
+		url:        strings.TrimSuffix(cfg.URL, "/"),
+		apiKey:     cfg.APIKey,
+		embedModel: cfg.EmbedModel,
+		chatModel:  cfg.ChatModel,
+		client:     &http.Client{Timeout: requestTimeout},
+	}
+	if c.url == "" {
+		c.url = DefaultURL
+	}
+	if c.apiKey == "" {
+		c.apiKey = os.Getenv(APIKeyEnv)
+	}
+	if c.embedModel == "" {
+		c.embedModel = DefaultEmbedModel
+	}
+	if c.chatModel == "" {
+		c.chatModel = DefaultChatModel
+	}
+	return c
+}
+
+// EmbedModel returns the name of the embedding model. Vectors of different
+// models cannot be compared.
+func (c *Client) EmbedModel() string {
+	return c.embedModel
+}
+
+// Embed returns the embedding vector of each text
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	req := map[string]any{
+		"model": c.embedModel,
+		"input": texts,
+	}
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := c.post(ctx, "/embeddings", req, &resp); err != nil {
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding failed: got %d vectors for %d texts", len(resp.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("embedding failed: vector index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// Complete sends a system and a user message to the chat model and returns
+// its reply
+func (c *Client) Complete(ctx context.Context, system, user string) (string, error) {
+	req := map[string]any{
+		"model": c.chatModel,
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": user},
+		},
+		"temperature": 0.2,
+	}
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := c.post(ctx, "/chat/completions", req, &resp); err != nil {
+		return "", fmt.Errorf("chat completion failed: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("chat completion failed: empty response")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// post posts body as JSON to path under the API URL and decodes the JSON
+// response into v
+func (c *Client) post(ctx context.Context, path string, body, v any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,331 @@
+package ask
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	_ "modernc.org/sqlite" // registers the pure Go "sqlite" driver
+
+	"github.com/exler/rekord/internal/transcript"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS files (
+	path  TEXT PRIMARY KEY,
+	mtime INTEGER NOT NULL,
+	size  INTEGER NOT NULL,
+	model TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS passages (
+	path   TEXT NOT NULL,
+	time   TEXT NOT NULL,
+	text   TEXT NOT NULL,
+	vector BLOB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS passages_path ON passages (path);
+`
+
+const (
+	// passageLines is how many transcript lines make up a passage, and
+	// passageOverlap how many of them are repeated in the next one so a
+	// thought cut at a boundary is still found whole
+	passageLines   = 6
+	passageOverlap = 2
+
+	// embedBatch is how many passages are embedded per request
+	embedBatch = 32
+)
+
+// Embedder turns texts into vectors
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	EmbedModel() string
+}
+
+// Passage is a few consecutive lines of a transcript
+type Passage struct {
+	Path string
+	// Time is the timestamp of the first line, if the transcript has one
+	Time string
+	Text string
+	// Score is the cosine similarity to the query of a search
+	Score float64
+}
+
+// Index stores the embedded passages of transcript files
+type Index struct {
+	db *sql.DB
+}
+
+// DefaultIndexPath returns the index location shared by all output
+// directories, ~/.cache/rekord/ask.db
+func DefaultIndexPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "rekord", "ask.db")
+}
+
+// Open opens or creates the index at path
+func Open(path string) (*Index, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create index directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize embeddings index: %w", err)
+	}
+	return &Index{db: db}, nil
+}
+
+// Close closes the index
+func (x *Index) Close() error {
+	return x.db.Close()
+}
+
+// Update embeds the passages of new and changed .txt transcripts in dir and
+// drops removed ones. Files embedded with another model are embedded again.
+// Each file is committed on its own, so an interrupted update keeps the
+// files done so far. progress, if not nil, is called with each file before
+// it is embedded. It returns the number of files embedded.
+func (x *Index) Update(ctx context.Context, dir string, embedder Embedder, progress func(path string)) (int, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	// Files indexed before, to find the ones that were removed
+	known := make(map[string]bool)
+	rows, err := x.db.QueryContext(ctx, `SELECT path FROM files WHERE path LIKE ? ESCAPE '\'`, likePrefix(dir))
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		known[path] = true
+	}
+	rows.Close()
+
+	updated := 0
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".txt" {
+			return nil
+		}
+		delete(known, path)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var mtime, size int64
+		var model string
+		err = x.db.QueryRowContext(ctx, `SELECT mtime, size, model FROM files WHERE path = ?`, path).Scan(&mtime, &size, &model)
+		if err == nil && mtime == info.ModTime().UnixNano() && size == info.Size() && model == embedder.EmbedModel() {
+			return nil
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if progress != nil {
+			progress(path)
+		}
+		if err := x.embedFile(ctx, path, info, embedder); err != nil {
+			return fmt.Errorf("failed to embed %s: %w", path, err)
+		}
+		updated++
+		return nil
+	})
+	if err != nil {
+		return updated, err
+	}
+
+	for path := range known {
+		tx, err := x.db.BeginTx(ctx, nil)
+		if err != nil {
+			return updated, err
+		}
+		if err := removeFile(tx, path); err != nil {
+			tx.Rollback()
+			return updated, err
+		}
+		if err := tx.Commit(); err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+// embedFile replaces the passages of a transcript file
+func (x *Index) embedFile(ctx context.Context, path string, info fs.FileInfo, embedder Embedder) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	lines, err := transcript.ReadLines(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	passages := split(path, lines)
+	vectors := make([][]float32, 0, len(passages))
+	for batch := range slices.Chunk(passages, embedBatch) {
+		texts := make([]string, len(batch))
+		for i, p := range batch {
+			texts[i] = p.Text
+		}
+		v, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+		vectors = append(vectors, v...)
+	}
+
+	tx, err := x.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := removeFile(tx, path); err != nil {
+		return err
+	}
+	for i, p := range passages {
+		if _, err := tx.Exec(`INSERT INTO passages (path, time, text, vector) VALUES (?, ?, ?, ?)`, path, p.Time, p.Text, encodeVector(vectors[i])); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO files (path, mtime, size, model) VALUES (?, ?, ?, ?)`, path, info.ModTime().UnixNano(), info.Size(), embedder.EmbedModel()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// split groups the lines of a transcript into overlapping passages
+func split(path string, lines []transcript.Line) []Passage {
+	var passages []Passage
+	for start := 0; start < len(lines); start += passageLines - passageOverlap {
+		end := min(start+passageLines, len(lines))
+		texts := make([]string, end-start)
+		for i, line := range lines[start:end] {
+			texts[i] = line.Text
+		}
+		passages = append(passages, Passage{Path: path, Time: lines[start].Time, Text: strings.Join(texts, "\n")})
+		if end == len(lines) {
+			break
+		}
+	}
+	return passages
+}
+
+// removeFile drops a file from the index
+func removeFile(tx *sql.Tx, path string) error {
+	if _, err := tx.Exec(`DELETE FROM passages WHERE path = ?`, path); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`DELETE FROM files WHERE path = ?`, path)
+	return err
+}
+
+// Nearest returns the limit passages of transcripts in dir closest to the
+// query vector, closest first
+func (x *Index) Nearest(dir string, query []float32, limit int) ([]Passage, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := x.db.Query(`SELECT path, time, text, vector FROM passages WHERE path LIKE ? ESCAPE '\'`, likePrefix(dir))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var passages []Passage
+	for rows.Next() {
+		var p Passage
+		var blob []byte
+		if err := rows.Scan(&p.Path, &p.Time, &p.Text, &blob); err != nil {
+			return nil, err
+		}
+		p.Score = cosine(query, decodeVector(blob))
+		passages = append(passages, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(passages, func(a, b Passage) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	return passages[:min(limit, len(passages))], nil
+}
+
+// cosine returns the cosine similarity of two vectors, 0 if their lengths
+// differ
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// encodeVector stores a vector as little-endian float32 values
+func encodeVector(v []float32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+// decodeVector reverses encodeVector
+func decodeVector(b []byte) []float32 {
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
+
+// likePrefix returns a LIKE pattern matching paths inside dir
+func likePrefix(dir string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(dir + string(filepath.Separator))
+	return escaped + "%"
+}
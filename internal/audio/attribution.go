@@ -0,0 +1,92 @@
+package audio
+
+import (
+	"sort"
+	"sync"
+)
+
+// Source labels of the capture sources
+const (
+	SourceSystem = "System"
+	SourceMic    = "Mic"
+)
+
+// Attribution remembers which capture source each span of a mixed stream
+// came from and whether someone was talking in it, so text transcribed from
+// the mix can be attributed to a source. Positions are sample offsets into
+// the mixed stream, counted from the first sample added.
+type Attribution struct {
+	mu    sync.Mutex
+	spans []attributedSpan
+	pos   int
+}
+
+// attributedSpan is a run of consecutive samples from one source
+type attributedSpan struct {
+	start, end int
+	source     string
+	loud       bool
+}
+
+// Add records that samples from source were appended to the mixed stream
+func (a *Attribution) Add(source string, samples []float32) {
+	if len(samples) == 0 {
+		return
+	}
+	loud := isSpeech(samples)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	end := a.pos + len(samples)
+	if n := len(a.spans); n > 0 && a.spans[n-1].source == source && a.spans[n-1].loud == loud && a.spans[n-1].end == a.pos {
+		a.spans[n-1].end = end
+	} else {
+		a.spans = append(a.spans, attributedSpan{start: a.pos, end: end, source: source, loud: loud})
+	}
+	a.pos = end
+}
+
+// Dominant returns the source that talked the most between the sample
+// positions from and to. It returns "" if nobody talked, or if only one
+// source contributed to that part of the stream, where a label tells the
+// reader nothing.
+func (a *Attribution) Dominant(from, to int) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	seen := make(map[string]bool)
+	talked := make(map[string]int)
+	i := sort.Search(len(a.spans), func(i int) bool { return a.spans[i].end > from })
+	for _, span := range a.spans[i:] {
+		if span.start >= to {
+			break
+		}
+		seen[span.source] = true
+		if span.loud {
+			talked[span.source] += min(span.end, to) - max(span.start, from)
+		}
+	}
+	if len(seen) < 2 {
+		return ""
+	}
+
+	dominant, most := "", 0
+	for source, n := range talked {
+		switch {
+		case n > most:
+			dominant, most = source, n
+		case n == most:
+			// A tie is as good as not knowing
+			dominant = ""
+		}
+	}
+	return dominant
+}
+
+// Trim forgets the spans that end before the sample position before
+func (a *Attribution) Trim(before int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	i := sort.Search(len(a.spans), func(i int) bool { return a.spans[i].end > before })
+	a.spans = append(a.spans[:0], a.spans[i:]...)
+}
@@ -0,0 +1,160 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+)
+
+// bytesPerSample is the size of a sample in a spill file
+const bytesPerSample = 4
+
+// Buffer is a queue of audio samples that keeps at most a fixed number of
+// samples in memory. Audio beyond that is spilled to a temporary file and
+// read back as the front of the buffer is consumed. Without spilling, the
+// oldest audio is dropped instead. A Buffer is not safe for concurrent use.
+type Buffer struct {
+	mem    []float32
+	maxMem int
+
+	// Samples after mem, stored in the spill file between the two offsets
+	spill     bool
+	file      *os.File
+	readOff   int64
+	writeOff  int64
+	encodeBuf []byte
+}
+
+// NewBuffer returns a buffer keeping up to maxMem samples in memory. With
+// spill set, audio beyond that goes to a temporary file; otherwise the
+// oldest audio is dropped.
+func NewBuffer(maxMem int, spill bool) *Buffer {
+	return &Buffer{maxMem: max(maxMem, SampleRate), spill: spill}
+}
+
+// Len returns the number of buffered samples, in memory and on disk
+func (b *Buffer) Len() int {
+	return len(b.mem) + b.Spilled()
+}
+
+// Spilled returns the number of samples currently on disk
+func (b *Buffer) Spilled() int {
+	return int((b.writeOff - b.readOff) / bytesPerSample)
+}
+
+// Front returns the oldest samples that are in memory. The slice is only
+// valid until the buffer is next modified.
+func (b *Buffer) Front() []float32 {
+	return b.mem
+}
+
+// Append adds samples to the end of the buffer. It returns how many of the
+// oldest samples were dropped to stay within the memory limit. If the spill
+// file cannot be written, the samples are lost and an error is returned.
+func (b *Buffer) Append(samples []float32) (dropped int, err error) {
+	if b.Spilled() == 0 {
+		n := min(len(samples), b.maxMem-len(b.mem))
+		b.mem = append(b.mem, samples[:n]...)
+		samples = samples[n:]
+	}
+	if len(samples) == 0 {
+		return 0, nil
+	}
+
+	if !b.spill {
+		b.mem = append(b.mem, samples...)
+		dropped = len(b.mem) - b.maxMem
+		b.mem = append(b.mem[:0], b.mem[dropped:]...)
+		return dropped, nil
+	}
+	return 0, b.writeSpill(samples)
+}
+
+// Discard removes the first n samples and moves spilled audio back into
+// memory in their place
+func (b *Buffer) Discard(n int) error {
+	n = min(n, b.Len())
+	fromMem := min(n, len(b.mem))
+	b.mem = append(b.mem[:0], b.mem[fromMem:]...)
+	b.readOff += int64(n-fromMem) * bytesPerSample
+
+	if b.Spilled() == 0 {
+		return b.resetSpill()
+	}
+	return b.readSpill()
+}
+
+// Close discards the buffered audio and removes the spill file
+func (b *Buffer) Close() error {
+	b.mem = nil
+	b.readOff, b.writeOff = 0, 0
+	if b.file == nil {
+		return nil
+	}
+	err := b.file.Close()
+	os.Remove(b.file.Name())
+	b.file = nil
+	return err
+}
+
+// writeSpill appends samples to the spill file, creating it on first use
+func (b *Buffer) writeSpill(samples []float32) error {
+	if b.file == nil {
+		f, err := os.CreateTemp("", "rekord-audio-*.f32")
+		if err != nil {
+			return fmt.Errorf("creating audio spill file: %w", err)
+		}
+		b.file = f
+	}
+
+	size := len(samples) * bytesPerSample
+	if cap(b.encodeBuf) < size {
+		b.encodeBuf = make([]byte, size)
+	}
+	buf := b.encodeBuf[:size]
+	for i, s := range samples {
+		binary.LittleEndian.PutUint32(buf[i*bytesPerSample:], math.Float32bits(s))
+	}
+	if _, err := b.file.WriteAt(buf, b.writeOff); err != nil {
+		return fmt.Errorf("writing audio spill file: %w", err)
+	}
+	b.writeOff += int64(size)
+	return nil
+}
+
+// readSpill refills memory from the front of the spill file
+func (b *Buffer) readSpill() error {
+	n := min(b.maxMem-len(b.mem), b.Spilled())
+	if n <= 0 {
+		return nil
+	}
+
+	size := n * bytesPerSample
+	if cap(b.encodeBuf) < size {
+		b.encodeBuf = make([]byte, size)
+	}
+	buf := b.encodeBuf[:size]
+	if _, err := b.file.ReadAt(buf, b.readOff); err != nil {
+		return fmt.Errorf("reading audio spill file: %w", err)
+	}
+	for i := range n {
+		b.mem = append(b.mem, math.Float32frombits(binary.LittleEndian.Uint32(buf[i*bytesPerSample:])))
+	}
+	b.readOff += int64(size)
+
+	if b.Spilled() == 0 {
+		return b.resetSpill()
+	}
+	return nil
+}
+
+// resetSpill empties the spill file once everything in it has been read
+// back, so it does not keep growing over a long session
+func (b *Buffer) resetSpill() error {
+	b.readOff, b.writeOff = 0, 0
+	if b.file == nil {
+		return nil
+	}
+	return b.file.Truncate(0)
+}
@@ -1,14 +1,16 @@
-// Package audio provides system audio capture functionality using PulseAudio/PipeWire
+// Package audio provides system audio capture functionality using PulseAudio/PipeWire,
+// or ALSA and sndio when built with the alsa or sndio tag
 package audio
 
 import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math"
-	"os/exec"
-	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -16,15 +18,41 @@ const (
 	Channels     = 1     // Mono audio
 	FrameSize    = 480   // 30ms frames at 16kHz
 	BufferFrames = 10    // Buffer multiple frames
+
+	// restartDelay is how long to wait between attempts to restart a source
+	// whose capture process exited unexpectedly
+	restartDelay = 2 * time.Second
 )
 
+// DeviceResolver returns the device a lost source should be restarted on.
+// It receives the device that was lost and may return it unchanged.
+type DeviceResolver func(lost string) (string, error)
+
+// ChannelHandler receives the samples of each capture source separately.
+// channel is 0 for mono sources and 0 (left) or 1 (right) for stereo ones.
+type ChannelHandler func(device string, channel int, samples []float32)
+
+// RestartHandler is notified about automatic source restarts. err is non-nil
+// when the source was lost or a restart attempt failed; newDevice is set once
+// capture has resumed.
+type RestartHandler func(oldDevice, newDevice string, err error)
+
 // Source represents a single audio source (monitor or microphone)
 type Source struct {
-	cmd        *exec.Cmd
+	runner     SourceRunner
+	proc       SourceProcess
 	cancel     context.CancelFunc
 	deviceName string
-	stopCh     chan struct{}
+	channels   int
+	stopCh     chan struct{} // replaced on each start, guarded by mu
 	wg         sync.WaitGroup
+	mu         sync.Mutex
+
+	// lastRead is when the source last delivered audio, in Unix nanoseconds,
+	// and stall is set by the watchdog when it kills a source that stopped
+	// delivering
+	lastRead atomic.Int64
+	stall    error
 }
 
 // MultiCapture handles audio capture from multiple sources (system + microphone)
@@ -33,6 +61,16 @@ type MultiCapture struct {
 	mu        sync.Mutex
 	isRunning bool
 	onAudio   func([]float32)
+	onChannel ChannelHandler
+	resolve   DeviceResolver
+	onRestart RestartHandler
+
+	onReplayEnd func()
+
+	stallTimeout time.Duration
+	stopWatchdog chan struct{}
+
+	bytesRead *atomic.Int64 // bytes delivered by the capture programs, nil to not count
 }
 
 // Capture handles audio capture from system audio (single source, kept for compatibility)
@@ -44,90 +82,11 @@ type MonitorSource struct {
 	Description string
 	IsMonitor   bool
 	IsInput     bool
-}
-
-// ListMonitorSources returns available monitor sources for capturing system audio
-func ListMonitorSources() ([]MonitorSource, error) {
-	// Use pactl to list sources and find monitors
-	cmd := exec.Command("pactl", "list", "sources", "short")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to list PulseAudio sources: %w", err)
-	}
-
-	var sources []MonitorSource
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		fields := strings.Fields(line)
-		if len(fields) >= 2 {
-			name := fields[1]
-			isMonitor := strings.Contains(name, ".monitor")
-			// Input sources typically contain "input" or don't have ".monitor"
-			isInput := !isMonitor && (strings.Contains(name, "input") ||
-				strings.Contains(name, "mic") ||
-				strings.Contains(name, "Mic") ||
-				strings.Contains(name, "capture"))
-			sources = append(sources, MonitorSource{
-				Name:        name,
-				Description: name,
-				IsMonitor:   isMonitor,
-				IsInput:     isInput || !isMonitor, // Non-monitors are typically inputs
-			})
-		}
-	}
-
-	return sources, nil
-}
-
-// GetDefaultMonitorSource returns the default output monitor source
-func GetDefaultMonitorSource() (string, error) {
-	// Get default sink and append .monitor
-	cmd := exec.Command("pactl", "get-default-sink")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("failed to get default sink: %w", err)
-	}
-
-	sink := strings.TrimSpace(string(output))
-	if sink == "" {
-		return "", errors.New("no default sink found")
-	}
 
-	return sink + ".monitor", nil
-}
-
-// GetDefaultInputSource returns the default input (microphone) source
-func GetDefaultInputSource() (string, error) {
-	cmd := exec.Command("pactl", "get-default-source")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("failed to get default source: %w", err)
-	}
-
-	source := strings.TrimSpace(string(output))
-	if source == "" {
-		return "", errors.New("no default source found")
-	}
-
-	// Don't return if it's a monitor (we want actual input)
-	if strings.Contains(source, ".monitor") {
-		// Try to find an actual input source
-		sources, err := ListMonitorSources()
-		if err != nil {
-			return "", err
-		}
-		for _, s := range sources {
-			if s.IsInput && !s.IsMonitor {
-				return s.Name, nil
-			}
-		}
-		return "", errors.New("no input source found")
-	}
-
-	return source, nil
+	// SampleRate and Channels are the native format of the device, 0 if
+	// unknown. Capture is resampled to 16kHz either way.
+	SampleRate int
+	Channels   int
 }
 
 // NewCapture creates a new audio capture instance with a single device
@@ -144,7 +103,9 @@ func NewMultiCapture(deviceNames []string, onAudio func([]float32)) (*MultiCaptu
 	sources := make([]*Source, len(deviceNames))
 	for i, name := range deviceNames {
 		sources[i] = &Source{
+			runner:     commandRunner{},
 			deviceName: name,
+			channels:   Channels,
 			stopCh:     make(chan struct{}),
 		}
 	}
@@ -176,68 +137,320 @@ func (c *MultiCapture) Start() error {
 	}
 
 	c.isRunning = true
+	if c.stallTimeout > 0 {
+		c.stopWatchdog = make(chan struct{})
+		go c.watchdog(c.stallTimeout, c.stopWatchdog)
+	}
+	return nil
+}
+
+// SetStereo captures the source at index in stereo. The channels are
+// delivered separately to the channel handler and downmixed to mono for the
+// audio callback. It must be called before Start.
+func (c *MultiCapture) SetStereo(index int) error {
+	if index < 0 || index >= len(c.sources) {
+		return fmt.Errorf("no source at index %d", index)
+	}
+	c.sources[index].channels = 2
 	return nil
 }
 
+// SetSourceRunner makes capture start its sources with runner instead of
+// running the capture program of the build's backend, e.g. to replay audio
+// or to feed the sources from tests. It must be called before Start.
+func (c *MultiCapture) SetSourceRunner(runner SourceRunner) {
+	for _, s := range c.sources {
+		s.runner = runner
+	}
+}
+
+// SetChannelHandler sets the callback receiving per-source, per-channel audio
+func (c *MultiCapture) SetChannelHandler(onChannel ChannelHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onChannel = onChannel
+}
+
+// SetDeviceResolver sets the function used to pick a device when a source is
+// lost. Without a resolver, lost sources are restarted on the same device.
+func (c *MultiCapture) SetDeviceResolver(resolve DeviceResolver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resolve = resolve
+}
+
+// SetStallTimeout makes capture restart sources that deliver no audio for
+// timeout, e.g. because the capture process hangs, through the same path as
+// lost sources. 0 disables the watchdog. It must be called before Start.
+func (c *MultiCapture) SetStallTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stallTimeout = timeout
+}
+
+// SetByteCounter makes capture add the bytes its capture programs deliver to
+// n. It must be called before Start.
+func (c *MultiCapture) SetByteCounter(n *atomic.Int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bytesRead = n
+}
+
+// watchdog kills the capture process of sources that have been silent for
+// longer than timeout, until stop is closed
+func (c *MultiCapture) watchdog(timeout time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		for _, source := range c.sources {
+			last := time.Unix(0, source.lastRead.Load())
+			if time.Since(last) < timeout {
+				continue
+			}
+			source.mu.Lock()
+			// A source being restarted has no process to kill
+			if source.proc != nil && source.stall == nil && !source.stopped() {
+				source.stall = fmt.Errorf("no audio for %s", time.Since(last).Round(time.Second))
+				source.cancel()
+			}
+			source.mu.Unlock()
+		}
+	}
+}
+
+// SetRestartHandler sets the callback notified about automatic source restarts
+func (c *MultiCapture) SetRestartHandler(onRestart RestartHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onRestart = onRestart
+}
+
 // startSource starts a single audio source
 func (c *MultiCapture) startSource(source *Source) error {
-	// Create a new stop channel
-	source.stopCh = make(chan struct{})
+	// Create a new stop channel. The read goroutine keeps its own, so it
+	// is not confused by the next start replacing it.
+	stop := make(chan struct{})
+	source.mu.Lock()
+	source.stopCh = stop
+	source.mu.Unlock()
+
+	stdout, err := source.spawn()
+	if err != nil {
+		return err
+	}
 
-	// Use parec for PulseAudio/PipeWire capture
-	ctx, cancel := context.WithCancel(context.Background())
-	source.cancel = cancel
+	// Start reading audio in a goroutine
+	source.wg.Add(1)
+	go c.readAudioLoop(source, stdout, stop)
+
+	return nil
+}
+
+// spawn starts the capture process for the source's current device
+func (s *Source) spawn() (SourceProcess, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	source.cmd = exec.CommandContext(ctx, "parec",
-		"--format=float32le",
-		"--rate=16000",
-		"--channels=1",
-		"-d", source.deviceName,
-	)
+	if s.stopped() {
+		return nil, errors.New("source stopped")
+	}
 
-	stdout, err := source.cmd.StdoutPipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	proc, err := s.runner.Start(ctx, s.deviceName, s.channels)
 	if err != nil {
 		cancel()
-		return fmt.Errorf("failed to create stdout pipe: %w", err)
+		return nil, err
 	}
 
-	if err := source.cmd.Start(); err != nil {
-		cancel()
-		return fmt.Errorf("failed to start parec: %w", err)
+	s.proc = proc
+	s.cancel = cancel
+	s.lastRead.Store(time.Now().UnixNano())
+	return proc, nil
+}
+
+// stopped reports whether the source has been asked to stop. Must be called
+// with s.mu held.
+func (s *Source) stopped() bool {
+	return closed(s.stopCh)
+}
+
+// closed reports whether the stop channel stop is closed
+func closed(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
 	}
+}
 
-	// Start reading audio in a goroutine
-	source.wg.Add(1)
-	go func() {
-		defer source.wg.Done()
+// readAudioLoop reads samples from the source until stop is closed. If the
+// capture process exits on its own (e.g. the device was unplugged), the
+// source is restarted on the device returned by the resolver.
+func (c *MultiCapture) readAudioLoop(source *Source, stdout io.Reader, stop <-chan struct{}) {
+	defer source.wg.Done()
+
+	channels := source.channels
+	buffer := make([]byte, FrameSize*channels*sampleBytes)
+	samples := make([]float32, FrameSize*channels)
+	mono := make([]float32, FrameSize)
+	split := make([][]float32, channels)
+	for ch := range split {
+		split[ch] = make([]float32, FrameSize)
+	}
 
-		buffer := make([]byte, FrameSize*4) // 4 bytes per float32
-		samples := make([]float32, FrameSize)
+	c.mu.Lock()
+	onChannel, bytesRead := c.onChannel, c.bytesRead
+	c.mu.Unlock()
 
-		for {
-			select {
-			case <-source.stopCh:
+	for {
+		// Read whole frames so interleaved channels stay aligned
+		_, err := io.ReadFull(stdout, buffer)
+		source.lastRead.Store(time.Now().UnixNano())
+		if err != nil {
+			if closed(stop) {
 				return
-			default:
-				n, err := stdout.Read(buffer)
-				if err != nil {
-					return
-				}
+			}
 
-				// Convert bytes to float32
-				numSamples := n / 4
-				for i := 0; i < numSamples; i++ {
-					samples[i] = bytesToFloat32(buffer[i*4 : (i+1)*4])
+			cause := source.wait(err)
+			if errors.Is(cause, ErrSourceEnded) {
+				c.mu.Lock()
+				onReplayEnd := c.onReplayEnd
+				c.mu.Unlock()
+				if onReplayEnd != nil {
+					onReplayEnd()
 				}
+				return
+			}
+
+			stdout = c.restartSource(source, cause, stop)
+			if stdout == nil {
+				return
+			}
+			continue
+		}
+		if bytesRead != nil {
+			bytesRead.Add(int64(len(buffer)))
+		}
+
+		for i := range samples {
+			samples[i] = decodeSample(buffer[i*sampleBytes : (i+1)*sampleBytes])
+		}
+
+		if channels == 1 {
+			if onChannel != nil {
+				onChannel(source.device(), 0, samples)
+			}
+			if c.onAudio != nil {
+				c.onAudio(samples)
+			}
+			continue
+		}
+
+		// Deinterleave and downmix
+		for i := range mono {
+			var sum float32
+			for ch := range channels {
+				v := samples[i*channels+ch]
+				split[ch][i] = v
+				sum += v
+			}
+			mono[i] = sum / float32(channels)
+		}
+
+		if onChannel != nil {
+			device := source.device()
+			for ch := range split {
+				onChannel(device, ch, split[ch])
+			}
+		}
+		if c.onAudio != nil {
+			c.onAudio(mono)
+		}
+	}
+}
+
+// wait waits for the capture process of a source whose stream failed with
+// err to end, and returns why it ended
+func (s *Source) wait(err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.proc != nil {
+		if waitErr := s.proc.Wait(); waitErr != nil {
+			err = waitErr
+		}
+		s.proc = nil
+	}
+	return err
+}
 
-				if c.onAudio != nil {
-					c.onAudio(samples[:numSamples])
+// device returns the device the source is currently capturing from
+func (s *Source) device() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deviceName
+}
+
+// restartSource re-resolves the device of a lost source and restarts capture,
+// retrying until it succeeds or stop is closed. It returns nil if the source
+// was stopped before capture could resume.
+func (c *MultiCapture) restartSource(source *Source, cause error, stop <-chan struct{}) io.Reader {
+	source.mu.Lock()
+	lost := source.deviceName
+	if source.stall != nil {
+		cause, source.stall = source.stall, nil
+	}
+	source.mu.Unlock()
+
+	c.mu.Lock()
+	resolve, onRestart := c.resolve, c.onRestart
+	c.mu.Unlock()
+
+	if onRestart != nil {
+		onRestart(lost, "", fmt.Errorf("source %s lost: %w", lost, cause))
+	}
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(restartDelay):
+		}
+
+		device := lost
+		if resolve != nil {
+			resolved, err := resolve(lost)
+			if err != nil {
+				if onRestart != nil {
+					onRestart(lost, "", fmt.Errorf("failed to resolve device: %w", err))
 				}
+				continue
 			}
+			device = resolved
 		}
-	}()
 
-	return nil
+		source.mu.Lock()
+		source.deviceName = device
+		source.mu.Unlock()
+
+		stdout, err := source.spawn()
+		if err != nil {
+			if onRestart != nil {
+				onRestart(lost, "", err)
+			}
+			continue
+		}
+
+		if onRestart != nil {
+			onRestart(lost, device, nil)
+		}
+		return stdout
+	}
 }
 
 func bytesToFloat32(b []byte) float32 {
@@ -254,43 +467,91 @@ func (c *MultiCapture) stopAllSources() {
 
 // stopSource stops a single audio source
 func (c *MultiCapture) stopSource(source *Source) {
-	// Signal stop
-	select {
-	case <-source.stopCh:
-		// Already closed
-	default:
+	// Signal stop and cancel the context to kill parec. Both happen under the
+	// source lock so a concurrent restart cannot spawn a new process after this.
+	source.mu.Lock()
+	if !source.stopped() {
 		close(source.stopCh)
 	}
-
-	// Cancel the context to kill parec
 	if source.cancel != nil {
 		source.cancel()
 	}
+	source.mu.Unlock()
 
 	// Wait for the goroutine to finish
 	source.wg.Wait()
 
-	// Wait for command to exit
-	if source.cmd != nil && source.cmd.Process != nil {
-		source.cmd.Wait()
-	}
+	// Wait for the capture process to exit
+	source.wait(nil)
 }
 
 // Stop stops audio capture from all sources
 func (c *MultiCapture) Stop() error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	if !c.isRunning {
+		c.mu.Unlock()
 		return nil
 	}
 
 	c.isRunning = false
-	c.stopAllSources()
+	if c.stopWatchdog != nil {
+		close(c.stopWatchdog)
+		c.stopWatchdog = nil
+	}
+	c.mu.Unlock()
 
+	// The read goroutines take the capture lock, e.g. when a source is
+	// lost, so it is not held while waiting for them
+	c.stopAllSources()
 	return nil
 }
 
+// SwitchDevice moves the source capturing from oldDevice over to newDevice.
+// While capturing, the old device is stopped and the new one started in its
+// place, so the audio callbacks continue with only a short gap. If the new
+// device fails to start, capture goes back to the old one.
+func (c *MultiCapture) SwitchDevice(oldDevice, newDevice string) error {
+	var source *Source
+	for _, s := range c.sources {
+		if s.device() == oldDevice {
+			source = s
+			break
+		}
+	}
+	if source == nil {
+		return fmt.Errorf("not capturing from %s", oldDevice)
+	}
+
+	// The capture lock is not held while the source restarts, since its read
+	// goroutine takes it when the device is lost
+	c.mu.Lock()
+	running := c.isRunning
+	c.mu.Unlock()
+	if !running {
+		source.mu.Lock()
+		source.deviceName = newDevice
+		source.mu.Unlock()
+		return nil
+	}
+
+	c.stopSource(source)
+	source.mu.Lock()
+	source.deviceName = newDevice
+	source.mu.Unlock()
+	err := c.startSource(source)
+	if err == nil {
+		return nil
+	}
+
+	source.mu.Lock()
+	source.deviceName = oldDevice
+	source.mu.Unlock()
+	if restoreErr := c.startSource(source); restoreErr != nil {
+		return fmt.Errorf("failed to start %s: %w, and %s did not restart: %w", newDevice, err, oldDevice, restoreErr)
+	}
+	return fmt.Errorf("failed to start %s: %w", newDevice, err)
+}
+
 // Close releases all resources
 func (c *MultiCapture) Close() error {
 	return c.Stop()
@@ -307,7 +568,7 @@ func (c *MultiCapture) IsRunning() bool {
 func (c *MultiCapture) GetDeviceNames() []string {
 	names := make([]string, len(c.sources))
 	for i, s := range c.sources {
-		names[i] = s.deviceName
+		names[i] = s.device()
 	}
 	return names
 }
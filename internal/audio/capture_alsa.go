@@ -0,0 +1,116 @@
+//go:build alsa && !sndio && !openbsd
+
+package audio
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"strings"
+)
+
+// CaptureBackend names the audio system this build captures from
+const CaptureBackend = "ALSA"
+
+// sampleBytes is the size of one sample in the capture stream
+const sampleBytes = 4
+
+// Tools are the programs the ALSA backend runs, and ToolsHint tells how to
+// install them
+var (
+	Tools     = []string{"arecord"}
+	ToolsHint = "Install the ALSA utilities: alsa-utils"
+)
+
+// captureCommand returns the arecord command recording device as raw
+// little-endian float32 samples
+func captureCommand(ctx context.Context, device string, channels int) *exec.Cmd {
+	return exec.CommandContext(ctx, "arecord",
+		"-q",
+		"-t", "raw",
+		"-f", "FLOAT_LE",
+		"-r", fmt.Sprint(SampleRate),
+		"-c", fmt.Sprint(channels),
+		"-D", device,
+	)
+}
+
+// decodeSample converts one sample of the capture stream
+func decodeSample(b []byte) float32 {
+	return bytesToFloat32(b)
+}
+
+// ListMonitorSources returns the ALSA capture devices. ALSA has no monitor
+// of the speakers; system audio can only be captured from a loopback card
+// (snd-aloop) that playback is routed to, so its devices count as monitors.
+func ListMonitorSources() ([]MonitorSource, error) {
+	output, err := exec.Command("arecord", "-L").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ALSA devices: %w", err)
+	}
+
+	// Device names start a line; the indented lines below describe them
+	var sources []MonitorSource
+	for line := range strings.Lines(string(output)) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if n := len(sources); n > 0 && sources[n-1].Description == sources[n-1].Name {
+				sources[n-1].Description = strings.TrimSpace(line)
+			}
+			continue
+		}
+		name := strings.TrimSpace(line)
+		if name == "null" {
+			continue
+		}
+		isMonitor := strings.Contains(name, "Loopback")
+		sources = append(sources, MonitorSource{
+			Name:        name,
+			Description: name,
+			IsMonitor:   isMonitor,
+			IsInput:     !isMonitor,
+		})
+	}
+	return sources, nil
+}
+
+// GetDefaultMonitorSource returns the capture side of the first loopback
+// card
+func GetDefaultMonitorSource() (string, error) {
+	sources, err := ListMonitorSources()
+	if err != nil {
+		return "", err
+	}
+	var loopback string
+	for _, s := range sources {
+		if !s.IsMonitor {
+			continue
+		}
+		// Playback goes to device 0 of the loopback card and comes out of 1
+		if strings.Contains(s.Name, "DEV=1") {
+			return s.Name, nil
+		}
+		if loopback == "" {
+			loopback = s.Name
+		}
+	}
+	if loopback == "" {
+		return "", errors.New("no loopback device found, load snd-aloop and route playback to it to capture system audio")
+	}
+	return loopback, nil
+}
+
+// GetDefaultInputSource returns the default ALSA capture device
+func GetDefaultInputSource() (string, error) {
+	return "default", nil
+}
+
+// encodeSample converts one sample to the capture stream format
+func encodeSample(b []byte, v float32) {
+	binary.LittleEndian.PutUint32(b, math.Float32bits(v))
+}
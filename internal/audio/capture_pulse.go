@@ -0,0 +1,158 @@
+//go:build !alsa && !sndio && !openbsd
+
+package audio
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// CaptureBackend names the audio system this build captures from
+const CaptureBackend = "PulseAudio/PipeWire"
+
+// sampleBytes is the size of one sample in the capture stream
+const sampleBytes = 4
+
+// Tools are the programs the PulseAudio/PipeWire backend runs, and
+// ToolsHint tells how to install them
+var (
+	Tools     = []string{"pactl", "parec"}
+	ToolsHint = "Install PulseAudio utilities: pulseaudio-utils (Debian/Ubuntu/Arch) or pipewire-pulseaudio (Fedora)"
+)
+
+// captureCommand returns the parec command recording device as raw
+// little-endian float32 samples
+func captureCommand(ctx context.Context, device string, channels int) *exec.Cmd {
+	return exec.CommandContext(ctx, "parec",
+		"--format=float32le",
+		fmt.Sprintf("--rate=%d", SampleRate),
+		fmt.Sprintf("--channels=%d", channels),
+		"-d", device,
+	)
+}
+
+// decodeSample converts one sample of the capture stream
+func decodeSample(b []byte) float32 {
+	return bytesToFloat32(b)
+}
+
+// ListMonitorSources returns available monitor sources for capturing system
+// audio, with the human-readable descriptions PulseAudio shows in its
+// volume controls
+func ListMonitorSources() ([]MonitorSource, error) {
+	// The long listing has the descriptions; its labels are only stable in
+	// the C locale
+	cmd := exec.Command("pactl", "list", "sources")
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PulseAudio sources: %w", err)
+	}
+	return parseSources(string(output)), nil
+}
+
+// parseSources parses the output of pactl list sources. Each source starts
+// with a "Source #N" line followed by indented "Key: value" lines.
+func parseSources(output string) []MonitorSource {
+	var sources []MonitorSource
+	for line := range strings.Lines(output) {
+		if strings.HasPrefix(line, "Source #") {
+			sources = append(sources, MonitorSource{})
+			continue
+		}
+		if len(sources) == 0 {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ": ")
+		if !ok {
+			continue
+		}
+		s := &sources[len(sources)-1]
+		switch key {
+		case "Name":
+			s.Name = value
+		case "Description":
+			s.Description = value
+		case "Sample Specification":
+			// e.g. s16le 2ch 48000Hz
+			for _, f := range strings.Fields(value) {
+				if n, ok := strings.CutSuffix(f, "ch"); ok {
+					s.Channels, _ = strconv.Atoi(n)
+				} else if n, ok := strings.CutSuffix(f, "Hz"); ok {
+					s.SampleRate, _ = strconv.Atoi(n)
+				}
+			}
+		}
+	}
+
+	for i := range sources {
+		s := &sources[i]
+		if s.Description == "" {
+			s.Description = s.Name
+		}
+		s.IsMonitor = strings.Contains(s.Name, ".monitor")
+		// Non-monitors are typically inputs
+		s.IsInput = !s.IsMonitor
+	}
+	return sources
+}
+
+// GetDefaultMonitorSource returns the default output monitor source
+func GetDefaultMonitorSource() (string, error) {
+	// Get default sink and append .monitor
+	cmd := exec.Command("pactl", "get-default-sink")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get default sink: %w", err)
+	}
+
+	sink := strings.TrimSpace(string(output))
+	if sink == "" {
+		return "", errors.New("no default sink found")
+	}
+
+	return sink + ".monitor", nil
+}
+
+// GetDefaultInputSource returns the default input (microphone) source
+func GetDefaultInputSource() (string, error) {
+	cmd := exec.Command("pactl", "get-default-source")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get default source: %w", err)
+	}
+
+	source := strings.TrimSpace(string(output))
+	if source == "" {
+		return "", errors.New("no default source found")
+	}
+
+	// Don't return if it's a monitor (we want actual input)
+	if strings.Contains(source, ".monitor") {
+		// Try to find an actual input source
+		sources, err := ListMonitorSources()
+		if err != nil {
+			return "", err
+		}
+		for _, s := range sources {
+			if s.IsInput && !s.IsMonitor {
+				return s.Name, nil
+			}
+		}
+		return "", errors.New("no input source found")
+	}
+
+	return source, nil
+}
+
+// encodeSample converts one sample to the capture stream format
+func encodeSample(b []byte, v float32) {
+	binary.LittleEndian.PutUint32(b, math.Float32bits(v))
+}
@@ -0,0 +1,75 @@
+//go:build sndio || openbsd
+
+package audio
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os/exec"
+)
+
+// CaptureBackend names the audio system this build captures from
+const CaptureBackend = "sndio"
+
+// sampleBytes is the size of one sample in the capture stream
+const sampleBytes = 2
+
+const (
+	// sndioDefault is the default sndio device
+	sndioDefault = "snd/0"
+
+	// sndioMonitor is a sub-device of sndiod in monitoring mode, which
+	// carries everything played, e.g. from sndiod -s default -m mon -s mon
+	sndioMonitor = sndioDefault + ".mon"
+)
+
+// Tools are the programs the sndio backend runs, and ToolsHint tells how to
+// install them
+var (
+	Tools     = []string{"aucat"}
+	ToolsHint = "Install sndio (aucat is part of the OpenBSD base system)"
+)
+
+// captureCommand returns the aucat command recording device as raw
+// little-endian 16-bit samples, since aucat cannot write floats
+func captureCommand(ctx context.Context, device string, channels int) *exec.Cmd {
+	return exec.CommandContext(ctx, "aucat",
+		"-f", device,
+		"-r", fmt.Sprint(SampleRate),
+		"-c", fmt.Sprintf("0:%d", channels-1),
+		"-e", "s16le",
+		"-h", "raw",
+		"-o", "-",
+	)
+}
+
+// decodeSample converts one sample of the capture stream
+func decodeSample(b []byte) float32 {
+	return float32(int16(binary.LittleEndian.Uint16(b))) / 32768
+}
+
+// ListMonitorSources returns the default sndio device and its monitoring
+// sub-device. sndio has no way to enumerate devices.
+func ListMonitorSources() ([]MonitorSource, error) {
+	return []MonitorSource{
+		{Name: sndioDefault, Description: "Default sndio device", IsInput: true},
+		{Name: sndioMonitor, Description: "Default sndio device (monitor, needs a sndiod mon sub-device)", IsMonitor: true},
+	}, nil
+}
+
+// GetDefaultMonitorSource returns the monitoring sub-device of sndiod
+func GetDefaultMonitorSource() (string, error) {
+	return sndioMonitor, nil
+}
+
+// GetDefaultInputSource returns the default sndio device
+func GetDefaultInputSource() (string, error) {
+	return sndioDefault, nil
+}
+
+// encodeSample converts one sample to the capture stream format
+func encodeSample(b []byte, v float32) {
+	v = max(-1, min(v, 1))
+	binary.LittleEndian.PutUint16(b, uint16(int16(v*32767)))
+}
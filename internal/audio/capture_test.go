@@ -0,0 +1,334 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRun scripts one capture started by a fakeRunner
+type fakeRun struct {
+	// startErr fails the start of the capture
+	startErr error
+	// frames are delivered in order, each holding FrameSize samples per
+	// channel, interleaved
+	frames [][]float32
+	// err ends the capture once the frames are delivered, as if the device
+	// was lost. Without it, the capture stays open delivering nothing until
+	// it is canceled, like a hung capture program.
+	err error
+}
+
+// fakeRunner is a SourceRunner replaying scripted captures per device.
+// Devices without a script left capture nothing until canceled.
+type fakeRunner struct {
+	mu      sync.Mutex
+	runs    map[string][]fakeRun
+	started []string
+}
+
+func newFakeRunner(runs map[string][]fakeRun) *fakeRunner {
+	return &fakeRunner{runs: runs}
+}
+
+// Start implements SourceRunner
+func (r *fakeRunner) Start(ctx context.Context, device string, channels int) (SourceProcess, error) {
+	r.mu.Lock()
+	var run fakeRun
+	if runs := r.runs[device]; len(runs) > 0 {
+		run, r.runs[device] = runs[0], runs[1:]
+	}
+	if run.startErr == nil {
+		r.started = append(r.started, device)
+	}
+	r.mu.Unlock()
+
+	if run.startErr != nil {
+		return nil, run.startErr
+	}
+
+	var data []byte
+	for _, frame := range run.frames {
+		for _, v := range frame {
+			b := make([]byte, sampleBytes)
+			encodeSample(b, v)
+			data = append(data, b...)
+		}
+	}
+	return &fakeProcess{ctx: ctx, data: data, err: run.err}, nil
+}
+
+// Started returns the devices started so far, in order
+func (r *fakeRunner) Started() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.started)
+}
+
+// fakeProcess is a capture started by a fakeRunner
+type fakeProcess struct {
+	ctx  context.Context
+	data []byte
+	err  error
+}
+
+// Read implements io.Reader
+func (p *fakeProcess) Read(b []byte) (int, error) {
+	if len(p.data) > 0 {
+		n := copy(b, p.data)
+		p.data = p.data[n:]
+		return n, nil
+	}
+	if p.err != nil {
+		return 0, io.EOF
+	}
+	<-p.ctx.Done()
+	return 0, p.ctx.Err()
+}
+
+// Wait implements SourceProcess
+func (p *fakeProcess) Wait() error {
+	if p.err != nil {
+		return p.err
+	}
+	return p.ctx.Err()
+}
+
+// frame returns a mono frame with every sample set to v
+func frame(v float32) []float32 {
+	f := make([]float32, FrameSize)
+	for i := range f {
+		f[i] = v
+	}
+	return f
+}
+
+// stereoFrame returns a stereo frame with every sample of the left and
+// right channel set to left and right
+func stereoFrame(left, right float32) []float32 {
+	f := make([]float32, FrameSize*2)
+	for i := 0; i < len(f); i += 2 {
+		f[i], f[i+1] = left, right
+	}
+	return f
+}
+
+// received is audio delivered to the channel handler
+type received struct {
+	device  string
+	channel int
+	value   float32
+}
+
+// recorder collects what capture delivers to its callbacks
+type recorder struct {
+	mu       sync.Mutex
+	channels []received
+	mixed    []float32
+	restarts []string
+	got      chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{got: make(chan struct{}, 64)}
+}
+
+func (r *recorder) onChannel(device string, channel int, samples []float32) {
+	r.mu.Lock()
+	r.channels = append(r.channels, received{device, channel, samples[0]})
+	r.mu.Unlock()
+	select {
+	case r.got <- struct{}{}:
+	default:
+	}
+}
+
+func (r *recorder) onAudio(samples []float32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mixed = append(r.mixed, samples[0])
+}
+
+func (r *recorder) onRestart(oldDevice, newDevice string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.restarts = append(r.restarts, oldDevice+" lost: "+err.Error())
+		return
+	}
+	r.restarts = append(r.restarts, oldDevice+" -> "+newDevice)
+}
+
+// waitFor waits until the channel handler received audio matching ok
+func (r *recorder) waitFor(t *testing.T, what string, ok func([]received) bool) {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		r.mu.Lock()
+		done := ok(r.channels)
+		r.mu.Unlock()
+		if done {
+			return
+		}
+		select {
+		case <-r.got:
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+// newTestCapture returns a capture of devices started through runner,
+// reporting to rec
+func newTestCapture(t *testing.T, devices []string, runner SourceRunner, rec *recorder) *MultiCapture {
+	t.Helper()
+	c, err := NewMultiCapture(devices, rec.onAudio)
+	if err != nil {
+		t.Fatalf("NewMultiCapture: %v", err)
+	}
+	c.SetSourceRunner(runner)
+	c.SetChannelHandler(rec.onChannel)
+	c.SetRestartHandler(rec.onRestart)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// near reports whether two samples are equal up to the precision of the
+// capture stream
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-3
+}
+
+func TestCaptureLabelsAndMixesSources(t *testing.T) {
+	runner := newFakeRunner(map[string][]fakeRun{
+		"monitor": {{frames: [][]float32{stereoFrame(0.5, -0.25)}}},
+		"mic":     {{frames: [][]float32{frame(0.75)}}},
+	})
+	rec := newRecorder()
+	c := newTestCapture(t, []string{"monitor", "mic"}, runner, rec)
+	if err := c.SetStereo(0); err != nil {
+		t.Fatalf("SetStereo: %v", err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	rec.waitFor(t, "all channels", func(got []received) bool { return len(got) == 3 })
+	if err := c.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	// Sources are read concurrently, so only the order within a source is
+	// fixed
+	want := []received{{"mic", 0, 0.75}, {"monitor", 0, 0.5}, {"monitor", 1, -0.25}}
+	got := slices.SortedFunc(slices.Values(rec.channels), func(a, b received) int {
+		return strings.Compare(a.device, b.device)
+	})
+	for i, w := range want {
+		if got[i].device != w.device || got[i].channel != w.channel || !near(got[i].value, w.value) {
+			t.Errorf("channel audio %d = %+v, want %+v", i, got[i], w)
+		}
+	}
+
+	// The stereo source is downmixed for the audio callback
+	slices.Sort(rec.mixed)
+	if len(rec.mixed) != 2 || !near(rec.mixed[0], 0.125) || !near(rec.mixed[1], 0.75) {
+		t.Errorf("mixed audio = %v, want [0.125 0.75]", rec.mixed)
+	}
+}
+
+func TestCaptureRestartsLostSource(t *testing.T) {
+	runner := newFakeRunner(map[string][]fakeRun{
+		"usb":    {{frames: [][]float32{frame(0.5)}, err: errors.New("device unplugged")}},
+		"laptop": {{frames: [][]float32{frame(0.25)}}},
+	})
+	rec := newRecorder()
+	c := newTestCapture(t, []string{"usb"}, runner, rec)
+	c.SetDeviceResolver(func(lost string) (string, error) { return "laptop", nil })
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	rec.waitFor(t, "audio of the new device", func(got []received) bool {
+		return slices.ContainsFunc(got, func(r received) bool { return r.device == "laptop" })
+	})
+
+	if names := c.GetDeviceNames(); !slices.Equal(names, []string{"laptop"}) {
+		t.Errorf("devices = %v, want [laptop]", names)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.restarts) != 2 || !strings.Contains(rec.restarts[0], "device unplugged") || rec.restarts[1] != "usb -> laptop" {
+		t.Errorf("restarts = %q, want the loss of usb and the move to laptop", rec.restarts)
+	}
+}
+
+func TestCaptureWatchdogRestartsStalledSource(t *testing.T) {
+	// The first capture delivers one frame and then hangs
+	runner := newFakeRunner(map[string][]fakeRun{
+		"monitor": {{frames: [][]float32{frame(0.5)}}, {frames: [][]float32{frame(0.25)}}},
+	})
+	rec := newRecorder()
+	c := newTestCapture(t, []string{"monitor"}, runner, rec)
+	c.SetStallTimeout(time.Second)
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	rec.waitFor(t, "audio after the restart", func(got []received) bool {
+		return slices.ContainsFunc(got, func(r received) bool { return near(r.value, 0.25) })
+	})
+
+	if started := runner.Started(); !slices.Equal(started, []string{"monitor", "monitor"}) {
+		t.Errorf("started %v, want monitor twice", started)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.restarts) != 2 || !strings.Contains(rec.restarts[0], "no audio for") || rec.restarts[1] != "monitor -> monitor" {
+		t.Errorf("restarts = %q, want a stall of monitor and its restart", rec.restarts)
+	}
+}
+
+func TestCaptureSwitchDevice(t *testing.T) {
+	runner := newFakeRunner(map[string][]fakeRun{
+		"speakers":  {{frames: [][]float32{frame(0.5)}}},
+		"headset":   {{frames: [][]float32{frame(0.25)}}},
+		"bluetooth": {{startErr: errors.New("device busy")}},
+	})
+	rec := newRecorder()
+	c := newTestCapture(t, []string{"speakers"}, runner, rec)
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	rec.waitFor(t, "audio of speakers", func(got []received) bool { return len(got) == 1 })
+
+	if err := c.SwitchDevice("speakers", "headset"); err != nil {
+		t.Fatalf("SwitchDevice: %v", err)
+	}
+	rec.waitFor(t, "audio of headset", func(got []received) bool {
+		return slices.ContainsFunc(got, func(r received) bool { return r.device == "headset" && near(r.value, 0.25) })
+	})
+	if names := c.GetDeviceNames(); !slices.Equal(names, []string{"headset"}) {
+		t.Errorf("devices = %v, want [headset]", names)
+	}
+
+	// A device that fails to start leaves capture on the old one
+	err := c.SwitchDevice("headset", "bluetooth")
+	if err == nil || !strings.Contains(err.Error(), "device busy") {
+		t.Errorf("SwitchDevice to a busy device = %v, want its start error", err)
+	}
+	if names := c.GetDeviceNames(); !slices.Equal(names, []string{"headset"}) {
+		t.Errorf("devices = %v, want [headset]", names)
+	}
+	if err := c.SwitchDevice("speakers", "headset"); err == nil {
+		t.Error("SwitchDevice from a device not captured succeeded")
+	}
+	if started := runner.Started(); !slices.Equal(started, []string{"speakers", "headset", "headset"}) {
+		t.Errorf("started %v, want speakers, headset and headset again", started)
+	}
+}
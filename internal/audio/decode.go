@@ -0,0 +1,47 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// DecodeFile decodes the audio of any file ffmpeg can read, including the
+// audio track of video files, to 16kHz mono samples
+func DecodeFile(ctx context.Context, path string) ([]float32, error) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return nil, errors.New("ffmpeg not found, install it to transcribe audio files")
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-nostdin",
+		"-v", "error",
+		"-i", path,
+		"-vn",
+		"-f", "f32le",
+		"-ac", "1",
+		"-ar", strconv.Itoa(SampleRate),
+		"pipe:1",
+	)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("decoding %s: %w: %s", path, err, msg)
+		}
+		return nil, fmt.Errorf("decoding %s: %w", path, err)
+	}
+
+	samples := make([]float32, len(out)/4)
+	for i := range samples {
+		samples[i] = math.Float32frombits(binary.LittleEndian.Uint32(out[i*4:]))
+	}
+	return samples, nil
+}
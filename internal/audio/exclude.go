@@ -0,0 +1,235 @@
+package audio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// excludeSinkName is the null sink the captured applications play to
+	excludeSinkName = "rekord_capture"
+
+	// excludeInterval is how often new playback streams are moved to the
+	// null sink
+	excludeInterval = 2 * time.Second
+)
+
+// Exclusion keeps the audio of blocked applications out of a monitor
+// capture. PulseAudio monitors carry everything played on a sink, so the
+// other applications' streams are moved to a null sink instead, whose
+// monitor is captured and which is looped back to the real sink to stay
+// audible. Blocked applications keep playing on the real sink directly.
+type Exclusion struct {
+	sink string
+	// null is the null sink, named excludeSinkName unless PulseAudio had to
+	// rename it, e.g. next to one left behind by a crash
+	null    string
+	blocked []string
+	modules []string     // loaded module indexes, unloaded in reverse
+	moved   map[int]bool // sink inputs moved to the null sink
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+}
+
+// sinkInput is a playback stream as listed by pactl
+type sinkInput struct {
+	Index       int               `json:"index"`
+	Sink        int               `json:"sink"`
+	OwnerModule json.RawMessage   `json:"owner_module"`
+	Properties  map[string]string `json:"properties"`
+}
+
+// NewExclusion sets up the null sink for capturing the sink behind monitor
+// without the applications in apps, matched case-insensitively against the
+// application name or binary (e.g. Spotify or firefox). Capture from
+// Monitor and call Close to restore the original routing.
+func NewExclusion(monitor string, apps []string) (*Exclusion, error) {
+	sink, ok := strings.CutSuffix(monitor, ".monitor")
+	if !ok {
+		return nil, fmt.Errorf("%s is not a monitor source, applications can only be excluded from system audio", monitor)
+	}
+
+	e := &Exclusion{sink: sink, moved: make(map[int]bool), stop: make(chan struct{})}
+	for _, app := range apps {
+		e.blocked = append(e.blocked, strings.ToLower(app))
+	}
+
+	null, err := loadModule("module-null-sink", "sink_name="+excludeSinkName, "sink_properties=device.description=Rekord-capture")
+	if err != nil {
+		return nil, err
+	}
+	e.modules = append(e.modules, null)
+	if e.null, err = moduleSink(null); err != nil {
+		e.Close()
+		return nil, err
+	}
+	loop, err := loadModule("module-loopback", "source="+e.null+".monitor", "sink="+sink, "latency_msec=20")
+	if err != nil {
+		e.Close()
+		return nil, err
+	}
+	e.modules = append(e.modules, loop)
+
+	if err := e.route(); err != nil {
+		e.Close()
+		return nil, err
+	}
+	e.wg.Go(e.routeLoop)
+	return e, nil
+}
+
+// Monitor returns the source to capture
+func (e *Exclusion) Monitor() string {
+	return e.null + ".monitor"
+}
+
+// routeLoop moves streams that start playing during the recording
+func (e *Exclusion) routeLoop() {
+	ticker := time.NewTicker(excludeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			e.route()
+		}
+	}
+}
+
+// route moves the streams of applications that are not blocked from the
+// real sink to the null sink
+func (e *Exclusion) route() error {
+	sinkIndex, err := sinkIndex(e.sink)
+	if err != nil {
+		return err
+	}
+	inputs, err := listSinkInputs()
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, in := range inputs {
+		if in.Sink != sinkIndex || e.isBlocked(in) || slices.Contains(e.modules, strings.Trim(string(in.OwnerModule), `"`)) {
+			continue
+		}
+		if err := exec.Command("pactl", "move-sink-input", fmt.Sprint(in.Index), e.null).Run(); err != nil {
+			// The stream may have ended in the meantime
+			continue
+		}
+		e.moved[in.Index] = true
+	}
+	return nil
+}
+
+// isBlocked reports whether a stream belongs to a blocked application
+func (e *Exclusion) isBlocked(in sinkInput) bool {
+	name := strings.ToLower(in.Properties["application.name"])
+	binary := strings.ToLower(in.Properties["application.process.binary"])
+	return slices.Contains(e.blocked, name) || slices.Contains(e.blocked, binary)
+}
+
+// Close moves the streams back to the real sink and removes the null sink
+func (e *Exclusion) Close() error {
+	select {
+	case <-e.stop:
+		return nil
+	default:
+		close(e.stop)
+	}
+	e.wg.Wait()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for index := range e.moved {
+		// Streams that ended in the meantime fail to move, which is fine
+		exec.Command("pactl", "move-sink-input", fmt.Sprint(index), e.sink).Run()
+	}
+	var errs []error
+	for i := len(e.modules) - 1; i >= 0; i-- {
+		if err := exec.Command("pactl", "unload-module", e.modules[i]).Run(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to unload module %s: %w", e.modules[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// loadModule loads a PulseAudio module and returns its index
+func loadModule(name string, args ...string) (string, error) {
+	out, err := exec.Command("pactl", append([]string{"load-module", name}, args...)...).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to load %s: %w", name, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// sink is a sink as listed by pactl
+type sink struct {
+	Index       int             `json:"index"`
+	Name        string          `json:"name"`
+	OwnerModule json.RawMessage `json:"owner_module"`
+}
+
+// listSinks returns the sinks
+func listSinks() ([]sink, error) {
+	out, err := exec.Command("pactl", "-f", "json", "list", "sinks").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sinks: %w", err)
+	}
+	var sinks []sink
+	if err := json.Unmarshal(out, &sinks); err != nil {
+		return nil, fmt.Errorf("failed to parse sinks: %w", err)
+	}
+	return sinks, nil
+}
+
+// sinkIndex returns the index of the named sink
+func sinkIndex(name string) (int, error) {
+	sinks, err := listSinks()
+	if err != nil {
+		return 0, err
+	}
+	for _, s := range sinks {
+		if s.Name == name {
+			return s.Index, nil
+		}
+	}
+	return 0, fmt.Errorf("sink %s not found", name)
+}
+
+// moduleSink returns the name of the sink created by the module with the
+// given index
+func moduleSink(module string) (string, error) {
+	sinks, err := listSinks()
+	if err != nil {
+		return "", err
+	}
+	for _, s := range sinks {
+		if strings.Trim(string(s.OwnerModule), `"`) == module {
+			return s.Name, nil
+		}
+	}
+	return "", fmt.Errorf("sink of module %s not found", module)
+}
+
+// listSinkInputs returns the playback streams
+func listSinkInputs() ([]sinkInput, error) {
+	out, err := exec.Command("pactl", "-f", "json", "list", "sink-inputs").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list playback streams: %w", err)
+	}
+	var inputs []sinkInput
+	if err := json.Unmarshal(out, &inputs); err != nil {
+		return nil, fmt.Errorf("failed to parse playback streams: %w", err)
+	}
+	return inputs, nil
+}
@@ -0,0 +1,87 @@
+package audio
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// MinDBFS is the floor of the meter range; quieter signals are clamped to it
+	MinDBFS = -60.0
+
+	// ClipThreshold is the absolute sample value treated as clipping
+	ClipThreshold = 0.999
+
+	peakHoldTime = 1500 * time.Millisecond
+	clipHoldTime = 2 * time.Second
+)
+
+// Level is a single audio meter reading. All values are in dBFS.
+type Level struct {
+	RMS      float64
+	Peak     float64
+	PeakHold float64
+	Clipping bool
+}
+
+// Meter computes RMS/peak levels with peak hold and clip detection
+type Meter struct {
+	mu         sync.Mutex
+	peakHold   float64
+	peakHoldAt time.Time
+	clipAt     time.Time
+}
+
+// NewMeter creates a new audio level meter
+func NewMeter() *Meter {
+	return &Meter{peakHold: MinDBFS}
+}
+
+// Process measures a block of samples and returns the current reading
+func (m *Meter) Process(samples []float32) Level {
+	if len(samples) == 0 {
+		return Level{RMS: MinDBFS, Peak: MinDBFS, PeakHold: MinDBFS}
+	}
+
+	var sumSquares, peak float64
+	clipped := false
+	for _, s := range samples {
+		v := math.Abs(float64(s))
+		sumSquares += v * v
+		peak = max(peak, v)
+		if v >= ClipThreshold {
+			clipped = true
+		}
+	}
+
+	level := Level{
+		RMS:  ToDBFS(math.Sqrt(sumSquares / float64(len(samples)))),
+		Peak: ToDBFS(peak),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if level.Peak >= m.peakHold || now.Sub(m.peakHoldAt) > peakHoldTime {
+		m.peakHold = level.Peak
+		m.peakHoldAt = now
+	}
+	if clipped {
+		m.clipAt = now
+	}
+
+	level.PeakHold = m.peakHold
+	level.Clipping = !m.clipAt.IsZero() && now.Sub(m.clipAt) < clipHoldTime
+	return level
+}
+
+// ToDBFS converts a linear amplitude (1.0 = full scale) to dBFS, clamped to
+// [MinDBFS, 0]
+func ToDBFS(amplitude float64) float64 {
+	if amplitude <= 0 {
+		return MinDBFS
+	}
+	return min(max(20*math.Log10(amplitude), MinDBFS), 0)
+}
@@ -0,0 +1,29 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os/exec"
+)
+
+// Play plays captured samples on the default output device and blocks until
+// playback has finished
+func Play(samples []float32) error {
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, samples); err != nil {
+		return err
+	}
+
+	// pacat ships with parec and takes the same raw format
+	cmd := exec.Command("pacat", "--playback",
+		"--format=float32le",
+		fmt.Sprintf("--rate=%d", SampleRate),
+		fmt.Sprintf("--channels=%d", Channels),
+	)
+	cmd.Stdin = &buf
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("pacat failed: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
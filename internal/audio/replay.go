@@ -0,0 +1,82 @@
+package audio
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// NewReplayRunner creates a SourceRunner that delivers samples at real-time
+// speed for any device, ending with ErrSourceEnded
+func NewReplayRunner(samples []float32) SourceRunner {
+	return replayRunner(samples)
+}
+
+// SetReplayEndHandler sets a function called when a source ends with
+// ErrSourceEnded, such as a replay capture that delivered all of its samples
+func (c *MultiCapture) SetReplayEndHandler(onEnd func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onReplayEnd = onEnd
+}
+
+// replayRunner replays samples in place of capturing devices
+type replayRunner []float32
+
+// Start implements SourceRunner. Stereo sources get the samples on both
+// channels.
+func (r replayRunner) Start(ctx context.Context, device string, channels int) (SourceProcess, error) {
+	return &replayReader{ctx: ctx, samples: r, channels: channels, start: time.Now()}, nil
+}
+
+// replayReader delivers samples in the capture stream format no faster than
+// real time
+type replayReader struct {
+	ctx      context.Context
+	samples  []float32
+	channels int
+	pos      int
+	start    time.Time
+}
+
+// Read implements io.Reader. The last frame is padded with silence so the
+// stream ends on a frame boundary.
+func (r *replayReader) Read(p []byte) (int, error) {
+	if r.pos >= len(r.samples) {
+		return 0, io.EOF
+	}
+	frame := sampleBytes * r.channels
+	n := len(p) / frame
+	if n == 0 {
+		return 0, io.ErrShortBuffer
+	}
+
+	// Wait until the last of the samples would have been recorded
+	due := r.start.Add(time.Duration(r.pos+n) * time.Second / SampleRate)
+	select {
+	case <-r.ctx.Done():
+		return 0, r.ctx.Err()
+	case <-time.After(time.Until(due)):
+	}
+
+	for i := range n {
+		var v float32
+		if r.pos < len(r.samples) {
+			v = r.samples[r.pos]
+		}
+		r.pos++
+		for ch := range r.channels {
+			off := i*frame + ch*sampleBytes
+			encodeSample(p[off:off+sampleBytes], v)
+		}
+	}
+	return n * frame, nil
+}
+
+// Wait implements SourceProcess
+func (r *replayReader) Wait() error {
+	if r.pos >= len(r.samples) {
+		return ErrSourceEnded
+	}
+	return r.ctx.Err()
+}
@@ -0,0 +1,61 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// ErrSourceEnded is returned by SourceProcess.Wait when a source has no more
+// audio to deliver, e.g. a replay that reached its end. Such sources are not
+// restarted.
+var ErrSourceEnded = errors.New("source ended")
+
+// SourceProcess is the running capture of one device
+type SourceProcess interface {
+	// Read reads the capture stream: interleaved little-endian samples in
+	// the format of the build's capture backend (see sampleBytes). It fails
+	// once the capture ends or its context is canceled.
+	io.Reader
+
+	// Wait waits for the capture to end after Read failed and returns why
+	Wait() error
+}
+
+// SourceRunner starts the capture of devices. Canceling ctx must end the
+// capture.
+type SourceRunner interface {
+	Start(ctx context.Context, device string, channels int) (SourceProcess, error)
+}
+
+// commandRunner captures devices with the capture program of the build's
+// backend, e.g. parec
+type commandRunner struct{}
+
+// Start implements SourceRunner
+func (commandRunner) Start(ctx context.Context, device string, channels int) (SourceProcess, error) {
+	cmd := captureCommand(ctx, device, channels)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", cmd.Args[0], err)
+	}
+	return commandProcess{Reader: stdout, cmd: cmd}, nil
+}
+
+// commandProcess is a running capture program
+type commandProcess struct {
+	io.Reader
+	cmd *exec.Cmd
+}
+
+// Wait implements SourceProcess
+func (p commandProcess) Wait() error {
+	return p.cmd.Wait()
+}
@@ -0,0 +1,29 @@
+package audio
+
+// FindQuietestPoint returns the sample index in samples[from:to] at the center
+// of the quietest FrameSize window, the best place to cut audio without
+// splitting a word. It returns to if the range is shorter than one frame.
+func FindQuietestPoint(samples []float32, from, to int) int {
+	from = max(from, 0)
+	to = min(to, len(samples))
+	if to-from < FrameSize {
+		return to
+	}
+
+	// Sliding window energy, advanced by half a frame for resolution
+	best := to
+	bestEnergy := -1.0
+	step := FrameSize / 2
+	for start := from; start+FrameSize <= to; start += step {
+		var energy float64
+		for _, s := range samples[start : start+FrameSize] {
+			energy += float64(s) * float64(s)
+		}
+		// Prefer later points on ties so chunks stay as long as possible
+		if bestEnergy < 0 || energy <= bestEnergy {
+			bestEnergy = energy
+			best = start + FrameSize/2
+		}
+	}
+	return best
+}
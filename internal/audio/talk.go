@@ -0,0 +1,65 @@
+package audio
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// SpeechDBFS is the RMS level above which a frame counts as someone talking
+const SpeechDBFS = -40.0
+
+// TalkTime accumulates how long the local user and the other participants
+// spoke, judged by the level of the microphone and system audio frames
+type TalkTime struct {
+	mu   sync.Mutex
+	you  time.Duration
+	them time.Duration
+
+	// Whether channel 0 of the last system audio frame was loud, so a stereo
+	// frame is counted once when either channel is
+	lastLoud bool
+}
+
+// Add measures a frame of one channel from the microphone (mic true) or the
+// system audio, as passed to a ChannelHandler
+func (t *TalkTime) Add(mic bool, channel int, samples []float32) {
+	if len(samples) == 0 {
+		return
+	}
+	loud := isSpeech(samples)
+	d := time.Duration(len(samples)) * time.Second / SampleRate
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case mic:
+		if loud {
+			t.you += d
+		}
+	case channel == 0:
+		t.lastLoud = loud
+		if loud {
+			t.them += d
+		}
+	case loud && !t.lastLoud:
+		t.them += d
+		t.lastLoud = true
+	}
+}
+
+// Totals returns how long the user and the others have talked so far
+func (t *TalkTime) Totals() (you, them time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.you, t.them
+}
+
+// isSpeech reports whether the RMS level of a frame is above SpeechDBFS
+func isSpeech(samples []float32) bool {
+	var sumSquares float64
+	for _, s := range samples {
+		sumSquares += float64(s) * float64(s)
+	}
+	return ToDBFS(math.Sqrt(sumSquares/float64(len(samples)))) >= SpeechDBFS
+}
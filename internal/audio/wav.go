@@ -0,0 +1,123 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+)
+
+// WAV sample formats
+const (
+	wavPCM        = 1
+	wavFloat      = 3
+	wavExtensible = 0xfffe
+)
+
+// ReadWAV reads a WAV file with integer PCM samples of 8 to 32 bits or
+// 32-bit float samples, downmixed to mono and resampled to 16kHz
+func ReadWAV(path string) ([]float32, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("%s is not a WAV file", path)
+	}
+
+	var format, channels, bits int
+	var rate int
+	var pcm []byte
+	for rest := data[12:]; len(rest) >= 8; {
+		id := string(rest[:4])
+		size := int(binary.LittleEndian.Uint32(rest[4:8]))
+		rest = rest[8:]
+		// Recorders streaming the file may leave the size of the last chunk
+		// unset
+		size = min(size, len(rest))
+		chunk := rest[:size]
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, errors.New("WAV format chunk too short")
+			}
+			format = int(binary.LittleEndian.Uint16(chunk[0:2]))
+			channels = int(binary.LittleEndian.Uint16(chunk[2:4]))
+			rate = int(binary.LittleEndian.Uint32(chunk[4:8]))
+			bits = int(binary.LittleEndian.Uint16(chunk[14:16]))
+			if format == wavExtensible && size >= 26 {
+				// The sub-format GUID starts with the actual format code
+				format = int(binary.LittleEndian.Uint16(chunk[24:26]))
+			}
+		case "data":
+			pcm = chunk
+		}
+		// Chunks are padded to an even size
+		rest = rest[min(size+size%2, len(rest)):]
+	}
+
+	switch {
+	case format == 0:
+		return nil, errors.New("WAV file has no format chunk")
+	case pcm == nil:
+		return nil, errors.New("WAV file has no data chunk")
+	case channels < 1 || rate < 1:
+		return nil, fmt.Errorf("invalid WAV format: %d channels at %dHz", channels, rate)
+	case format == wavPCM && (bits < 8 || bits > 32 || bits%8 != 0),
+		format == wavFloat && bits != 32,
+		format != wavPCM && format != wavFloat:
+		return nil, fmt.Errorf("unsupported WAV sample format %d with %d bits (want integer PCM or 32-bit float)", format, bits)
+	}
+
+	width := bits / 8
+	frames := len(pcm) / (width * channels)
+	mono := make([]float32, frames)
+	for i := range mono {
+		var sum float32
+		for ch := range channels {
+			off := (i*channels + ch) * width
+			sum += wavSample(pcm[off:off+width], format)
+		}
+		mono[i] = sum / float32(channels)
+	}
+	return resample(mono, rate), nil
+}
+
+// wavSample decodes one sample
+func wavSample(b []byte, format int) float32 {
+	if format == wavFloat {
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	}
+	if len(b) == 1 {
+		// 8-bit samples are unsigned
+		return float32(int(b[0])-128) / 128
+	}
+	// Sign-extend the little-endian value from the top byte
+	v := int32(int8(b[len(b)-1]))
+	for i := len(b) - 2; i >= 0; i-- {
+		v = v<<8 | int32(b[i])
+	}
+	return float32(v) / float32(int64(1)<<(8*len(b)-1))
+}
+
+// resample converts samples at rate to SampleRate by linear interpolation
+func resample(samples []float32, rate int) []float32 {
+	if rate == SampleRate || len(samples) == 0 {
+		return samples
+	}
+	out := make([]float32, int(int64(len(samples))*SampleRate/int64(rate)))
+	step := float64(rate) / SampleRate
+	for i := range out {
+		pos := float64(i) * step
+		j := int(pos)
+		if j+1 >= len(samples) {
+			out[i] = samples[len(samples)-1]
+			continue
+		}
+		frac := float32(pos - float64(j))
+		out[i] = samples[j]*(1-frac) + samples[j+1]*frac
+	}
+	return out
+}
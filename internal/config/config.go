@@ -0,0 +1,148 @@
+// Package config reads and writes the config file, the defaults of rekord's
+// flags written by the setup wizard
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// header starts a config file written from scratch
+const header = `# rekord configuration, written by rekord setup.
+# Each "flag = value" line sets the default of a flag; flags given on the
+# command line and -profile settings take precedence.
+`
+
+// Setting is the value of one flag, named without the leading dash
+type Setting struct {
+	Flag  string
+	Value string
+}
+
+// DefaultPath returns the default config file,
+// $XDG_CONFIG_HOME/rekord/config.conf or ~/.config/rekord/config.conf
+func DefaultPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = os.TempDir()
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "rekord", "config.conf")
+}
+
+// Exists reports whether there is a config file at path
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Load reads the settings in the file at path, in order. A missing file has
+// no settings. Each line is "flag = value", with a leading ~/ in values
+// expanded to the home directory; blank lines and lines starting with # are
+// ignored.
+func Load(path string) ([]Setting, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var settings []Setting
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"flag = value\"", lineNum)
+		}
+		value = ExpandHome(strings.TrimSpace(value))
+		settings = append(settings, Setting{Flag: strings.TrimLeft(strings.TrimSpace(key), "-"), Value: value})
+	}
+	return settings, scanner.Err()
+}
+
+// ExpandHome expands a leading ~/ in path to the home directory
+func ExpandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
+
+// Update sets the flags of settings in the file at path, creating it if
+// needed. Lines of other flags and comments are kept; a setting with an
+// empty value removes the flag's line.
+func Update(path string, settings []Setting) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		data = []byte(header)
+	} else if err != nil {
+		return err
+	}
+
+	pending := make(map[string]string, len(settings))
+	for _, s := range settings {
+		pending[s.Flag] = s.Value
+	}
+	var b strings.Builder
+	for line := range strings.Lines(string(data)) {
+		key, _, ok := strings.Cut(line, "=")
+		key = strings.TrimLeft(strings.TrimSpace(key), "-")
+		value, set := pending[key]
+		if !ok || strings.HasPrefix(strings.TrimSpace(line), "#") || !set {
+			b.WriteString(line)
+			continue
+		}
+		delete(pending, key)
+		if value != "" {
+			fmt.Fprintf(&b, "%s = %s\n", key, value)
+		}
+	}
+	if !strings.HasSuffix(b.String(), "\n") && b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	for _, s := range settings {
+		if value, ok := pending[s.Flag]; ok && value != "" {
+			fmt.Fprintf(&b, "%s = %s\n", s.Flag, value)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// Replace the file atomically so a crash never leaves half a config
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(b.String()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
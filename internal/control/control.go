@@ -0,0 +1,206 @@
+// Package control exposes a running rekord instance over a unix socket so it
+// can be driven from scripts, window-manager keybindings and `rekord ctl`.
+//
+// The protocol is line based: a client sends one command line with
+// space-separated arguments and receives one JSON Response line. A running
+// instance understands:
+//
+//	start, stop, toggle  control recording
+//	save [filename]      save the transcript, returning its path
+//	status               return a Status
+//	segments [n]         return the last n (default all) Segments
+//	duplicate [action]   return the Duplicate waiting to be resolved, or
+//	                     resolve it: merge, discard, discard-other or keep
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/exler/rekord/internal/logging"
+)
+
+const clientTimeout = 5 * time.Second
+
+// Response is the reply to a control command
+type Response struct {
+	OK    bool            `json:"ok"`
+	Error string          `json:"error,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+// Status describes the state of a running instance
+type Status struct {
+	Recording bool          `json:"recording"`
+	Elapsed   time.Duration `json:"elapsed_ns"`
+	Segments  int           `json:"segments"`
+	Device    string        `json:"device"`
+	Model     string        `json:"model"`
+}
+
+// Segment is a transcript segment as returned by the segments command
+type Segment struct {
+	Time time.Time `json:"time"`
+	// Offset is where the segment starts in the recorded audio
+	Offset time.Duration `json:"offset_ns"`
+	Source string        `json:"source,omitempty"`
+	Text   string        `json:"text"`
+}
+
+// Duplicate is a saved transcript that looks like a recording of the same
+// meeting as another one, as returned by the duplicate command
+type Duplicate struct {
+	Path       string  `json:"path"`
+	Other      string  `json:"other"`
+	Similarity float64 `json:"similarity"`
+}
+
+// Handler runs a control command and returns data to encode in the response
+type Handler func(args []string) (any, error)
+
+// DefaultSocketPath returns the socket path in the user's cache directory
+func DefaultSocketPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "rekord", "rekord.sock")
+}
+
+// Server accepts control connections and dispatches commands to handlers
+type Server struct {
+	mu       sync.Mutex
+	handlers map[string]Handler
+	listener net.Listener
+	path     string
+}
+
+// NewServer creates a server with no commands registered
+func NewServer() *Server {
+	return &Server{handlers: make(map[string]Handler)}
+}
+
+// Handle registers the handler for a command
+func (s *Server) Handle(command string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[command] = h
+}
+
+// Listen starts accepting connections on the socket at path. A stale socket
+// left by a crashed instance is replaced, but a live one is an error.
+func (s *Server) Listen(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("another rekord instance is listening on %s", path)
+	}
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.path = path
+
+	go s.acceptLoop()
+	return nil
+}
+
+// Close stops the server and removes the socket
+func (s *Server) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	err := s.listener.Close()
+	os.Remove(s.path)
+	return err
+}
+
+// acceptLoop serves connections until the listener is closed
+func (s *Server) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logging.Error("Control socket accept failed: %v", err)
+			}
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+// serve answers commands on one connection until the client disconnects
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		logging.Debug("Control command: %v", fields)
+		if err := encoder.Encode(s.dispatch(fields[0], fields[1:])); err != nil {
+			return
+		}
+	}
+}
+
+// dispatch runs a command and builds its response
+func (s *Server) dispatch(command string, args []string) Response {
+	s.mu.Lock()
+	h, ok := s.handlers[command]
+	s.mu.Unlock()
+	if !ok {
+		return Response{Error: fmt.Sprintf("unknown command %q", command)}
+	}
+
+	data, err := h(args)
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	resp := Response{OK: true}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		resp.Data = raw
+	}
+	return resp
+}
+
+// Send runs a command on the instance listening at path
+func Send(path, command string, args ...string) (Response, error) {
+	conn, err := net.DialTimeout("unix", path, clientTimeout)
+	if err != nil {
+		return Response{}, fmt.Errorf("no running rekord instance at %s: %w", path, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(clientTimeout))
+
+	line := strings.Join(append([]string{command}, args...), " ")
+	if _, err := fmt.Fprintln(conn, line); err != nil {
+		return Response{}, err
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return Response{}, fmt.Errorf("invalid control response: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,242 @@
+// Package crash writes a diagnostic bundle when rekord panics: the panic with
+// all goroutine stacks, the tail of the log, versions and whatever sections
+// the app adds, such as its configuration, zipped into the cache directory so
+// it can be attached to a bug report
+package crash
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/exler/rekord/internal/logging"
+)
+
+// logTail is how much of the end of the log goes into a bundle
+const logTail = 256 << 10
+
+var (
+	mu       sync.Mutex
+	sections []section
+	onCrash  func()
+	caught   *report // a panic recorded by Capture, not yet written
+)
+
+// section is a named file of a bundle
+type section struct {
+	name  string
+	write func(w io.Writer) error
+}
+
+// report is a panic and the stack of the goroutine it happened in
+type report struct {
+	value any
+	stack []byte
+}
+
+// AddSection adds a file called name to bundles, written by write when the
+// crash happens
+func AddSection(name string, write func(w io.Writer) error) {
+	mu.Lock()
+	defer mu.Unlock()
+	sections = append(sections, section{name, write})
+}
+
+// SetCrashHandler sets a function called before a bundle is written and the
+// process exits in Recover, e.g. to restore the terminal
+func SetCrashHandler(fn func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	onCrash = fn
+}
+
+// Dir is where bundles are written
+func Dir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "rekord", "crashes")
+}
+
+// Recover writes a bundle for a panic of the calling goroutine, tells the
+// user where it is and exits. It must be deferred directly, at the top of
+// main and of long-running goroutines.
+func Recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+
+	mu.Lock()
+	handler := onCrash
+	mu.Unlock()
+	if handler != nil {
+		handler()
+	}
+
+	fmt.Fprintf(os.Stderr, "\npanic: %v\n\n%s", r, stack)
+	Report(r, stack)
+	os.Exit(2)
+}
+
+// Capture records a panic of the calling goroutine and panics again, for
+// code whose caller recovers from panics itself, such as the Update and View
+// methods of a Bubble Tea model. The panic is written by ReportCaught. It
+// must be deferred directly.
+func Capture() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	mu.Lock()
+	if caught == nil {
+		caught = &report{value: r, stack: debug.Stack()}
+	}
+	mu.Unlock()
+	panic(r)
+}
+
+// ReportCaught writes a bundle for the panic recorded by Capture, if any,
+// and reports whether there was one
+func ReportCaught() bool {
+	mu.Lock()
+	r := caught
+	caught = nil
+	mu.Unlock()
+	if r == nil {
+		return false
+	}
+	Report(r.value, r.stack)
+	return true
+}
+
+// Report writes a bundle for the panic value with the stack of the
+// goroutine it happened in and prints where it is to stderr
+func Report(value any, stack []byte) {
+	logging.Error("Panic: %v\n%s", value, stack)
+
+	path, err := writeBundle(value, stack)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write crash report: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\nrekord crashed. A crash report was written to %s\nPlease attach it when reporting the bug.\n", path)
+}
+
+// writeBundle zips the diagnostics of a crash into Dir and returns its path
+func writeBundle(value any, stack []byte) (string, error) {
+	dir := Dir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	name := fmt.Sprintf("rekord-crash-%s.zip", now.Format("2006-01-02_15-04-05"))
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	z := zip.NewWriter(f)
+	add := func(name string, write func(w io.Writer) error) {
+		w, err := z.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err == nil {
+			err = write(w)
+		}
+		if err != nil {
+			// A partial bundle is more useful than none
+			fmt.Fprintf(os.Stderr, "Crash report is missing %s: %v\n", name, err)
+		}
+	}
+
+	add("panic.txt", func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "panic: %v\n\n%s\n\nall goroutines:\n\n%s", value, stack, allStacks())
+		return err
+	})
+	add("versions.txt", writeVersions)
+	if path := logging.GetLogPath(); path != "" {
+		add("log.txt", func(w io.Writer) error { return copyTail(w, path, logTail) })
+	}
+
+	mu.Lock()
+	extra := sections
+	mu.Unlock()
+	for _, s := range extra {
+		add(s.name, s.write)
+	}
+
+	if err := z.Close(); err != nil {
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// allStacks returns the stacks of all goroutines
+func allStacks() []byte {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 16<<20 {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// writeVersions writes the versions of rekord, Go and its dependencies
+func writeVersions(w io.Writer) error {
+	fmt.Fprintf(w, "os: %s/%s\ngo: %s\n", runtime.GOOS, runtime.GOARCH, runtime.Version())
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		_, err := fmt.Fprintln(w, "build info unavailable")
+		return err
+	}
+	fmt.Fprintf(w, "module: %s %s\n", info.Main.Path, info.Main.Version)
+	for _, s := range info.Settings {
+		fmt.Fprintf(w, "build %s: %s\n", s.Key, s.Value)
+	}
+	fmt.Fprintln(w, "\ndependencies:")
+	for _, dep := range info.Deps {
+		_, err := fmt.Fprintf(w, "%s %s\n", dep.Path, dep.Version)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyTail copies the last n bytes of the file at path to w, starting at a
+// whole line
+func copyTail(w io.Writer, path string, n int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	start := max(info.Size()-n, 0)
+	data := make([]byte, info.Size()-start)
+	if _, err := f.ReadAt(data, start); err != nil && err != io.EOF {
+		return err
+	}
+	if start > 0 {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	_, err = w.Write(data)
+	return err
+}
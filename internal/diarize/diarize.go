@@ -0,0 +1,104 @@
+// Package diarize tells apart the voices in a stream of audio, so that
+// segments of the system audio can be labeled with the remote participant
+// who spoke them. Voices are compared by a lightweight spectral embedding
+// and clustered online as segments arrive; it needs no model files but is
+// easily confused by similar voices, music or heavy compression.
+package diarize
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// DefaultThreshold is the distance between embeddings below which a voice is
+// taken to be one heard before
+const DefaultThreshold = 3.0
+
+// Clusterer assigns Speaker N labels to pieces of audio by the voice in
+// them. It is safe for concurrent use.
+type Clusterer struct {
+	// Threshold is the Distance from the closest known speaker above which
+	// a voice counts as a new speaker
+	Threshold float64
+	// MaxSpeakers limits how many speakers are told apart; further voices
+	// are given to the closest known speaker. 0 means no limit.
+	MaxSpeakers int
+
+	mu       sync.Mutex
+	speakers []cluster
+	last     int // index of the speaker of the previous piece, -1 for none
+}
+
+// cluster is a speaker and the mean embedding of their voice
+type cluster struct {
+	centroid Embedding
+	count    int
+}
+
+// NewClusterer creates a Clusterer telling apart up to maxSpeakers voices
+func NewClusterer(maxSpeakers int) *Clusterer {
+	return &Clusterer{Threshold: DefaultThreshold, MaxSpeakers: maxSpeakers, last: -1}
+}
+
+// Speaker returns the label of the speaker of 16kHz mono samples, e.g.
+// "Speaker 2". Pieces too short to embed are given the speaker of the
+// previous piece, or "" if there was none.
+func (c *Clusterer) Speaker(samples []float32) string {
+	emb := Embed(samples)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if emb == nil {
+		return c.label(c.last)
+	}
+
+	best, bestDist := -1, math.Inf(1)
+	for i, s := range c.speakers {
+		if d := Distance(emb, s.centroid); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+
+	full := c.MaxSpeakers > 0 && len(c.speakers) >= c.MaxSpeakers
+	if best < 0 || (bestDist > c.Threshold && !full) {
+		c.speakers = append(c.speakers, cluster{centroid: emb, count: 1})
+		c.last = len(c.speakers) - 1
+		return c.label(c.last)
+	}
+
+	s := &c.speakers[best]
+	s.count++
+	for i, v := range emb {
+		s.centroid[i] += (v - s.centroid[i]) / float64(s.count)
+	}
+	c.last = best
+	return c.label(best)
+}
+
+// Speakers returns how many speakers were told apart so far
+func (c *Clusterer) Speakers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.speakers)
+}
+
+// label names the speaker with index i
+func (c *Clusterer) label(i int) string {
+	if i < 0 {
+		return ""
+	}
+	return fmt.Sprintf("Speaker %d", i+1)
+}
+
+// Distance returns the root mean square difference of two embeddings. The
+// same voice is usually within 2 of itself and different voices further
+// apart, depending on the recording.
+func Distance(a, b Embedding) float64 {
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(a)))
+}
@@ -0,0 +1,176 @@
+package diarize
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Analysis parameters for 16kHz audio: 25ms frames every 10ms, 24 mel bands
+// up to 8kHz and 12 cepstral coefficients, leaving out c0 (loudness)
+const (
+	sampleRate = 16000
+	frameSize  = 400
+	frameHop   = 160
+	fftSize    = 512
+	melBands   = 24
+	cepstra    = 12
+)
+
+// minVoicedFrames is how many frames above the silence floor are needed for
+// an embedding, i.e. half a second of speech
+const minVoicedFrames = 50
+
+// silenceDBFS is the frame level below which frames are left out of an
+// embedding
+const silenceDBFS = -50
+
+// The analysis window and mel filterbank, computed once
+var (
+	window  = hann(frameSize)
+	filters = melFilters()
+)
+
+// Embedding summarizes the voice in a piece of audio: the mean and standard
+// deviation of its mel-frequency cepstral coefficients over the voiced
+// frames
+type Embedding []float64
+
+// Embed computes the embedding of 16kHz mono samples. It returns nil if
+// there is too little speech to tell a voice by.
+func Embed(samples []float32) Embedding {
+	var frames [][cepstra]float64
+	spectrum := make([]complex128, fftSize)
+	for start := 0; start+frameSize <= len(samples); start += frameHop {
+		frame := samples[start : start+frameSize]
+		var energy float64
+		for _, v := range frame {
+			energy += float64(v) * float64(v)
+		}
+		if 10*math.Log10(energy/frameSize+1e-12) < silenceDBFS {
+			continue
+		}
+
+		for i := range spectrum {
+			spectrum[i] = 0
+		}
+		for i, v := range frame {
+			spectrum[i] = complex(float64(v)*window[i], 0)
+		}
+		fft(spectrum)
+
+		var bands [melBands]float64
+		for b, filter := range filters {
+			var sum float64
+			for bin, w := range filter.weights {
+				p := cmplx.Abs(spectrum[filter.from+bin])
+				sum += w * p * p
+			}
+			bands[b] = math.Log(sum + 1e-10)
+		}
+		frames = append(frames, dct(bands))
+	}
+	if len(frames) < minVoicedFrames {
+		return nil
+	}
+
+	emb := make(Embedding, 2*cepstra)
+	for _, c := range frames {
+		for i, v := range c {
+			emb[i] += v
+		}
+	}
+	for i := range cepstra {
+		emb[i] /= float64(len(frames))
+	}
+	for _, c := range frames {
+		for i, v := range c {
+			d := v - emb[i]
+			emb[cepstra+i] += d * d
+		}
+	}
+	for i := range cepstra {
+		emb[cepstra+i] = math.Sqrt(emb[cepstra+i] / float64(len(frames)))
+	}
+	return emb
+}
+
+// hann returns a Hann window of n samples
+func hann(n int) []float64 {
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+	}
+	return w
+}
+
+// melFilter is a triangular filter over the FFT bins from on
+type melFilter struct {
+	from    int
+	weights []float64
+}
+
+// melFilters returns triangular filters spaced evenly on the mel scale
+// between 0Hz and the Nyquist frequency
+func melFilters() []melFilter {
+	mel := func(hz float64) float64 { return 2595 * math.Log10(1+hz/700) }
+	hz := func(m float64) float64 { return 700 * (math.Pow(10, m/2595) - 1) }
+
+	top := mel(sampleRate / 2)
+	var edges [melBands + 2]float64
+	for i := range edges {
+		edges[i] = hz(top*float64(i)/float64(melBands+1)) * fftSize / sampleRate
+	}
+
+	filters := make([]melFilter, melBands)
+	for b := range filters {
+		lo, mid, hi := edges[b], edges[b+1], edges[b+2]
+		f := melFilter{from: int(math.Ceil(lo))}
+		for bin := f.from; float64(bin) < hi && bin <= fftSize/2; bin++ {
+			x := float64(bin)
+			if x <= mid {
+				f.weights = append(f.weights, (x-lo)/(mid-lo))
+			} else {
+				f.weights = append(f.weights, (hi-x)/(hi-mid))
+			}
+		}
+		filters[b] = f
+	}
+	return filters
+}
+
+// dct returns cepstral coefficients 1 to cepstra of log mel band energies
+func dct(bands [melBands]float64) [cepstra]float64 {
+	var c [cepstra]float64
+	for k := range c {
+		for n, v := range bands {
+			c[k] += v * math.Cos(math.Pi*float64(k+1)*(float64(n)+0.5)/melBands)
+		}
+	}
+	return c
+}
+
+// fft transforms x in place. len(x) must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := range size / 2 {
+				a, b := x[start+k], x[start+k+size/2]*w
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
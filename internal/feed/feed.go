@@ -0,0 +1,165 @@
+// Package feed streams finalized transcript segments to external consumers
+// such as an editor buffer being tailed from a file or a TCP client
+package feed
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// Config holds feed configuration
+type Config struct {
+	// FilePath is a file segments are appended to (empty to disable)
+	FilePath string
+	// Addr is a TCP address to accept feed clients on (empty to disable)
+	Addr string
+	// TimeFormat is how segment times are written (wall clock by default)
+	TimeFormat transcriber.TimeFormat
+}
+
+// A client that has clientBacklog lines waiting, or takes longer than
+// clientWriteTimeout to accept one, is disconnected so it cannot hold up
+// the segments of everyone else
+const (
+	clientBacklog      = 256
+	clientWriteTimeout = 10 * time.Second
+)
+
+// Feed writes each finalized segment as a single line to a file and to all
+// connected TCP clients
+type Feed struct {
+	mu       sync.Mutex
+	file     *os.File
+	listener net.Listener
+	clients  map[*client]struct{}
+	format   transcriber.TimeFormat
+}
+
+// client is a connected TCP client and the lines waiting to be sent to it
+type client struct {
+	conn  net.Conn
+	lines chan string
+}
+
+// New creates a feed for the given configuration
+func New(cfg Config) (*Feed, error) {
+	f := &Feed{
+		clients: make(map[*client]struct{}),
+		format:  cfg.TimeFormat,
+	}
+
+	if cfg.FilePath != "" {
+		file, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open feed file: %w", err)
+		}
+		f.file = file
+	}
+
+	if cfg.Addr != "" {
+		ln, err := net.Listen("tcp", cfg.Addr)
+		if err != nil {
+			if f.file != nil {
+				f.file.Close()
+			}
+			return nil, fmt.Errorf("failed to listen on %s: %w", cfg.Addr, err)
+		}
+		f.listener = ln
+		go f.acceptLoop()
+	}
+
+	return f, nil
+}
+
+// acceptLoop registers new TCP clients until the listener is closed
+func (f *Feed) acceptLoop() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		logging.Info("Feed client connected: %s", conn.RemoteAddr())
+
+		c := &client{conn: conn, lines: make(chan string, clientBacklog)}
+		f.mu.Lock()
+		f.clients[c] = struct{}{}
+		f.mu.Unlock()
+		go f.send(c)
+	}
+}
+
+// send writes the lines queued for c until it is disconnected
+func (f *Feed) send(c *client) {
+	for line := range c.lines {
+		c.conn.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+		if _, err := c.conn.Write([]byte(line)); err != nil {
+			f.mu.Lock()
+			f.disconnect(c)
+			f.mu.Unlock()
+			return
+		}
+	}
+}
+
+// disconnect closes the connection of c and stops its sender. The caller
+// must hold mu.
+func (f *Feed) disconnect(c *client) {
+	if _, ok := f.clients[c]; !ok {
+		return
+	}
+	logging.Info("Feed client disconnected: %s", c.conn.RemoteAddr())
+	delete(f.clients, c)
+	close(c.lines)
+	c.conn.Close()
+}
+
+// Write sends a segment to the file and queues it for all connected
+// clients. Clients too far behind to take it are disconnected.
+func (f *Feed) Write(seg transcriber.Segment) {
+	line := fmt.Sprintf("[%s] %s\n", f.format.Format(seg), seg.Label())
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil {
+		if _, err := f.file.WriteString(line); err != nil {
+			logging.Error("Failed to write feed file: %v", err)
+		}
+	}
+
+	for c := range f.clients {
+		select {
+		case c.lines <- line:
+		default:
+			logging.Warn("Feed client %s fell behind", c.conn.RemoteAddr())
+			f.disconnect(c)
+		}
+	}
+}
+
+// Close stops accepting clients and releases all resources
+func (f *Feed) Close() error {
+	if f.listener != nil {
+		f.listener.Close()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for c := range f.clients {
+		f.disconnect(c)
+	}
+
+	if f.file != nil {
+		err := f.file.Close()
+		f.file = nil
+		return err
+	}
+	return nil
+}
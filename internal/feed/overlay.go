@@ -0,0 +1,145 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// Overlay keeps the latest lines of the transcript in a file for live
+// captions, e.g. an OBS text source or an i3bar/polybar block. A regular file
+// is rewritten with one line per segment on every update. A named pipe is
+// sent each update as a single line instead, for status bars reading line by
+// line.
+type Overlay struct {
+	mu    sync.Mutex
+	path  string
+	lines int
+	pipe  bool
+	fifo  *os.File // open named pipe, nil until a reader is connected
+	last  []string
+}
+
+// NewOverlay creates an overlay showing the latest lines segments at path,
+// creating the file if it does not exist
+func NewOverlay(path string, lines int) (*Overlay, error) {
+	if lines < 1 {
+		return nil, fmt.Errorf("overlay needs at least one line, got %d", lines)
+	}
+	o := &Overlay{path: path, lines: lines}
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		o.pipe = info.Mode()&os.ModeNamedPipe != 0
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to open overlay file: %w", err)
+	}
+	if !o.pipe {
+		if err := o.writeFile(""); err != nil {
+			return nil, fmt.Errorf("failed to write overlay file: %w", err)
+		}
+	}
+	return o, nil
+}
+
+// Write shows a segment as the latest line of the overlay
+func (o *Overlay) Write(seg transcriber.Segment) {
+	line := strings.Join(strings.Fields(seg.Label()), " ")
+	if line == "" {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.last = append(o.last, line)
+	if len(o.last) > o.lines {
+		o.last = o.last[len(o.last)-o.lines:]
+	}
+	o.show()
+}
+
+// Clear empties the overlay, e.g. when the recording stops
+func (o *Overlay) Clear() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.last = nil
+	o.show()
+}
+
+// show writes the current lines to the file or pipe
+func (o *Overlay) show() {
+	if o.pipe {
+		o.writePipe(strings.Join(o.last, " "))
+		return
+	}
+	text := strings.Join(o.last, "\n")
+	if text != "" {
+		text += "\n"
+	}
+	if err := o.writeFile(text); err != nil {
+		logging.Error("Failed to write overlay file: %v", err)
+	}
+}
+
+// writeFile replaces the overlay file with text, through a rename so that
+// readers never see it half written
+func (o *Overlay) writeFile(text string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(o.path), ".rekord-overlay-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.WriteString(text); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), o.path)
+}
+
+// writePipe sends line to the named pipe. Updates are dropped while no
+// reader has the pipe open or the reader falls behind, rather than blocking
+// the transcript.
+func (o *Overlay) writePipe(line string) {
+	if o.fifo == nil {
+		f, err := os.OpenFile(o.path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+		if err != nil {
+			logging.Debug("Overlay pipe has no reader: %v", err)
+			return
+		}
+		o.fifo = f
+	}
+	if _, err := o.fifo.WriteString(line + "\n"); err != nil {
+		logging.Debug("Overlay pipe reader gone: %v", err)
+		o.fifo.Close()
+		o.fifo = nil
+	}
+}
+
+// Close clears the overlay and releases the pipe
+func (o *Overlay) Close() error {
+	o.Clear()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.fifo != nil {
+		err := o.fifo.Close()
+		o.fifo = nil
+		return err
+	}
+	return nil
+}
@@ -2,30 +2,87 @@
 package logging
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"log"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
 
+// ParseLevel parses a level name: debug, info, warn or error
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+}
+
+// Options configures log format, filtering and rotation
+type Options struct {
+	// Level filters out less severe messages
+	Level slog.Level
+	// JSON writes one JSON object per line instead of key=value text
+	JSON bool
+	// MaxSize is the size in bytes at which the log is rotated, 0 for no
+	// limit
+	MaxSize int64
+	// MaxAge is how long one log file is written to before it is rotated,
+	// 0 for no limit
+	MaxAge time.Duration
+	// MaxBackups is how many rotated files of the log are kept, the oldest
+	// being deleted on rotation, 0 to keep all
+	MaxBackups int
+	// Retention is the age beyond which old logs in the directory are
+	// deleted at startup, 0 to keep all
+	Retention time.Duration
+}
+
 var (
 	logFile       *os.File
-	logger        *log.Logger
+	logger        *slog.Logger
 	mu            sync.Mutex
 	logPath       string
-	discardLogger *log.Logger
+	discardLogger = slog.New(slog.DiscardHandler)
+	options       Options
+
+	// Rotation state of the current log file
+	written  int64
+	openedAt time.Time
+	rotated  int
+	previous *os.File
 )
 
-func init() {
-	// Create a discard logger for when logging is not initialized
-	discardLogger = log.New(io.Discard, "", 0)
+// Init initializes the logging system
+func Init(dir string, opts Options) error {
+	if err := open(dir, opts); err != nil {
+		return err
+	}
+	Info("Rekord logging initialized")
+
+	if opts.Retention > 0 {
+		if n, err := cleanup(dir, opts.Retention); err != nil {
+			Warn("Failed to clean up old logs: %v", err)
+		} else if n > 0 {
+			Info("Deleted %d log file(s) older than %s", n, opts.Retention)
+		}
+	}
+	return nil
 }
 
-// Init initializes the logging system
-func Init(dir string) error {
+// open creates the log file for this run
+func open(dir string, opts Options) error {
 	mu.Lock()
 	defer mu.Unlock()
 
@@ -33,6 +90,7 @@ func Init(dir string) error {
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create log directory: %w", err)
 	}
+	options = opts
 
 	// Create log file with timestamp
 	filename := fmt.Sprintf("rekord_%s.log", time.Now().Format("2006-01-02_15-04-05"))
@@ -43,20 +101,113 @@ func Init(dir string) error {
 	if err != nil {
 		return fmt.Errorf("failed to open log file: %w", err)
 	}
+	written = 0
+	openedAt = time.Now()
+	rotated = 0
 
-	logger = log.New(logFile, "", log.LstdFlags|log.Lmicroseconds)
-	logger.Printf("Rekord logging initialized")
+	// The file sink keeps log output away from the TUI
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+	if opts.JSON {
+		logger = slog.New(slog.NewJSONHandler(writerFunc(write), handlerOpts))
+	} else {
+		logger = slog.New(slog.NewTextHandler(writerFunc(write), handlerOpts))
+	}
+	return nil
+}
+
+// writerFunc adapts a function to io.Writer
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// write appends a log line to the current file, rotating it first if it
+// grew too large or too old. It is called by the logger, which serializes
+// writes, but takes mu since rotation swaps the file others may read.
+func write(p []byte) (int, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if logFile == nil {
+		return len(p), nil
+	}
+	if (options.MaxSize > 0 && written+int64(len(p)) > options.MaxSize && written > 0) ||
+		(options.MaxAge > 0 && time.Since(openedAt) > options.MaxAge) {
+		if err := rotate(); err != nil {
+			fmt.Fprintf(logFile, "rotating log failed: %v\n", err)
+		}
+	}
+
+	n, err := logFile.Write(p)
+	written += int64(n)
+	return n, err
+}
 
+// rotate moves the current log aside as <log>.N and starts a new file under
+// the same path, so readers of GetLogPath keep following the live log. The
+// previous file stays open until the next rotation in case an external
+// command was just handed it as its stderr. Rotated files beyond
+// MaxBackups are deleted, oldest first.
+func rotate() error {
+	rotated++
+	if err := os.Rename(logPath, fmt.Sprintf("%s.%d", logPath, rotated)); err != nil {
+		return err
+	}
+	if oldest := rotated - options.MaxBackups; options.MaxBackups > 0 && oldest > 0 {
+		if err := os.Remove(fmt.Sprintf("%s.%d", logPath, oldest)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(logFile, "deleting old log failed: %v\n", err)
+		}
+	}
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		previous.Close()
+	}
+	previous = logFile
+	logFile = f
+	written = 0
+	openedAt = time.Now()
 	return nil
 }
 
+// cleanup deletes rekord logs in dir last written more than maxAge ago and
+// returns how many were removed
+func cleanup(dir string, maxAge time.Duration) (int, error) {
+	logs, err := filepath.Glob(filepath.Join(dir, "rekord_*.log*"))
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, path := range logs {
+		info, err := os.Stat(path)
+		if err != nil || path == GetLogPath() || time.Since(info.ModTime()) <= maxAge {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
 // Close closes the log file
 func Close() error {
 	mu.Lock()
-	defer mu.Unlock()
+	l := logger
+	mu.Unlock()
+	if l != nil {
+		l.Info("Rekord logging closed")
+	}
 
+	mu.Lock()
+	defer mu.Unlock()
+	if previous != nil {
+		previous.Close()
+		previous = nil
+	}
 	if logFile != nil {
-		logger.Printf("Rekord logging closed")
 		err := logFile.Close()
 		logFile = nil
 		logger = nil
@@ -72,8 +223,9 @@ func GetLogPath() string {
 	return logPath
 }
 
-// GetLogger returns the logger instance
-func GetLogger() *log.Logger {
+// GetLogger returns the logger instance, for messages with structured
+// key-value fields
+func GetLogger() *slog.Logger {
 	mu.Lock()
 	defer mu.Unlock()
 	if logger == nil {
@@ -89,26 +241,31 @@ func GetLogFile() *os.File {
 	return logFile
 }
 
+// logf formats and logs a message at the given level
+func logf(level slog.Level, format string, args ...any) {
+	l := GetLogger()
+	if !l.Enabled(context.Background(), level) {
+		return
+	}
+	l.Log(context.Background(), level, fmt.Sprintf(format, args...))
+}
+
 // Info logs an info message
 func Info(format string, args ...any) {
-	l := GetLogger()
-	l.Printf("[INFO] "+format, args...)
+	logf(slog.LevelInfo, format, args...)
 }
 
 // Error logs an error message
 func Error(format string, args ...any) {
-	l := GetLogger()
-	l.Printf("[ERROR] "+format, args...)
+	logf(slog.LevelError, format, args...)
 }
 
 // Debug logs a debug message
 func Debug(format string, args ...any) {
-	l := GetLogger()
-	l.Printf("[DEBUG] "+format, args...)
+	logf(slog.LevelDebug, format, args...)
 }
 
 // Warn logs a warning message
 func Warn(format string, args ...any) {
-	l := GetLogger()
-	l.Printf("[WARN] "+format, args...)
+	logf(slog.LevelWarn, format, args...)
 }
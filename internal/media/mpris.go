@@ -0,0 +1,68 @@
+// Package media pauses desktop media players over MPRIS while recording, so
+// background music does not end up in the system audio transcript
+package media
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Players pauses the MPRIS players that are playing and resumes exactly
+// those later, leaving players the user paused alone. It uses playerctl,
+// which talks to every MPRIS player on the session bus.
+type Players struct {
+	mu     sync.Mutex
+	paused []string
+}
+
+// Pause pauses every player that is currently playing
+func (p *Players) Pause() error {
+	if _, err := exec.LookPath("playerctl"); err != nil {
+		return errors.New("playerctl not found, install it to pause media players")
+	}
+	out, err := exec.Command("playerctl", "--list-all").Output()
+	if err != nil {
+		// playerctl exits non-zero when no players are running
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var errs []error
+	for _, name := range strings.Fields(string(out)) {
+		status, err := exec.Command("playerctl", "--player="+name, "status").Output()
+		if err != nil || strings.TrimSpace(string(status)) != "Playing" {
+			continue
+		}
+		if err := exec.Command("playerctl", "--player="+name, "pause").Run(); err != nil {
+			errs = append(errs, fmt.Errorf("pausing %s: %w", name, err))
+			continue
+		}
+		p.paused = append(p.paused, name)
+	}
+	return errors.Join(errs...)
+}
+
+// Resume resumes the players paused by Pause
+func (p *Players) Resume() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var errs []error
+	for _, name := range p.paused {
+		if err := exec.Command("playerctl", "--player="+name, "play").Run(); err != nil {
+			errs = append(errs, fmt.Errorf("resuming %s: %w", name, err))
+		}
+	}
+	p.paused = nil
+	return errors.Join(errs...)
+}
+
+// Paused returns the names of the players paused by Pause
+func (p *Players) Paused() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.paused...)
+}
@@ -0,0 +1,231 @@
+// Package metrics exposes counters, gauges and histograms in the Prometheus
+// text format, so headless recorders and model servers can be scraped
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Path is where metrics are served
+const Path = "/metrics"
+
+// DurationBuckets are histogram bucket bounds in seconds suited to
+// transcribing chunks of a few seconds to a minute
+var DurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 20, 30, 60, 120}
+
+// Registry holds metrics in the order they were registered
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// metric is a registered metric that writes its samples
+type metric interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register adds a metric, panicking on duplicate names like the flag package
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, other := range r.metrics {
+		if other.name() == m.name() {
+			panic("metrics: duplicate metric " + m.name())
+		}
+	}
+	r.metrics = append(r.metrics, m)
+}
+
+// Counter registers a value that only goes up
+func (r *Registry) Counter(name, help string) *Counter {
+	c := &Counter{desc: desc{name, help, "counter"}}
+	r.register(c)
+	return c
+}
+
+// Gauge registers a value that goes up and down
+func (r *Registry) Gauge(name, help string) *Gauge {
+	g := &Gauge{desc: desc{name, help, "gauge"}}
+	r.register(g)
+	return g
+}
+
+// CounterFunc registers a counter whose value is read from fn when scraped
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{desc: desc{name, help, "counter"}, fn: fn})
+}
+
+// GaugeFunc registers a gauge whose value is read from fn when scraped
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{desc: desc{name, help, "gauge"}, fn: fn})
+}
+
+// Histogram registers a distribution of observed values counted into
+// buckets with the given upper bounds, in increasing order
+func (r *Registry) Histogram(name, help string, buckets []float64) *Histogram {
+	h := &Histogram{desc: desc{name, help, "histogram"}, bounds: buckets, counts: make([]uint64, len(buckets))}
+	r.register(h)
+	return h
+}
+
+// ServeHTTP writes all metrics in the Prometheus text format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.mu.Lock()
+	metrics := r.metrics
+	r.mu.Unlock()
+
+	out := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(out)
+	}
+	out.Flush()
+}
+
+// desc is the name, help text and type of a metric
+type desc struct {
+	id, help, kind string
+}
+
+func (d desc) name() string {
+	return d.id
+}
+
+// header writes the HELP and TYPE lines of the metric
+func (d desc) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.id, d.help, d.id, d.kind)
+}
+
+// Counter is a value that only goes up. It is safe for concurrent use.
+type Counter struct {
+	desc
+	mu    sync.Mutex
+	value float64
+}
+
+// Add increases the counter by v, which must not be negative
+func (c *Counter) Add(v float64) {
+	c.mu.Lock()
+	c.value += v
+	c.mu.Unlock()
+}
+
+// Inc increases the counter by one
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	v := c.value
+	c.mu.Unlock()
+	c.header(w)
+	fmt.Fprintf(w, "%s %s\n", c.id, formatValue(v))
+}
+
+// Gauge is a value that goes up and down. It is safe for concurrent use.
+type Gauge struct {
+	desc
+	mu    sync.Mutex
+	value float64
+}
+
+// Set sets the gauge to v
+func (g *Gauge) Set(v float64) {
+	g.mu.Lock()
+	g.value = v
+	g.mu.Unlock()
+}
+
+// Add changes the gauge by v
+func (g *Gauge) Add(v float64) {
+	g.mu.Lock()
+	g.value += v
+	g.mu.Unlock()
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.mu.Lock()
+	v := g.value
+	g.mu.Unlock()
+	g.header(w)
+	fmt.Fprintf(w, "%s %s\n", g.id, formatValue(v))
+}
+
+// funcMetric is a counter or gauge read from a function when scraped
+type funcMetric struct {
+	desc
+	fn func() float64
+}
+
+func (f *funcMetric) write(w *bufio.Writer) {
+	f.header(w)
+	fmt.Fprintf(w, "%s %s\n", f.id, formatValue(f.fn()))
+}
+
+// Histogram counts observed values into buckets. It is safe for concurrent
+// use.
+type Histogram struct {
+	desc
+	bounds []float64
+
+	mu     sync.Mutex
+	counts []uint64 // observations per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Observe records a value
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	counts := append([]uint64(nil), h.counts...)
+	count, sum := h.count, h.sum
+	h.mu.Unlock()
+
+	h.header(w)
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.id, formatValue(bound), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.id, count)
+	fmt.Fprintf(w, "%s_sum %s\n", h.id, formatValue(sum))
+	fmt.Fprintf(w, "%s_count %d\n", h.id, count)
+}
+
+// formatValue formats a sample value the way Prometheus expects
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
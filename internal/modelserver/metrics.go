@@ -0,0 +1,60 @@
+package modelserver
+
+import (
+	"time"
+
+	"github.com/exler/rekord/internal/audio"
+	"github.com/exler/rekord/internal/metrics"
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// serverMetrics are the metrics served at metrics.Path
+type serverMetrics struct {
+	registry      *metrics.Registry
+	requests      *metrics.Counter
+	failures      *metrics.Counter
+	waiting       *metrics.Gauge
+	transcription *metrics.Histogram
+	audio         *metrics.Counter
+	realTime      *metrics.Gauge
+}
+
+// newServerMetrics registers the metrics of transcription requests
+func newServerMetrics() *serverMetrics {
+	reg := metrics.NewRegistry()
+	return &serverMetrics{
+		registry:      reg,
+		requests:      reg.Counter("rekord_server_requests_total", "Transcription requests served."),
+		failures:      reg.Counter("rekord_server_request_failures_total", "Transcription requests that failed."),
+		waiting:       reg.Gauge("rekord_server_waiting_requests", "Requests waiting for the backend to finish an earlier one."),
+		transcription: reg.Histogram("rekord_transcription_seconds", "Time the backend took to transcribe a request.", metrics.DurationBuckets),
+		audio:         reg.Counter("rekord_transcribed_audio_seconds_total", "Audio transcribed."),
+		realTime:      reg.Gauge("rekord_real_time_factor", "Transcription time divided by audio length of the last request; above 1 is slower than real time."),
+	}
+}
+
+// serialized runs transcribe for samples with s.mu held, so one model load
+// serves everyone, and records how it went
+func (s *Server) serialized(samples int, transcribe func() ([]transcriber.Segment, error)) ([]transcriber.Segment, error) {
+	m := s.metrics
+	m.waiting.Add(1)
+	s.mu.Lock()
+	m.waiting.Add(-1)
+	start := time.Now()
+	segments, err := transcribe()
+	took := time.Since(start)
+	s.mu.Unlock()
+
+	m.requests.Inc()
+	if err != nil {
+		m.failures.Inc()
+		return nil, err
+	}
+	audioLength := time.Duration(samples) * time.Second / audio.SampleRate
+	m.transcription.Observe(took.Seconds())
+	m.audio.Add(audioLength.Seconds())
+	if audioLength > 0 {
+		m.realTime.Set(took.Seconds() / audioLength.Seconds())
+	}
+	return segments, nil
+}
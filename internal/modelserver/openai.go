@@ -0,0 +1,199 @@
+package modelserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/exler/rekord/internal/audio"
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/transcriber"
+	"github.com/exler/rekord/internal/transcript"
+)
+
+// OpenAIPath is the OpenAI-compatible transcription endpoint, so tools
+// written against the OpenAI API can use the local model by pointing their
+// base URL at the server
+const OpenAIPath = "/v1/audio/transcriptions"
+
+// maxUploadBytes bounds the size of an uploaded audio or video file
+const maxUploadBytes = 200 << 20
+
+// openAISegment is a segment of a verbose_json response
+type openAISegment struct {
+	ID    int     `json:"id"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// handleOpenAI transcribes an uploaded file the way the OpenAI API does. The
+// file can be anything ffmpeg decodes; the model form field is ignored
+// since the server has one model loaded. Supported response formats are
+// json, text, verbose_json and srt.
+func (s *Server) handleOpenAI(w http.ResponseWriter, r *http.Request) {
+	if status, err := s.authorize(r); err != nil {
+		logging.Warn("Rejected OpenAI request from %s: %v", r.RemoteAddr, err)
+		openAIError(w, status, "%v", err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		openAIError(w, http.StatusBadRequest, "missing or unreadable file: %v", err)
+		return
+	}
+	defer file.Close()
+
+	format := r.FormValue("response_format")
+	switch format {
+	case "":
+		format = "json"
+	case "json", "text", "verbose_json", "srt":
+	default:
+		openAIError(w, http.StatusBadRequest, "unsupported response_format %q", format)
+		return
+	}
+
+	samples, err := decodeUpload(r.Context(), file)
+	if err != nil {
+		openAIError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	segments, err := s.serialized(len(samples), func() ([]transcriber.Segment, error) {
+		return s.transcribeLong(r.Context(), samples, r.FormValue("prompt"))
+	})
+	if err != nil {
+		logging.Error("Transcription for %s failed: %v", r.RemoteAddr, err)
+		openAIError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	logging.Info("Transcribed %s upload for %s: %d segments", format, r.RemoteAddr, len(segments))
+
+	texts := make([]string, len(segments))
+	for i, seg := range segments {
+		texts[i] = seg.Text
+	}
+	text := strings.Join(texts, " ")
+
+	switch format {
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, text)
+	case "srt":
+		w.Header().Set("Content-Type", "application/x-subrip")
+		transcript.WriteSRT(w, segments, nil)
+	case "verbose_json":
+		resp := struct {
+			Task     string          `json:"task"`
+			Language string          `json:"language"`
+			Duration float64         `json:"duration"`
+			Text     string          `json:"text"`
+			Segments []openAISegment `json:"segments"`
+		}{
+			Task:     "transcribe",
+			Duration: float64(len(samples)) / audio.SampleRate,
+			Text:     text,
+			Segments: []openAISegment{},
+		}
+		for i, seg := range segments {
+			if resp.Language == "" {
+				resp.Language = seg.Language
+			}
+			resp.Segments = append(resp.Segments, openAISegment{
+				ID:    i,
+				Start: seg.StartTime.Seconds(),
+				End:   seg.EndTime.Seconds(),
+				Text:  seg.Text,
+			})
+		}
+		writeJSON(w, resp)
+	default:
+		writeJSON(w, struct {
+			Text string `json:"text"`
+		}{text})
+	}
+}
+
+// decodeUpload stores the uploaded file in a temporary file, since ffmpeg
+// needs to seek in most containers, and decodes its audio
+func decodeUpload(ctx context.Context, file io.Reader) ([]float32, error) {
+	tmp, err := os.CreateTemp("", "rekord-upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, file); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	samples, err := audio.DecodeFile(ctx, tmp.Name())
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, errors.New("file contains no audio")
+	}
+	return samples, nil
+}
+
+// transcribeLong transcribes samples in chunks of at most
+// maxRequestSamples, cut at quiet points, with segments timed from the
+// start. A prompt is applied for this request only, on backends that take
+// one. The caller must hold s.mu.
+func (s *Server) transcribeLong(ctx context.Context, samples []float32, prompt string) ([]transcriber.Segment, error) {
+	if p, ok := s.backend.(transcriber.Prompter); ok && prompt != "" {
+		p.SetPrompt(prompt)
+		defer p.SetPrompt("")
+	}
+
+	var segments []transcriber.Segment
+	for pos := 0; pos < len(samples); {
+		end := min(pos+maxRequestSamples, len(samples))
+		if end < len(samples) {
+			end = audio.FindQuietestPoint(samples, end-5*audio.SampleRate, end)
+		}
+		segs, err := s.backend.Transcribe(ctx, samples[pos:end])
+		if err != nil {
+			return nil, err
+		}
+		offset := time.Duration(pos) * time.Second / audio.SampleRate
+		for _, seg := range segs {
+			seg.Shift(offset)
+			segments = append(segments, seg)
+		}
+		pos = end
+	}
+	return segments, nil
+}
+
+// openAIError writes an error in the format of the OpenAI API
+func openAIError(w http.ResponseWriter, status int, format string, args ...any) {
+	kind := "invalid_request_error"
+	if status >= 500 {
+		kind = "server_error"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]map[string]string{
+		"error": {"message": fmt.Sprintf(format, args...), "type": kind},
+	})
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
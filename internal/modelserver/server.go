@@ -0,0 +1,140 @@
+// Package modelserver shares one transcription backend with other rekord
+// instances on the network
+package modelserver
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/grandcat/zeroconf"
+
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/metrics"
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// maxRequestSamples bounds a single request to ten minutes of audio
+const maxRequestSamples = 16000 * 60 * 10
+
+// Server serves transcription requests over HTTP and advertises itself via
+// mDNS so clients can find it with -server auto
+type Server struct {
+	backend transcriber.Backend
+	mu      sync.Mutex // serializes requests so one model load serves everyone
+	mdns    *zeroconf.Server
+	metrics *serverMetrics
+	apiKey  string // required by the /v1 endpoints, if set
+}
+
+// New creates a model server for the given backend
+func New(backend transcriber.Backend) *Server {
+	return &Server{backend: backend, metrics: newServerMetrics()}
+}
+
+// SetAPIKey sets the key clients of the /v1 endpoints must send as a bearer
+// token. Without one, they only answer clients on this machine.
+func (s *Server) SetAPIKey(key string) {
+	s.apiKey = key
+}
+
+// ListenAndServe serves on addr until an error occurs. If advertise is true,
+// the server is announced on the local network via mDNS.
+func (s *Server) ListenAndServe(addr string, advertise bool) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	if advertise {
+		port := ln.Addr().(*net.TCPAddr).Port
+		host, _ := os.Hostname()
+		s.mdns, err = zeroconf.Register("rekord-"+host, transcriber.ServiceType, "local.", port, nil, nil)
+		if err != nil {
+			ln.Close()
+			return fmt.Errorf("failed to advertise via mDNS: %w", err)
+		}
+		defer s.mdns.Shutdown()
+	}
+
+	logging.Info("Model server listening on %s", ln.Addr())
+	return http.Serve(ln, s.handler())
+}
+
+// handler routes the requests of the server
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+transcriber.TranscribePath, s.handleTranscribe)
+	mux.HandleFunc("POST "+OpenAIPath, s.handleOpenAI)
+	mux.Handle("GET "+metrics.Path, s.metrics.registry)
+	return mux
+}
+
+// authorize checks that r may use the /v1 endpoints: with an API key set, it
+// must send it as a bearer token like OpenAI clients do; otherwise it must
+// come from this machine. It returns the status to reject it with.
+func (s *Server) authorize(r *http.Request) (int, error) {
+	if s.apiKey != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.apiKey)) != 1 {
+			return http.StatusUnauthorized, errors.New("invalid or missing API key")
+		}
+		return 0, nil
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+		return http.StatusForbidden, errors.New("the server has no API key set and only answers clients on its machine")
+	}
+	return 0, nil
+}
+
+// handleTranscribe transcribes raw PCM audio from the request body
+func (s *Server) handleTranscribe(w http.ResponseWriter, r *http.Request) {
+	if status, err := s.authorize(r); err != nil {
+		logging.Warn("Rejected transcription request from %s: %v", r.RemoteAddr, err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSamples*4+1))
+	if err != nil {
+		http.Error(w, "failed to read audio", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxRequestSamples*4 || len(body)%4 != 0 {
+		http.Error(w, "invalid audio length", http.StatusBadRequest)
+		return
+	}
+
+	samples := make([]float32, len(body)/4)
+	if _, err := binary.Decode(body, binary.LittleEndian, samples); err != nil {
+		http.Error(w, "invalid audio", http.StatusBadRequest)
+		return
+	}
+
+	// A client that gives up also stops its transcription
+	segments, err := s.serialized(len(samples), func() ([]transcriber.Segment, error) {
+		return s.backend.Transcribe(r.Context(), samples)
+	})
+	if err != nil {
+		logging.Error("Transcription for %s failed: %v", r.RemoteAddr, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	logging.Info("Transcribed %d samples for %s: %d segments", len(samples), r.RemoteAddr, len(segments))
+	w.Header().Set("Content-Type", "application/json")
+	if segments == nil {
+		segments = []transcriber.Segment{}
+	}
+	json.NewEncoder(w).Encode(segments)
+}
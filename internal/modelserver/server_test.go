@@ -0,0 +1,56 @@
+package modelserver
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+func TestV1RequiresKeyFromOtherMachines(t *testing.T) {
+	// One second of silence, a valid request for either endpoint's checks
+	// to get past authorization
+	pcm := make([]byte, 16000*4)
+
+	tests := []struct {
+		name   string
+		key    string // set on the server
+		remote string
+		auth   string
+		want   int
+	}{
+		{"loopback without key", "", "127.0.0.1:5000", "", http.StatusOK},
+		{"LAN without key", "", "192.168.1.20:5000", "", http.StatusForbidden},
+		{"LAN with key missing", "secret", "192.168.1.20:5000", "", http.StatusUnauthorized},
+		{"LAN with wrong key", "secret", "192.168.1.20:5000", "Bearer nope", http.StatusUnauthorized},
+		{"LAN with key", "secret", "192.168.1.20:5000", "Bearer secret", http.StatusOK},
+	}
+	for _, path := range []string{transcriber.TranscribePath, OpenAIPath} {
+		for _, tt := range tests {
+			t.Run(path+" "+tt.name, func(t *testing.T) {
+				s := New(transcriber.NewFake("en"))
+				s.SetAPIKey(tt.key)
+
+				req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(pcm))
+				req.RemoteAddr = tt.remote
+				req.Header.Set("Content-Type", transcriber.PCMContentType)
+				if tt.auth != "" {
+					req.Header.Set("Authorization", tt.auth)
+				}
+				rec := httptest.NewRecorder()
+				s.handler().ServeHTTP(rec, req)
+
+				want := tt.want
+				if want == http.StatusOK && path == OpenAIPath {
+					// Authorized, but the body is no multipart upload
+					want = http.StatusBadRequest
+				}
+				if rec.Code != want {
+					t.Errorf("status = %d, want %d: %s", rec.Code, want, rec.Body)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Package profile reads named recording profiles, bundles of flag values
+// such as the model, language, devices and exports for one kind of meeting
+package profile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Profile is a named set of flag values
+type Profile struct {
+	Name string
+	// Description is shown in the profile picker
+	Description string
+	Settings    []Setting
+	// Schedules select the profile when no -profile is given
+	Schedules []Schedule
+}
+
+// Setting is the value of one flag, named without the leading dash
+type Setting struct {
+	Flag  string
+	Value string
+}
+
+// DefaultPath returns the default profiles file,
+// $XDG_CONFIG_HOME/rekord/profiles.conf or ~/.config/rekord/profiles.conf
+func DefaultPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = os.TempDir()
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "rekord", "profiles.conf")
+}
+
+// Load reads the profiles in the file at path, in order. A missing file has
+// no profiles. Each profile starts with its name in brackets and is followed
+// by "flag = value" lines; "description = ..." sets the text shown in the
+// picker, each "schedule = ..." adds a time the profile applies by itself
+// (see ParseSchedule) and a leading ~/ in values is expanded to the home
+// directory. Blank lines and lines starting with # are ignored.
+//
+//	[interviews]
+//	description = One-on-one interviews in German
+//	model = ~/models/ggml-medium.bin
+//	language = de
+//	output = ~/Interviews
+//	markdown = true
+func Load(path string) ([]Profile, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	home, _ := os.UserHomeDir()
+	var profiles []Profile
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if name, ok := strings.CutPrefix(line, "["); ok {
+			name, ok = strings.CutSuffix(name, "]")
+			name = strings.TrimSpace(name)
+			if !ok || name == "" {
+				return nil, fmt.Errorf("line %d: expected \"[name]\"", lineNum)
+			}
+			if Find(profiles, name) != nil {
+				return nil, fmt.Errorf("line %d: profile %q defined twice", lineNum, name)
+			}
+			profiles = append(profiles, Profile{Name: name})
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"flag = value\"", lineNum)
+		}
+		if len(profiles) == 0 {
+			return nil, fmt.Errorf("line %d: setting outside of a [profile]", lineNum)
+		}
+		key = strings.TrimLeft(strings.TrimSpace(key), "-")
+		value = strings.TrimSpace(value)
+		if rest, ok := strings.CutPrefix(value, "~/"); ok && home != "" {
+			value = filepath.Join(home, rest)
+		}
+
+		p := &profiles[len(profiles)-1]
+		switch key {
+		case "description":
+			p.Description = value
+		case "schedule":
+			sch, err := ParseSchedule(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			p.Schedules = append(p.Schedules, sch)
+		default:
+			p.Settings = append(p.Settings, Setting{Flag: key, Value: value})
+		}
+	}
+	return profiles, scanner.Err()
+}
+
+// Find returns the profile called name, or nil if there is none
+func Find(profiles []Profile, name string) *Profile {
+	for i := range profiles {
+		if profiles[i].Name == name {
+			return &profiles[i]
+		}
+	}
+	return nil
+}
+
+// Names returns the names of the profiles
+func Names(profiles []Profile) []string {
+	names := make([]string, len(profiles))
+	for i, p := range profiles {
+		names[i] = p.Name
+	}
+	return names
+}
@@ -0,0 +1,122 @@
+package profile
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a recurring time when a profile applies by itself, such as
+// weekdays from 9 to 18
+type Schedule struct {
+	days [7]bool
+	// start and end are minutes into the day; end before start spans
+	// midnight
+	start, end int
+}
+
+// dayNames are the days a schedule can name, indexed like time.Weekday
+var dayNames = [7]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseSchedule parses days followed by hours, either of which may be left
+// out. Days are daily, weekdays, weekends or a comma-separated list such as
+// mon,wed,fri; hours are a range such as 9-18 or 8:30-12:15.
+//
+//	weekdays 9-18
+//	sat,sun
+//	daily 22-6
+func ParseSchedule(s string) (Schedule, error) {
+	sch := Schedule{end: 24 * 60}
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return Schedule{}, fmt.Errorf("schedule %q: expected days and/or hours, e.g. \"weekdays 9-18\"", s)
+	}
+
+	days := fields[0]
+	if len(fields) == 1 && strings.ContainsAny(days, "0123456789") {
+		days = "daily"
+	} else {
+		fields = fields[1:]
+	}
+	switch days {
+	case "daily":
+		sch.days = [7]bool{true, true, true, true, true, true, true}
+	case "weekdays":
+		sch.days = [7]bool{false, true, true, true, true, true, false}
+	case "weekends":
+		sch.days = [7]bool{true, false, false, false, false, false, true}
+	default:
+		for name := range strings.SplitSeq(days, ",") {
+			i := slices.Index(dayNames[:], strings.ToLower(name))
+			if i < 0 {
+				return Schedule{}, fmt.Errorf("schedule %q: unknown day %q", s, name)
+			}
+			sch.days[i] = true
+		}
+	}
+
+	if len(fields) == 0 {
+		return sch, nil
+	}
+	from, to, ok := strings.Cut(fields[0], "-")
+	if !ok {
+		return Schedule{}, fmt.Errorf("schedule %q: expected hours like 9-18", s)
+	}
+	var err error
+	if sch.start, err = parseClock(from); err != nil {
+		return Schedule{}, fmt.Errorf("schedule %q: %w", s, err)
+	}
+	if sch.end, err = parseClock(to); err != nil {
+		return Schedule{}, fmt.Errorf("schedule %q: %w", s, err)
+	}
+	if sch.start == sch.end {
+		return Schedule{}, fmt.Errorf("schedule %q: hours start and end at the same time", s)
+	}
+	return sch, nil
+}
+
+// parseClock parses a time of day such as 9, 18:30 or 24 into minutes
+func parseClock(s string) (int, error) {
+	hours, minutes, _ := strings.Cut(s, ":")
+	h, err := strconv.Atoi(hours)
+	if err != nil || h < 0 || h > 24 {
+		return 0, fmt.Errorf("invalid hour %q", s)
+	}
+	m := 0
+	if minutes != "" {
+		m, err = strconv.Atoi(minutes)
+		if err != nil || m < 0 || m > 59 || h == 24 && m > 0 {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+	}
+	return h*60 + m, nil
+}
+
+// Matches reports whether t falls into the schedule. Hours spanning
+// midnight belong to the day they start on.
+func (sch Schedule) Matches(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if sch.start < sch.end {
+		return sch.days[day] && minute >= sch.start && minute < sch.end
+	}
+	if minute >= sch.start {
+		return sch.days[day]
+	}
+	return minute < sch.end && sch.days[(day+6)%7]
+}
+
+// Scheduled returns the first profile with a schedule matching t, or nil
+// if there is none
+func Scheduled(profiles []Profile, t time.Time) *Profile {
+	for i, p := range profiles {
+		for _, sch := range p.Schedules {
+			if sch.Matches(t) {
+				return &profiles[i]
+			}
+		}
+	}
+	return nil
+}
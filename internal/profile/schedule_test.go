@@ -0,0 +1,75 @@
+package profile
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleMatches(t *testing.T) {
+	// 2026-10-12 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, 12+day, hour, minute, 0, 0, time.Local)
+	}
+	tests := []struct {
+		schedule string
+		t        time.Time
+		want     bool
+	}{
+		{"weekdays 9-18", at(0, 9, 0), true},
+		{"weekdays 9-18", at(4, 17, 59), true},
+		{"weekdays 9-18", at(0, 18, 0), false},
+		{"weekdays 9-18", at(5, 10, 0), false},
+		{"weekends", at(6, 23, 0), true},
+		{"mon,wed 8:30-12", at(2, 8, 30), true},
+		{"mon,wed 8:30-12", at(1, 9, 0), false},
+		{"13-14", at(3, 13, 15), true},
+		// Overnight hours belong to the day they start on
+		{"fri 22-6", at(4, 23, 0), true},
+		{"fri 22-6", at(5, 5, 59), true},
+		{"fri 22-6", at(4, 5, 0), false},
+	}
+	for _, tt := range tests {
+		sch, err := ParseSchedule(tt.schedule)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q): %v", tt.schedule, err)
+		}
+		if got := sch.Matches(tt.t); got != tt.want {
+			t.Errorf("%q matches %s = %v, want %v", tt.schedule, tt.t.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, s := range []string{"", "someday", "weekdays 9", "weekdays 9-25", "9-9", "mon 9:75-10", "weekdays 9-18 extra"} {
+		if _, err := ParseSchedule(s); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded", s)
+		}
+	}
+}
+
+func TestScheduledPicksFirstMatch(t *testing.T) {
+	profiles := []Profile{
+		{Name: "meetings", Schedules: []Schedule{mustParse(t, "weekdays 9-18")}},
+		{Name: "dictation", Schedules: []Schedule{mustParse(t, "daily")}},
+		{Name: "manual"},
+	}
+	monday := time.Date(2026, 10, 12, 10, 0, 0, 0, time.Local)
+	if p := Scheduled(profiles, monday); p == nil || p.Name != "meetings" {
+		t.Errorf("Scheduled on Monday 10:00 = %v, want meetings", p)
+	}
+	if p := Scheduled(profiles, monday.Add(10*time.Hour)); p == nil || p.Name != "dictation" {
+		t.Errorf("Scheduled on Monday 20:00 = %v, want dictation", p)
+	}
+	if p := Scheduled(profiles[2:], monday); p != nil {
+		t.Errorf("Scheduled without schedules = %v, want none", p.Name)
+	}
+}
+
+func mustParse(t *testing.T, s string) Schedule {
+	t.Helper()
+	sch, err := ParseSchedule(s)
+	if err != nil {
+		t.Fatalf("ParseSchedule(%q): %v", s, err)
+	}
+	return sch
+}
@@ -0,0 +1,226 @@
+// Package search maintains a full-text index of saved transcripts in a
+// SQLite FTS5 database
+package search
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite" // registers the pure Go "sqlite" driver
+
+	"github.com/exler/rekord/internal/transcript"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS files (
+	path  TEXT PRIMARY KEY,
+	mtime INTEGER NOT NULL,
+	size  INTEGER NOT NULL
+);
+CREATE VIRTUAL TABLE IF NOT EXISTS lines USING fts5(path UNINDEXED, time UNINDEXED, text);
+`
+
+// Hit is a transcript line matching a search
+type Hit struct {
+	Path    string
+	Time    string
+	Snippet string
+}
+
+// Index is a full-text index over transcript files
+type Index struct {
+	db *sql.DB
+}
+
+// DefaultIndexPath returns the index location shared by all output
+// directories, ~/.cache/rekord/search.db
+func DefaultIndexPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "rekord", "search.db")
+}
+
+// Open opens or creates the index at path
+func Open(path string) (*Index, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create index directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize search index: %w", err)
+	}
+	return &Index{db: db}, nil
+}
+
+// Close closes the index
+func (x *Index) Close() error {
+	return x.db.Close()
+}
+
+// Update brings the index up to date with the .txt transcripts in dir,
+// indexing new and changed files and dropping removed ones. It returns the
+// number of files (re)indexed.
+func (x *Index) Update(dir string) (int, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := x.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Files indexed before, to find the ones that were removed
+	known := make(map[string]bool)
+	rows, err := tx.Query(`SELECT path FROM files WHERE path LIKE ? ESCAPE '\'`, likePrefix(dir))
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		known[path] = true
+	}
+	rows.Close()
+
+	updated := 0
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".txt" {
+			return nil
+		}
+		delete(known, path)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var mtime, size int64
+		err = tx.QueryRow(`SELECT mtime, size FROM files WHERE path = ?`, path).Scan(&mtime, &size)
+		if err == nil && mtime == info.ModTime().UnixNano() && size == info.Size() {
+			return nil
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if err := indexFile(tx, path, info); err != nil {
+			return fmt.Errorf("failed to index %s: %w", path, err)
+		}
+		updated++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for path := range known {
+		if err := removeFile(tx, path); err != nil {
+			return 0, err
+		}
+	}
+	return updated, tx.Commit()
+}
+
+// indexFile replaces the indexed lines of a transcript file
+func indexFile(tx *sql.Tx, path string, info fs.FileInfo) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	lines, err := transcript.ReadLines(f)
+	if err != nil {
+		return err
+	}
+
+	if err := removeFile(tx, path); err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if _, err := tx.Exec(`INSERT INTO lines (path, time, text) VALUES (?, ?, ?)`, path, line.Time, line.Text); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(`INSERT INTO files (path, mtime, size) VALUES (?, ?, ?)`, path, info.ModTime().UnixNano(), info.Size())
+	return err
+}
+
+// removeFile drops a file from the index
+func removeFile(tx *sql.Tx, path string) error {
+	if _, err := tx.Exec(`DELETE FROM lines WHERE path = ?`, path); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`DELETE FROM files WHERE path = ?`, path)
+	return err
+}
+
+// Search returns up to limit lines of transcripts in dir containing all
+// words of query, best matches first. Matched words are wrapped in
+// mark[0] and mark[1] in the snippets.
+func (x *Index) Search(dir, query string, limit int, mark [2]string) ([]Hit, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	match := matchExpr(query)
+	if match == "" {
+		return nil, errors.New("empty search query")
+	}
+
+	rows, err := x.db.Query(`
+		SELECT path, time, snippet(lines, 2, ?, ?, '…', 16)
+		FROM lines
+		WHERE lines MATCH ? AND path LIKE ? ESCAPE '\'
+		ORDER BY rank
+		LIMIT ?`, mark[0], mark[1], match, likePrefix(dir), limit)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []Hit
+	for rows.Next() {
+		var h Hit
+		if err := rows.Scan(&h.Path, &h.Time, &h.Snippet); err != nil {
+			return nil, err
+		}
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
+
+// matchExpr turns a plain query into an FTS5 expression requiring every
+// word, quoting them so punctuation is not read as query syntax
+func matchExpr(query string) string {
+	var terms []string
+	for _, w := range strings.Fields(query) {
+		terms = append(terms, `"`+strings.ReplaceAll(w, `"`, `""`)+`"`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// likePrefix returns a LIKE pattern matching paths inside dir
+func likePrefix(dir string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(dir + string(filepath.Separator))
+	return escaped + "%"
+}
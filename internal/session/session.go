@@ -0,0 +1,193 @@
+// Package session holds the state of the current recording session, shared
+// between the transcription pipeline, the UI and the control socket
+package session
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/exler/rekord/internal/transcriber"
+	"github.com/exler/rekord/internal/transcript"
+)
+
+// Metadata describes the recording session
+type Metadata struct {
+	// ID is the database ID of the current or last session with -store,
+	// 0 otherwise
+	ID int64
+	// Recording is set while audio is being captured
+	Recording bool
+	// Started is when the current or last recording started
+	Started time.Time
+	// Model is the path of the whisper model transcribing the session
+	Model string
+}
+
+// SessionStore owns the segments, markers and metadata of a session. All
+// methods are safe for concurrent use; the slices returned are copies.
+type SessionStore struct {
+	mu       sync.RWMutex
+	segments []transcriber.Segment
+	markers  []transcript.Annotation
+	meta     Metadata
+}
+
+// New returns an empty session store
+func New() *SessionStore {
+	return &SessionStore{}
+}
+
+// Segments returns the transcript so far
+func (s *SessionStore) Segments() []transcriber.Segment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.segments)
+}
+
+// Len returns the number of segments
+func (s *SessionStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.segments)
+}
+
+// Last returns the latest segment
+func (s *SessionStore) Last() (transcriber.Segment, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.segments) == 0 {
+		return transcriber.Segment{}, false
+	}
+	return s.segments[len(s.segments)-1], true
+}
+
+// LastFrom returns the latest segment of source
+func (s *SessionStore) LastFrom(source string) (transcriber.Segment, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := len(s.segments) - 1; i >= 0; i-- {
+		if s.segments[i].Source == source {
+			return s.segments[i], true
+		}
+	}
+	return transcriber.Segment{}, false
+}
+
+// AddSegment appends a finalized segment
+func (s *SessionStore) AddSegment(seg transcriber.Segment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.segments = append(s.segments, seg)
+}
+
+// InsertSegment adds a segment in timestamp order, e.g. one that was
+// dropped by post-processing and restored later
+func (s *SessionStore) InsertSegment(seg transcriber.Segment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, _ := slices.BinarySearchFunc(s.segments, seg, func(x, y transcriber.Segment) int {
+		return x.Timestamp.Compare(y.Timestamp)
+	})
+	s.segments = slices.Insert(s.segments, i, seg)
+}
+
+// SetText replaces the text of the segment of source starting at timestamp.
+// It returns false if there is no such segment.
+func (s *SessionStore) SetText(timestamp time.Time, source, text string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.segments {
+		if s.segments[i].Timestamp.Equal(timestamp) && s.segments[i].Source == source {
+			s.segments[i].SetText(text)
+			return true
+		}
+	}
+	return false
+}
+
+// RenameSource relabels the segments of source from as to and returns how
+// many there were
+func (s *SessionStore) RenameSource(from, to string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for i := range s.segments {
+		if s.segments[i].Source == from {
+			s.segments[i].Source = to
+			n++
+		}
+	}
+	return n
+}
+
+// Markers returns the bookmarks and imported annotations, sorted by offset
+func (s *SessionStore) Markers() []transcript.Annotation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.markers)
+}
+
+// SetMarkers replaces the markers, e.g. with annotations imported from
+// another tool. They must be sorted by offset.
+func (s *SessionStore) SetMarkers(markers []transcript.Annotation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.markers = slices.Clone(markers)
+}
+
+// AddMarker adds a marker in offset order
+func (s *SessionStore) AddMarker(mark transcript.Annotation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, _ := slices.BinarySearchFunc(s.markers, mark, func(x, y transcript.Annotation) int {
+		return cmp.Compare(x.Offset, y.Offset)
+	})
+	s.markers = slices.Insert(s.markers, i, mark)
+}
+
+// Metadata returns the session metadata
+func (s *SessionStore) Metadata() Metadata {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.meta
+}
+
+// Recording reports whether audio is being captured
+func (s *SessionStore) Recording() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.meta.Recording
+}
+
+// ID returns the database ID of the current or last session, 0 without one
+func (s *SessionStore) ID() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.meta.ID
+}
+
+// Start records that a recording with database ID id (0 without -store)
+// started at t
+func (s *SessionStore) Start(id int64, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.meta.ID = id
+	s.meta.Recording = true
+	s.meta.Started = t
+}
+
+// SetModel records the whisper model transcribing the session
+func (s *SessionStore) SetModel(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.meta.Model = path
+}
+
+// Stop records that the recording stopped
+func (s *SessionStore) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.meta.Recording = false
+}
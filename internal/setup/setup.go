@@ -0,0 +1,321 @@
+// Package setup installs the whisper.cpp executable and models rekord needs
+// to transcribe locally
+package setup
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+const (
+	// ModelBaseURL is where whisper.cpp models are downloaded from
+	ModelBaseURL = "https://huggingface.co/ggerganov/whisper.cpp/resolve/main/"
+
+	whisperRepo = "https://github.com/ggml-org/whisper.cpp"
+
+	// WhisperBaseURL is where prebuilt whisper-cli executables published
+	// with rekord releases are downloaded from, followed by the release tag
+	WhisperBaseURL = "https://github.com/exler/rekord/releases/download/"
+
+	// progressInterval limits how often download progress is reported
+	progressInterval = 250 * time.Millisecond
+)
+
+// Release builds set these with -ldflags "-X
+// github.com/exler/rekord/internal/setup.WhisperRelease=v1.2.0 -X
+// github.com/exler/rekord/internal/setup.WhisperSHA256=<hex>" to the release
+// whose prebuilt whisper-cli they download and its SHA-256. Other builds
+// build whisper.cpp from source instead.
+var (
+	WhisperRelease string
+	WhisperSHA256  string
+)
+
+// Progress receives short human-readable status updates of a setup task
+type Progress func(status string)
+
+// DownloadModel downloads the whisper.cpp model named after the base name of
+// path, e.g. ggml-base.en.bin, and saves it at path
+func DownloadModel(path string, progress Progress) error {
+	name := filepath.Base(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	resp, err := http.Get(ModelBaseURL + name)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading %s: %s", name, resp.Status)
+	}
+
+	// Download next to the destination so a partial file is never mistaken
+	// for a model
+	part := path + ".part"
+	f, err := os.Create(part)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(part)
+
+	// Hugging Face sends the SHA-256 of files stored with Git LFS, as all
+	// models are
+	want := strings.Trim(resp.Header.Get("X-Linked-Etag"), `"`)
+	if len(want) != sha256.Size*2 {
+		f.Close()
+		return fmt.Errorf("downloading %s: the server sent no SHA-256 to verify it with", name)
+	}
+
+	sum := sha256.New()
+	r := &progressReader{r: io.TeeReader(resp.Body, sum), total: resp.ContentLength, name: name, progress: progress}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("downloading %s: %w", name, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := verify(name, sum, want); err != nil {
+		return err
+	}
+	if err := transcriber.ValidateModel(part); err != nil {
+		return err
+	}
+	if err := os.Rename(part, path); err != nil {
+		return err
+	}
+	logging.Info("Downloaded model %s to %s", name, path)
+	return nil
+}
+
+// progressReader reports how much of a download has been read
+type progressReader struct {
+	r        io.Reader
+	total    int64
+	read     int64
+	name     string
+	progress Progress
+	reported time.Time
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if p.progress != nil && time.Since(p.reported) >= progressInterval {
+		p.reported = time.Now()
+		if p.total > 0 {
+			p.progress(fmt.Sprintf("downloading %s: %d%% (%d/%d MB)", p.name, p.read*100/p.total, p.read>>20, p.total>>20))
+		} else {
+			p.progress(fmt.Sprintf("downloading %s: %d MB", p.name, p.read>>20))
+		}
+	}
+	return n, err
+}
+
+// InstallWhisper installs whisper-cli into transcriber.ManagedBinDir, where
+// rekord finds it without WHISPER_PATH. It downloads a prebuilt executable
+// for this OS and architecture and builds whisper.cpp from source if there
+// is none. It returns the path of the installed executable.
+func InstallWhisper(progress Progress) (string, error) {
+	path, err := DownloadWhisper(progress)
+	if err == nil {
+		return path, nil
+	}
+	logging.Warn("No prebuilt whisper.cpp, building from source: %v", err)
+	path, buildErr := BuildWhisper(progress)
+	if buildErr != nil {
+		return "", fmt.Errorf("%w (and no prebuilt executable: %v)", buildErr, err)
+	}
+	return path, nil
+}
+
+// DownloadWhisper downloads the prebuilt whisper-cli for this OS and
+// architecture published with WhisperRelease into
+// transcriber.ManagedBinDir, verifying it against WhisperSHA256
+func DownloadWhisper(progress Progress) (string, error) {
+	name := fmt.Sprintf("whisper-cli-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if WhisperRelease == "" || WhisperSHA256 == "" {
+		return "", fmt.Errorf("this build has no checksum of a released %s", name)
+	}
+	resp, err := http.Get(WhisperBaseURL + WhisperRelease + "/" + name)
+	if err != nil {
+		return "", fmt.Errorf("downloading %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading %s: %s", name, resp.Status)
+	}
+
+	r := &progressReader{r: resp.Body, total: resp.ContentLength, name: name, progress: progress}
+	path, err := install(r, name, WhisperSHA256)
+	if err != nil {
+		return "", err
+	}
+
+	// Make sure the executable runs here before relying on it
+	if err := exec.Command(path, "--help").Run(); err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("downloaded %s does not run: %w", name, err)
+	}
+	return path, nil
+}
+
+// MissingBuildTools returns the tools needed to build whisper.cpp that are
+// not installed
+func MissingBuildTools() []string {
+	var missing []string
+	for _, tool := range []string{"git", "cmake"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			missing = append(missing, tool)
+		}
+	}
+	compiler := false
+	for _, cxx := range []string{"c++", "g++", "clang++"} {
+		if _, err := exec.LookPath(cxx); err == nil {
+			compiler = true
+			break
+		}
+	}
+	if !compiler {
+		missing = append(missing, "a C++ compiler")
+	}
+	return missing
+}
+
+// BuildWhisper clones and builds whisper.cpp in the cache directory and
+// installs whisper-cli into transcriber.ManagedBinDir. It returns the path
+// of the installed executable.
+func BuildWhisper(progress Progress) (string, error) {
+	if missing := MissingBuildTools(); len(missing) > 0 {
+		return "", fmt.Errorf("building whisper.cpp needs %s", strings.Join(missing, ", "))
+	}
+
+	src := filepath.Join(filepath.Dir(transcriber.ManagedBinDir()), "src")
+	build := filepath.Join(src, "build")
+
+	var steps [][]string
+	if _, err := os.Stat(filepath.Join(src, ".git")); err == nil {
+		steps = append(steps, []string{"git", "-C", src, "pull", "--ff-only"})
+	} else {
+		if err := os.MkdirAll(filepath.Dir(src), 0755); err != nil {
+			return "", err
+		}
+		steps = append(steps, []string{"git", "clone", "--depth", "1", whisperRepo, src})
+	}
+	steps = append(steps,
+		// A static build keeps the installed executable self-contained
+		[]string{"cmake", "-S", src, "-B", build, "-DCMAKE_BUILD_TYPE=Release", "-DBUILD_SHARED_LIBS=OFF"},
+		[]string{"cmake", "--build", build, "--config", "Release", "--target", "whisper-cli", "-j", strconv.Itoa(runtime.NumCPU())},
+	)
+	for _, step := range steps {
+		if err := runStep(step, progress); err != nil {
+			return "", err
+		}
+	}
+
+	built, err := os.Open(filepath.Join(build, "bin", "whisper-cli"))
+	if err != nil {
+		return "", err
+	}
+	defer built.Close()
+	return install(built, "whisper-cli", "")
+}
+
+// runStep runs one build command, reporting its output lines as progress
+// and copying them to the log
+func runStep(args []string, progress Progress) error {
+	logging.Info("Running %s", strings.Join(args, " "))
+	if progress != nil {
+		progress(strings.Join(args[:min(len(args), 2)], " "))
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+
+	var last string
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		last = scanner.Text()
+		logging.Debug("%s: %s", args[0], last)
+		if progress != nil {
+			progress(last)
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", strings.Join(args[:min(len(args), 2)], " "), err, last)
+	}
+	return nil
+}
+
+// install writes the whisper-cli executable read from in into the managed
+// bin directory. Unless want is empty, the executable must have that
+// SHA-256 and is not installed otherwise.
+func install(in io.Reader, name, want string) (string, error) {
+	dir := transcriber.ManagedBinDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	// Replace the executable atomically in case a running rekord uses it
+	path := filepath.Join(dir, "whisper-cli")
+	tmp, err := os.CreateTemp(dir, ".whisper-cli-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	sum := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, sum), in); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if want != "" {
+		if err := verify(name, sum, want); err != nil {
+			return "", err
+		}
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	logging.Info("Installed whisper.cpp to %s", path)
+	return path, nil
+}
+
+// verify checks that the SHA-256 sum of a download named name is want
+func verify(name string, sum hash.Hash, want string) error {
+	got := hex.EncodeToString(sum.Sum(nil))
+	if !strings.EqualFold(got, want) {
+		logging.Error("Checksum of %s is %s, expected %s", name, got, want)
+		return fmt.Errorf("checksum of %s does not match, the download was discarded", name)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package setup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallVerifiesChecksum(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	exe := "#!/bin/sh\n"
+	sum := sha256.Sum256([]byte(exe))
+
+	if _, err := install(strings.NewReader(exe), "whisper-cli-test", strings.Repeat("0", 64)); err == nil {
+		t.Fatal("install with a wrong checksum succeeded")
+	}
+	dir := filepath.Join(os.Getenv("XDG_CACHE_HOME"), "rekord", "whisper", "bin")
+	if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+		t.Errorf("files left after a checksum mismatch: %v", entries)
+	}
+
+	path, err := install(strings.NewReader(exe), "whisper-cli-test", hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("installed %s is not executable: %v", path, err)
+	}
+}
@@ -0,0 +1,148 @@
+// Package speakers remembers the real names of the speakers of recurring
+// meetings, so the source labels of their transcripts ("Left", "Mic", ...)
+// can be replaced with the same names every time
+package speakers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Names maps the source labels of a meeting series to speaker names
+type Names map[string]string
+
+// Name returns the name for label, or label itself if it has none
+func (n Names) Name(label string) string {
+	if name, ok := n[label]; ok {
+		return name
+	}
+	return label
+}
+
+// Rename names the speaker currently shown as from to, and returns the
+// labels whose name changed. The labels of from are those named from, or
+// from itself if no label is.
+func (n Names) Rename(from, to string) []string {
+	var labels []string
+	for label, name := range n {
+		if name == from {
+			labels = append(labels, label)
+		}
+	}
+	if len(labels) == 0 {
+		labels = []string{from}
+	}
+	for _, label := range labels {
+		if label == to {
+			delete(n, label)
+		} else {
+			n[label] = to
+		}
+	}
+	slices.Sort(labels)
+	return labels
+}
+
+// DefaultPath returns the default speaker names file,
+// $XDG_CONFIG_HOME/rekord/speakers.conf or ~/.config/rekord/speakers.conf
+func DefaultPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = os.TempDir()
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "rekord", "speakers.conf")
+}
+
+// Load reads the speaker names of each meeting series in the file at path.
+// A missing file has none. Each series starts with its name in brackets and
+// is followed by "label = name" lines. Blank lines and lines starting with #
+// are ignored.
+//
+//	[weekly-standup]
+//	Mic = Jane
+//	Left = Alice
+//	Right = Bob
+func Load(path string) (map[string]Names, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]Names{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	series := make(map[string]Names)
+	var current Names
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if name, ok := strings.CutPrefix(line, "["); ok {
+			name, ok = strings.CutSuffix(name, "]")
+			name = strings.TrimSpace(name)
+			if !ok || name == "" {
+				return nil, fmt.Errorf("line %d: expected \"[series]\"", lineNum)
+			}
+			if series[name] == nil {
+				series[name] = make(Names)
+			}
+			current = series[name]
+			continue
+		}
+
+		label, name, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"label = name\"", lineNum)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: speaker outside of a [series]", lineNum)
+		}
+		current[strings.TrimSpace(label)] = strings.TrimSpace(name)
+	}
+	return series, scanner.Err()
+}
+
+// Save writes the speaker names of each meeting series to the file at path,
+// replacing it
+func Save(path string, series map[string]Names) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("# Speaker names per meeting series, written by rekord\n")
+	for _, name := range slices.Sorted(maps.Keys(series)) {
+		names := series[name]
+		if len(names) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n[%s]\n", name)
+		for _, label := range slices.Sorted(maps.Keys(names)) {
+			fmt.Fprintf(&b, "%s = %s\n", label, names[label])
+		}
+	}
+
+	// Write a temporary file first so a failed write keeps the old names
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
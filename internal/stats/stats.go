@@ -0,0 +1,200 @@
+// Package stats records and analyzes per-chunk transcription pipeline timing
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/exler/rekord/internal/logging"
+)
+
+// chunkMarker prefixes chunk timing log lines so they can be found again
+const chunkMarker = "chunk-timing"
+
+// ChunkTiming describes how one audio chunk moved through the pipeline
+type ChunkTiming struct {
+	Source   string        // buffer label the chunk came from ("" for mixed audio)
+	Audio    time.Duration // length of captured audio in the chunk
+	Queue    time.Duration // time between cutting the chunk and starting whisper
+	Whisper  time.Duration // time whisper took to transcribe the chunk
+	Segments int           // number of segments produced
+	Failed   bool          // whether transcription failed
+}
+
+// Log writes a chunk timing as a structured log line
+func Log(t ChunkTiming) {
+	logging.GetLogger().Info(chunkMarker,
+		"source", t.Source,
+		"audio_ms", t.Audio.Milliseconds(),
+		"queue_ms", t.Queue.Milliseconds(),
+		"whisper_ms", t.Whisper.Milliseconds(),
+		"segments", t.Segments,
+		"failed", t.Failed)
+}
+
+// ParseLog extracts all chunk timings from a rekord log, written either as
+// text or as JSON
+func ParseLog(r io.Reader) ([]ChunkTiming, error) {
+	var timings []ChunkTiming
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "{") {
+			if t, ok := parseJSON(line); ok {
+				timings = append(timings, t)
+			}
+			continue
+		}
+		_, fields, ok := strings.Cut(line, chunkMarker+" ")
+		if !ok {
+			continue
+		}
+		if t, ok := parseFields(fields); ok {
+			timings = append(timings, t)
+		}
+	}
+	return timings, scanner.Err()
+}
+
+// parseJSON parses a chunk timing from a JSON log line
+func parseJSON(line string) (ChunkTiming, bool) {
+	var entry struct {
+		Msg       string `json:"msg"`
+		Source    string `json:"source"`
+		AudioMs   int64  `json:"audio_ms"`
+		QueueMs   int64  `json:"queue_ms"`
+		WhisperMs int64  `json:"whisper_ms"`
+		Segments  int    `json:"segments"`
+		Failed    bool   `json:"failed"`
+	}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.Msg != chunkMarker {
+		return ChunkTiming{}, false
+	}
+	return ChunkTiming{
+		Source:   entry.Source,
+		Audio:    time.Duration(entry.AudioMs) * time.Millisecond,
+		Queue:    time.Duration(entry.QueueMs) * time.Millisecond,
+		Whisper:  time.Duration(entry.WhisperMs) * time.Millisecond,
+		Segments: entry.Segments,
+		Failed:   entry.Failed,
+	}, true
+}
+
+// parseFields parses the key=value fields of a chunk timing line
+func parseFields(fields string) (ChunkTiming, bool) {
+	var t ChunkTiming
+	for _, field := range strings.Fields(fields) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return t, false
+		}
+
+		var err error
+		switch key {
+		case "source":
+			// Text logs only quote values that need it
+			t.Source = value
+			if strings.HasPrefix(value, `"`) {
+				t.Source, err = strconv.Unquote(value)
+			}
+		case "audio_ms":
+			t.Audio, err = parseMillis(value)
+		case "queue_ms":
+			t.Queue, err = parseMillis(value)
+		case "whisper_ms":
+			t.Whisper, err = parseMillis(value)
+		case "segments":
+			t.Segments, err = strconv.Atoi(value)
+		case "failed":
+			t.Failed, err = strconv.ParseBool(value)
+		}
+		if err != nil {
+			return t, false
+		}
+	}
+	return t, true
+}
+
+func parseMillis(s string) (time.Duration, error) {
+	ms, err := strconv.ParseInt(s, 10, 64)
+	return time.Duration(ms) * time.Millisecond, err
+}
+
+// Summary aggregates chunk timings
+type Summary struct {
+	Chunks      int
+	Failed      int
+	Segments    int
+	Audio       time.Duration
+	WhisperMean time.Duration
+	WhisperP95  time.Duration
+	WhisperMax  time.Duration
+	QueueMean   time.Duration
+	QueueMax    time.Duration
+	// RealTimeFactor is whisper time divided by audio time; above 1 the
+	// pipeline cannot keep up with live audio
+	RealTimeFactor float64
+}
+
+// Summarize aggregates a list of chunk timings
+func Summarize(timings []ChunkTiming) Summary {
+	var s Summary
+	if len(timings) == 0 {
+		return s
+	}
+
+	whisper := make([]time.Duration, 0, len(timings))
+	var whisperTotal, queueTotal time.Duration
+	for _, t := range timings {
+		s.Chunks++
+		s.Segments += t.Segments
+		s.Audio += t.Audio
+		if t.Failed {
+			s.Failed++
+		}
+
+		whisper = append(whisper, t.Whisper)
+		whisperTotal += t.Whisper
+		queueTotal += t.Queue
+		s.QueueMax = max(s.QueueMax, t.Queue)
+	}
+
+	slices.Sort(whisper)
+	s.WhisperMean = whisperTotal / time.Duration(len(timings))
+	s.WhisperP95 = whisper[(len(whisper)*95+99)/100-1]
+	s.WhisperMax = whisper[len(whisper)-1]
+	s.QueueMean = queueTotal / time.Duration(len(timings))
+	if s.Audio > 0 {
+		s.RealTimeFactor = float64(whisperTotal) / float64(s.Audio)
+	}
+	return s
+}
+
+// Write prints a human-readable report of the summary
+func (s Summary) Write(w io.Writer) {
+	if s.Chunks == 0 {
+		fmt.Fprintln(w, "No chunk timing found in log")
+		return
+	}
+
+	fmt.Fprintf(w, "Chunks:           %d (%d failed)\n", s.Chunks, s.Failed)
+	fmt.Fprintf(w, "Segments:         %d\n", s.Segments)
+	fmt.Fprintf(w, "Audio processed:  %s\n", s.Audio.Round(time.Second))
+	fmt.Fprintf(w, "Whisper mean:     %s\n", s.WhisperMean.Round(time.Millisecond))
+	fmt.Fprintf(w, "Whisper p95:      %s\n", s.WhisperP95.Round(time.Millisecond))
+	fmt.Fprintf(w, "Whisper max:      %s\n", s.WhisperMax.Round(time.Millisecond))
+	fmt.Fprintf(w, "Queue wait mean:  %s\n", s.QueueMean.Round(time.Millisecond))
+	fmt.Fprintf(w, "Queue wait max:   %s\n", s.QueueMax.Round(time.Millisecond))
+	fmt.Fprintf(w, "Real-time factor: %.2f\n", s.RealTimeFactor)
+	if s.RealTimeFactor > 1 {
+		fmt.Fprintln(w, "Warning: transcription is slower than real time; consider a smaller model or more threads")
+	}
+}
@@ -0,0 +1,223 @@
+// Package store keeps recording sessions, their segments and bookmarks in a
+// SQLite database for history queries
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the pure Go "sqlite" driver
+
+	"github.com/exler/rekord/internal/transcriber"
+	"github.com/exler/rekord/internal/transcript"
+)
+
+// schemaVersion is stored in PRAGMA user_version to allow migrations
+const schemaVersion = 1
+
+const schema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	id       INTEGER PRIMARY KEY,
+	started  INTEGER NOT NULL,
+	ended    INTEGER,
+	device   TEXT NOT NULL DEFAULT '',
+	model    TEXT NOT NULL DEFAULT '',
+	language TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS segments (
+	id         INTEGER PRIMARY KEY,
+	session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+	time       INTEGER NOT NULL,
+	start_ns   INTEGER NOT NULL,
+	end_ns     INTEGER NOT NULL,
+	source     TEXT NOT NULL DEFAULT '',
+	language   TEXT NOT NULL DEFAULT '',
+	confidence REAL NOT NULL DEFAULT 0,
+	text       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS segments_session ON segments(session_id, start_ns);
+CREATE TABLE IF NOT EXISTS bookmarks (
+	id         INTEGER PRIMARY KEY,
+	session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+	offset_ns  INTEGER NOT NULL,
+	label      TEXT NOT NULL
+);
+`
+
+// Session describes a recording session
+type Session struct {
+	ID       int64
+	Started  time.Time
+	Ended    time.Time // zero while recording or if rekord exited early
+	Device   string
+	Model    string
+	Language string
+	Segments int
+}
+
+// Store is a SQLite database of sessions
+type Store struct {
+	db *sql.DB
+}
+
+// DefaultPath returns the default database location,
+// $XDG_DATA_HOME/rekord/rekord.db or ~/.local/share/rekord/rekord.db
+func DefaultPath() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = os.TempDir()
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "rekord", "rekord.db")
+}
+
+// Open opens or creates the database at path
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to read database version: %w", err)
+	}
+	if version > schemaVersion {
+		db.Close()
+		return nil, fmt.Errorf("database %s was created by a newer rekord (schema %d)", path, version)
+	}
+	if _, err := db.Exec(schema + fmt.Sprintf("PRAGMA user_version = %d;", schemaVersion)); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// StartSession records the start of a recording and returns its ID
+func (s *Store) StartSession(sess Session) (int64, error) {
+	res, err := s.db.Exec(`INSERT INTO sessions (started, device, model, language) VALUES (?, ?, ?, ?)`,
+		sess.Started.UnixNano(), sess.Device, sess.Model, sess.Language)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store session: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// EndSession records when a session's recording stopped
+func (s *Store) EndSession(id int64, ended time.Time) error {
+	_, err := s.db.Exec(`UPDATE sessions SET ended = ? WHERE id = ?`, ended.UnixNano(), id)
+	return err
+}
+
+// AddSegment stores a finalized segment of a session
+func (s *Store) AddSegment(sessionID int64, seg transcriber.Segment) error {
+	_, err := s.db.Exec(`INSERT INTO segments (session_id, time, start_ns, end_ns, source, language, confidence, text) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		sessionID, seg.Timestamp.UnixNano(), int64(seg.StartTime), int64(seg.EndTime), seg.Source, seg.Language, seg.Confidence, seg.Text)
+	return err
+}
+
+// RenameSource relabels the segments of a session from source from as to
+func (s *Store) RenameSource(sessionID int64, from, to string) error {
+	_, err := s.db.Exec(`UPDATE segments SET source = ? WHERE session_id = ? AND source = ?`, to, sessionID, from)
+	return err
+}
+
+// AddBookmark stores a bookmark of a session
+func (s *Store) AddBookmark(sessionID int64, mark transcript.Annotation) error {
+	_, err := s.db.Exec(`INSERT INTO bookmarks (session_id, offset_ns, label) VALUES (?, ?, ?)`,
+		sessionID, int64(mark.Offset), mark.Label)
+	return err
+}
+
+// Sessions returns up to limit sessions, newest first
+func (s *Store) Sessions(limit int) ([]Session, error) {
+	rows, err := s.db.Query(`
+		SELECT s.id, s.started, s.ended, s.device, s.model, s.language,
+			(SELECT COUNT(*) FROM segments WHERE session_id = s.id)
+		FROM sessions s
+		ORDER BY s.started DESC
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var sess Session
+		var started int64
+		var ended sql.NullInt64
+		if err := rows.Scan(&sess.ID, &started, &ended, &sess.Device, &sess.Model, &sess.Language, &sess.Segments); err != nil {
+			return nil, err
+		}
+		sess.Started = time.Unix(0, started)
+		if ended.Valid {
+			sess.Ended = time.Unix(0, ended.Int64)
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions, rows.Err()
+}
+
+// Segments returns the segments of a session in the order they were spoken
+func (s *Store) Segments(sessionID int64) ([]transcriber.Segment, error) {
+	rows, err := s.db.Query(`
+		SELECT time, start_ns, end_ns, source, language, confidence, text
+		FROM segments
+		WHERE session_id = ?
+		ORDER BY start_ns, id`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segments []transcriber.Segment
+	for rows.Next() {
+		var seg transcriber.Segment
+		var ts, start, end int64
+		if err := rows.Scan(&ts, &start, &end, &seg.Source, &seg.Language, &seg.Confidence, &seg.Text); err != nil {
+			return nil, err
+		}
+		seg.Timestamp = time.Unix(0, ts)
+		seg.StartTime = time.Duration(start)
+		seg.EndTime = time.Duration(end)
+		segments = append(segments, seg)
+	}
+	return segments, rows.Err()
+}
+
+// Bookmarks returns the bookmarks of a session ordered by offset
+func (s *Store) Bookmarks(sessionID int64) ([]transcript.Annotation, error) {
+	rows, err := s.db.Query(`SELECT offset_ns, label FROM bookmarks WHERE session_id = ? ORDER BY offset_ns`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var marks []transcript.Annotation
+	for rows.Next() {
+		var offset int64
+		var mark transcript.Annotation
+		if err := rows.Scan(&offset, &mark.Label); err != nil {
+			return nil, err
+		}
+		mark.Offset = time.Duration(offset)
+		marks = append(marks, mark)
+	}
+	return marks, rows.Err()
+}
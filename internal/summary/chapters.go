@@ -0,0 +1,173 @@
+package summary
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MinChapterLines is a sensible minimum chapter length for transcripts
+	// cut into segments of a few seconds
+	MinChapterLines = 12
+
+	// chapterWindow is how many lines on each side of a gap are compared
+	chapterWindow = 6
+	// headingWords is how many keywords make up a chapter heading
+	headingWords = 3
+)
+
+// Chapter is a topical section of a transcript
+type Chapter struct {
+	// Start is the index of the chapter's first line
+	Start int
+	// Heading names the chapter by its most distinctive words
+	Heading string
+}
+
+// Chapters groups transcript lines, one utterance each, into topical
+// chapters of at least minLines lines. Boundaries are placed where the
+// vocabulary of the lines before and after changes the most (TextTiling).
+// Texts too short to split form a single chapter.
+func Chapters(lines []string, minLines int) []Chapter {
+	if len(lines) == 0 {
+		return nil
+	}
+	bags := make([]map[string]int, len(lines))
+	for i, line := range lines {
+		bags[i] = make(map[string]int)
+		for _, w := range words(line) {
+			bags[i][w]++
+		}
+	}
+
+	// Similarity of the windows around each gap; gap i lies before line i
+	sims := make([]float64, len(lines))
+	for i := 1; i < len(lines); i++ {
+		sims[i] = cosine(merge(bags[max(i-chapterWindow, 0):i]), merge(bags[i:min(i+chapterWindow, len(lines))]))
+	}
+
+	// Depth of each similarity valley relative to the peaks around it
+	depths := make([]float64, len(lines))
+	var sum, sumSquares float64
+	for i := 1; i < len(lines); i++ {
+		left, right := sims[i], sims[i]
+		for j := i - 1; j >= 1 && sims[j] >= left; j-- {
+			left = sims[j]
+		}
+		for j := i + 1; j < len(lines) && sims[j] >= right; j++ {
+			right = sims[j]
+		}
+		depths[i] = left - sims[i] + right - sims[i]
+		sum += depths[i]
+		sumSquares += depths[i] * depths[i]
+	}
+	gaps := float64(max(len(lines)-1, 1))
+	mean := sum / gaps
+	cutoff := mean + math.Sqrt(max(sumSquares/gaps-mean*mean, 0))/2
+
+	// Take the deepest valleys first as long as chapters stay long enough
+	candidates := make([]int, 0, len(lines))
+	for i := 1; i < len(lines); i++ {
+		if depths[i] > cutoff {
+			candidates = append(candidates, i)
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b int) int {
+		switch {
+		case depths[a] > depths[b]:
+			return -1
+		case depths[a] < depths[b]:
+			return 1
+		}
+		return 0
+	})
+	starts := []int{0}
+	for _, c := range candidates {
+		i, _ := slices.BinarySearch(starts, c)
+		if c-starts[i-1] < minLines || len(lines)-c < minLines || (i < len(starts) && starts[i]-c < minLines) {
+			continue
+		}
+		starts = slices.Insert(starts, i, c)
+	}
+
+	chapters := make([]Chapter, len(starts))
+	for i, start := range starts {
+		end := len(lines)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		chapters[i] = Chapter{Start: start, Heading: heading(bags[start:end], bags)}
+	}
+	return chapters
+}
+
+// heading names a chapter by the words that are frequent in it but rare in
+// the rest of the transcript
+func heading(chapter, all []map[string]int) string {
+	counts := merge(chapter)
+	docs := make(map[string]int)
+	for _, bag := range all {
+		for w := range bag {
+			docs[w]++
+		}
+	}
+
+	type scored struct {
+		word  string
+		score float64
+	}
+	var ranked []scored
+	for w, n := range counts {
+		idf := math.Log(float64(len(all)) / float64(docs[w]))
+		ranked = append(ranked, scored{w, float64(n) * (idf + 1)})
+	}
+	slices.SortFunc(ranked, func(a, b scored) int {
+		switch {
+		case a.score > b.score:
+			return -1
+		case a.score < b.score:
+			return 1
+		}
+		return strings.Compare(a.word, b.word)
+	})
+
+	var names []string
+	for _, s := range ranked[:min(headingWords, len(ranked))] {
+		r, size := utf8.DecodeRuneInString(s.word)
+		names = append(names, string(unicode.ToUpper(r))+s.word[size:])
+	}
+	if len(names) == 0 {
+		return "Untitled"
+	}
+	return strings.Join(names, ", ")
+}
+
+// merge sums word counts of several lines
+func merge(bags []map[string]int) map[string]int {
+	out := make(map[string]int)
+	for _, bag := range bags {
+		for w, n := range bag {
+			out[w] += n
+		}
+	}
+	return out
+}
+
+// cosine returns the cosine similarity of two word count vectors
+func cosine(a, b map[string]int) float64 {
+	var dot, na, nb float64
+	for w, n := range a {
+		dot += float64(n * b[w])
+		na += float64(n * n)
+	}
+	for _, n := range b {
+		nb += float64(n * n)
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
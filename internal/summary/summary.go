@@ -0,0 +1,169 @@
+// Package summary provides transcript summarization
+package summary
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// WordsPerMinute is the average silent reading speed used for estimates
+const WordsPerMinute = 200
+
+// Summarizer produces a short list of key points from transcript text
+type Summarizer interface {
+	Summarize(text string, maxPoints int) ([]string, error)
+}
+
+// ReadingTime estimates how long it takes to read text, rounded up to whole
+// minutes
+func ReadingTime(text string) time.Duration {
+	words := len(strings.Fields(text))
+	if words == 0 {
+		return 0
+	}
+	minutes := math.Ceil(float64(words) / WordsPerMinute)
+	return time.Duration(minutes) * time.Minute
+}
+
+// Minutes returns a reading time in whole minutes, rounded up so that a
+// short text does not read as taking no time
+func Minutes(d time.Duration) int {
+	return max(1, int((d+time.Minute-1)/time.Minute))
+}
+
+// Extractive is a local Summarizer that picks the most representative
+// sentences of the text by word frequency, without any external service
+type Extractive struct{}
+
+// Summarize returns up to maxPoints sentences from text in their original
+// order
+func (Extractive) Summarize(text string, maxPoints int) ([]string, error) {
+	sentences := splitSentences(text)
+	if len(sentences) <= maxPoints {
+		return sentences, nil
+	}
+
+	// Word frequencies across the whole text, ignoring stop words
+	freq := make(map[string]int)
+	for _, s := range sentences {
+		for _, w := range words(s) {
+			freq[w]++
+		}
+	}
+
+	type scored struct {
+		index int
+		score float64
+	}
+	scores := make([]scored, len(sentences))
+	for i, s := range sentences {
+		ws := words(s)
+		var total float64
+		for _, w := range ws {
+			total += float64(freq[w])
+		}
+		if len(ws) > 0 {
+			// Normalize so long sentences do not always win
+			total /= math.Sqrt(float64(len(ws)))
+		}
+		scores[i] = scored{index: i, score: total}
+	}
+
+	slices.SortStableFunc(scores, func(a, b scored) int {
+		switch {
+		case a.score > b.score:
+			return -1
+		case a.score < b.score:
+			return 1
+		}
+		return 0
+	})
+
+	picked := scores[:maxPoints]
+	slices.SortFunc(picked, func(a, b scored) int { return a.index - b.index })
+
+	points := make([]string, len(picked))
+	for i, p := range picked {
+		points[i] = sentences[p.index]
+	}
+	return points, nil
+}
+
+// splitSentences splits text into trimmed sentences
+func splitSentences(text string) []string {
+	var sentences []string
+	var b strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(b.String()); len(strings.Fields(s)) >= 3 {
+			sentences = append(sentences, s)
+		}
+		b.Reset()
+	}
+
+	for _, r := range text {
+		if r == '\n' {
+			flush()
+			continue
+		}
+		b.WriteRune(r)
+		if r == '.' || r == '!' || r == '?' {
+			flush()
+		}
+	}
+	flush()
+	return sentences
+}
+
+// words returns the lowercased content words of a sentence
+func words(sentence string) []string {
+	var out []string
+	for w := range strings.FieldsFuncSeq(strings.ToLower(sentence), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	}) {
+		if len(w) > 2 && !stopWords[w] {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "but": true, "not": true,
+	"you": true, "all": true, "any": true, "can": true, "had": true, "her": true,
+	"was": true, "one": true, "our": true, "out": true, "has": true, "have": true,
+	"him": true, "his": true, "how": true, "its": true, "let": true, "that": true,
+	"this": true, "with": true, "they": true, "them": true, "then": true, "there": true,
+	"what": true, "when": true, "which": true, "will": true, "would": true, "could": true,
+	"should": true, "from": true, "into": true, "just": true, "like": true, "yeah": true,
+	"okay": true, "about": true, "been": true, "were": true, "we're": true, "it's": true,
+	"i'm": true, "that's": true, "don't": true, "also": true, "some": true, "know": true,
+	"think": true, "really": true, "going": true, "right": true, "well": true, "very": true,
+}
+
+// actionCues are phrases that usually introduce a commitment or task
+var actionCues = []string{
+	"action item", "todo", "to do", "to-do", "follow up", "follow-up",
+	"i will", "i'll", "we will", "we'll", "you will", "you'll",
+	"need to", "needs to", "have to", "has to", "going to",
+	"let's", "should", "make sure", "deadline", "by monday", "by tuesday",
+	"by wednesday", "by thursday", "by friday", "by tomorrow", "next week",
+}
+
+// ActionItems returns the sentences of text that look like tasks or
+// commitments, in their original order
+func ActionItems(text string) []string {
+	var items []string
+	for _, s := range splitSentences(text) {
+		lower := strings.ToLower(s)
+		for _, cue := range actionCues {
+			if strings.Contains(lower, cue) {
+				items = append(items, s)
+				break
+			}
+		}
+	}
+	return items
+}
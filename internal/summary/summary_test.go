@@ -0,0 +1,24 @@
+package summary
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMinutes(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want int
+	}{
+		{0, 1},
+		{10 * time.Second, 1},
+		{time.Minute, 1},
+		{time.Minute + time.Second, 2},
+		{5 * time.Minute, 5},
+	}
+	for _, tt := range tests {
+		if got := Minutes(tt.d); got != tt.want {
+			t.Errorf("Minutes(%s) = %d, want %d", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package transcriber
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// accelerationLibs maps ggml backend library names to display names
+var accelerationLibs = []struct {
+	lib  string
+	name string
+}{
+	{"ggml-cuda", "CUDA"},
+	{"ggml-metal", "Metal"},
+	{"ggml-vulkan", "Vulkan"},
+	{"ggml-hip", "ROCm"},
+	{"ggml-sycl", "SYCL"},
+	{"ggml-opencl", "OpenCL"},
+	{"cublas", "CUDA"},
+}
+
+// DetectAcceleration guesses which GPU backends the whisper executable at
+// path can use, from the libraries it links and the ggml backends installed
+// next to it. An empty result means CPU only.
+func DetectAcceleration(path string) []string {
+	var haystack strings.Builder
+
+	// Statically linked backends show up in the dynamic dependencies
+	if runtime.GOOS == "darwin" {
+		if out, err := exec.Command("otool", "-L", path).Output(); err == nil {
+			haystack.Write(out)
+		}
+	} else if out, err := exec.Command("ldd", path).Output(); err == nil {
+		haystack.Write(out)
+	}
+
+	// Newer builds load backends at runtime from the binary's directory or
+	// the lib directory next to it
+	dir := filepath.Dir(path)
+	for _, libDir := range []string{dir, filepath.Join(dir, "..", "lib")} {
+		entries, err := os.ReadDir(libDir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			haystack.WriteString(e.Name())
+			haystack.WriteByte('\n')
+		}
+	}
+
+	found := haystack.String()
+	var backends []string
+	for _, a := range accelerationLibs {
+		if strings.Contains(found, a.lib) && !slices.Contains(backends, a.name) {
+			backends = append(backends, a.name)
+		}
+	}
+	return backends
+}
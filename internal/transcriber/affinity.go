@@ -0,0 +1,59 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ParseCPUList parses a Linux-style CPU list such as "0-3,8,10-11"
+func ParseCPUList(list string) ([]int, error) {
+	var cpus []int
+	for part := range strings.SplitSeq(strings.TrimSpace(list), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(lo)
+		if err != nil || first < 0 {
+			return nil, fmt.Errorf("invalid CPU %q in list %q", lo, list)
+		}
+		last := first
+		if isRange {
+			last, err = strconv.Atoi(hi)
+			if err != nil || last < first {
+				return nil, fmt.Errorf("invalid CPU range %q in list %q", part, list)
+			}
+		}
+
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// DefaultWhisperCPUs returns the CPUs whisper should be pinned to by default.
+// On hybrid CPUs that expose their efficiency cores (Intel "cpu_atom"), whisper
+// is kept on those so it does not compete with a video call for the
+// performance cores. Elsewhere it returns nil, meaning no pinning.
+func DefaultWhisperCPUs() []int {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+
+	data, err := os.ReadFile("/sys/devices/cpu_atom/cpus")
+	if err != nil {
+		return nil
+	}
+
+	cpus, err := ParseCPUList(string(data))
+	if err != nil || len(cpus) < 2 {
+		return nil
+	}
+	return cpus
+}
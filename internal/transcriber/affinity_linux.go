@@ -0,0 +1,31 @@
+//go:build linux
+
+package transcriber
+
+import (
+	"os/exec"
+	"runtime"
+
+	"github.com/exler/rekord/internal/logging"
+	"golang.org/x/sys/unix"
+)
+
+// startPinned starts cmd pinned to the given CPUs from its first
+// instruction. A child inherits the affinity of the thread that forks it, so
+// cmd is started from a thread of its own that is pinned first. That thread
+// stays locked and is discarded with its affinity once the start is done.
+func startPinned(cmd *exec.Cmd, cpus []int) error {
+	started := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		var set unix.CPUSet
+		for _, cpu := range cpus {
+			set.Set(cpu)
+		}
+		if err := unix.SchedSetaffinity(0, &set); err != nil {
+			logging.Warn("Failed to set whisper CPU affinity: %v", err)
+		}
+		started <- cmd.Start()
+	}()
+	return <-started
+}
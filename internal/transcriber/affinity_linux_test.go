@@ -0,0 +1,25 @@
+//go:build linux
+
+package transcriber
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestStartPinned(t *testing.T) {
+	cmd := exec.Command("grep", "Cpus_allowed_list", "/proc/self/status")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := startPinned(cmd, []int{0}); err != nil {
+		t.Fatalf("startPinned: %v", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); !strings.HasSuffix(got, "\t0") {
+		t.Errorf("%s, want the process pinned to CPU 0 from the start", got)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package transcriber
+
+import (
+	"os/exec"
+
+	"github.com/exler/rekord/internal/logging"
+)
+
+// startPinned starts cmd without pinning it, which is only supported on Linux
+func startPinned(cmd *exec.Cmd, cpus []int) error {
+	logging.Warn("Failed to set whisper CPU affinity: only supported on Linux")
+	return cmd.Start()
+}
@@ -0,0 +1,55 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// MinChunkTimeout is the least time a backend is given for one chunk
+	MinChunkTimeout = 30 * time.Second
+
+	// chunkTimeoutFactor scales the timeout with the chunk's audio length,
+	// leaving room for slow CPUs and large models
+	chunkTimeoutFactor = 10
+)
+
+// Backend turns chunks of 16kHz mono audio into transcript segments.
+// Transcribe must stop and return the context's error once ctx is done.
+type Backend interface {
+	Transcribe(ctx context.Context, samples []float32) ([]Segment, error)
+	Close() error
+}
+
+// ChunkTimeout returns how long transcribing a chunk of the given number of
+// samples may take before it is considered hung
+func ChunkTimeout(samples int) time.Duration {
+	return max(MinChunkTimeout, chunkTimeoutFactor*time.Duration(samples)*time.Second/16000)
+}
+
+// ChunkTimeouter is implemented by backends that need longer than
+// ChunkTimeout for a chunk, e.g. because it waits its turn on a shared
+// server
+type ChunkTimeouter interface {
+	ChunkTimeout(samples int) time.Duration
+}
+
+// TimeoutFor returns how long b may take to transcribe a chunk of the given
+// number of samples
+func TimeoutFor(b Backend, samples int) time.Duration {
+	if t, ok := b.(ChunkTimeouter); ok {
+		return t.ChunkTimeout(samples)
+	}
+	return ChunkTimeout(samples)
+}
+
+// WarmUp transcribes one second of silence so the model is loaded before the
+// first real chunk, and returns how long it took
+func WarmUp(ctx context.Context, b Backend) (time.Duration, error) {
+	start := time.Now()
+	if _, err := b.Transcribe(ctx, make([]float32, 16000)); err != nil {
+		return 0, fmt.Errorf("warm-up failed: %w", err)
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,283 @@
+package transcriber
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	openAIURL   = "https://api.openai.com/v1/audio/transcriptions"
+	deepgramURL = "https://api.deepgram.com/v1/listen"
+
+	// OpenAIKeyEnv and DeepgramKeyEnv name the environment variables holding
+	// the cloud API keys
+	OpenAIKeyEnv   = "OPENAI_API_KEY"
+	DeepgramKeyEnv = "DEEPGRAM_API_KEY"
+
+	cloudTimeout = 60 * time.Second
+)
+
+// CloudOptions configures a cloud transcription backend
+type CloudOptions struct {
+	// APIKey overrides the key read from the environment
+	APIKey string
+	// Model is the provider's model name (empty for the provider default)
+	Model string
+	// Language is the spoken language code (default "en")
+	Language string
+	// Prompt is the initial prompt, used by providers that support one
+	Prompt string
+}
+
+// OpenAIClient is a Backend using the OpenAI audio transcription API
+type OpenAIClient struct {
+	opts   CloudOptions
+	client *http.Client
+	mu     sync.Mutex
+}
+
+// NewOpenAIClient creates an OpenAI backend, taking the API key from
+// OPENAI_API_KEY unless one is given
+func NewOpenAIClient(opts CloudOptions) (*OpenAIClient, error) {
+	if opts.APIKey == "" {
+		opts.APIKey = os.Getenv(OpenAIKeyEnv)
+	}
+	if opts.APIKey == "" {
+		return nil, fmt.Errorf("no OpenAI API key, set %s", OpenAIKeyEnv)
+	}
+	if opts.Model == "" {
+		opts.Model = "whisper-1"
+	}
+	return &OpenAIClient{opts: opts, client: &http.Client{Timeout: cloudTimeout}}, nil
+}
+
+// Transcribe implements Backend by uploading the chunk as a WAV file
+func (c *OpenAIClient) Transcribe(ctx context.Context, samples []float32) ([]Segment, error) {
+	body := new(bytes.Buffer)
+	form := multipart.NewWriter(body)
+	form.WriteField("model", c.opts.Model)
+	form.WriteField("language", cloudLanguage(c.opts.Language))
+	form.WriteField("response_format", "verbose_json")
+	form.WriteField("timestamp_granularities[]", "segment")
+	form.WriteField("timestamp_granularities[]", "word")
+	if prompt := c.prompt(); prompt != "" {
+		form.WriteField("prompt", prompt)
+	}
+	part, err := form.CreateFormFile("file", "chunk.wav")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeWAV(part, samples, 16000); err != nil {
+		return nil, fmt.Errorf("failed to encode audio: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, openAIURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.opts.APIKey)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	var result struct {
+		Segments []struct {
+			Start      float64 `json:"start"`
+			End        float64 `json:"end"`
+			Text       string  `json:"text"`
+			AvgLogprob float64 `json:"avg_logprob"`
+		} `json:"segments"`
+		Words []struct {
+			Word  string  `json:"word"`
+			Start float64 `json:"start"`
+			End   float64 `json:"end"`
+		} `json:"words"`
+	}
+	if err := doCloudRequest(c.client, req, "OpenAI", &result); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var segments []Segment
+	for _, s := range result.Segments {
+		text := strings.TrimSpace(s.Text)
+		if text == "" {
+			continue
+		}
+		// Words come as one list for the whole chunk; assign them to the
+		// segment they start in
+		var words []Word
+		for _, w := range result.Words {
+			if w.Start >= s.Start && w.Start < s.End {
+				words = append(words, Word{Text: strings.TrimSpace(w.Word), Start: seconds(w.Start), End: seconds(w.End)})
+			}
+		}
+		segments = append(segments, Segment{
+			Text:       text,
+			StartTime:  seconds(s.Start),
+			EndTime:    seconds(s.End),
+			Timestamp:  now,
+			Language:   cloudLanguage(c.opts.Language),
+			Confidence: math.Exp(s.AvgLogprob),
+			Words:      words,
+		})
+	}
+	return segments, nil
+}
+
+// SetPrompt implements Prompter
+func (c *OpenAIClient) SetPrompt(prompt string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.opts.Prompt = prompt
+}
+
+// prompt returns the current initial prompt
+func (c *OpenAIClient) prompt() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.opts.Prompt
+}
+
+// Close is a no-op for the OpenAI client
+func (c *OpenAIClient) Close() error {
+	return nil
+}
+
+// DeepgramClient is a Backend using the Deepgram pre-recorded audio API
+type DeepgramClient struct {
+	opts   CloudOptions
+	client *http.Client
+}
+
+// NewDeepgramClient creates a Deepgram backend, taking the API key from
+// DEEPGRAM_API_KEY unless one is given
+func NewDeepgramClient(opts CloudOptions) (*DeepgramClient, error) {
+	if opts.APIKey == "" {
+		opts.APIKey = os.Getenv(DeepgramKeyEnv)
+	}
+	if opts.APIKey == "" {
+		return nil, fmt.Errorf("no Deepgram API key, set %s", DeepgramKeyEnv)
+	}
+	if opts.Model == "" {
+		opts.Model = "nova-2"
+	}
+	return &DeepgramClient{opts: opts, client: &http.Client{Timeout: cloudTimeout}}, nil
+}
+
+// Transcribe implements Backend by posting the chunk as a WAV file and
+// returning Deepgram's utterances as segments
+func (c *DeepgramClient) Transcribe(ctx context.Context, samples []float32) ([]Segment, error) {
+	body := new(bytes.Buffer)
+	if err := writeWAV(body, samples, 16000); err != nil {
+		return nil, fmt.Errorf("failed to encode audio: %w", err)
+	}
+
+	query := url.Values{
+		"model":      {c.opts.Model},
+		"language":   {cloudLanguage(c.opts.Language)},
+		"punctuate":  {"true"},
+		"utterances": {"true"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, deepgramURL+"?"+query.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Token "+c.opts.APIKey)
+	req.Header.Set("Content-Type", "audio/wav")
+
+	var result struct {
+		Results struct {
+			Utterances []struct {
+				Start      float64 `json:"start"`
+				End        float64 `json:"end"`
+				Transcript string  `json:"transcript"`
+				Confidence float64 `json:"confidence"`
+				Words      []struct {
+					Word           string  `json:"word"`
+					PunctuatedWord string  `json:"punctuated_word"`
+					Start          float64 `json:"start"`
+					End            float64 `json:"end"`
+				} `json:"words"`
+			} `json:"utterances"`
+		} `json:"results"`
+	}
+	if err := doCloudRequest(c.client, req, "Deepgram", &result); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var segments []Segment
+	for _, u := range result.Results.Utterances {
+		text := strings.TrimSpace(u.Transcript)
+		if text == "" {
+			continue
+		}
+		words := make([]Word, 0, len(u.Words))
+		for _, w := range u.Words {
+			word := w.PunctuatedWord
+			if word == "" {
+				word = w.Word
+			}
+			words = append(words, Word{Text: word, Start: seconds(w.Start), End: seconds(w.End)})
+		}
+		segments = append(segments, Segment{
+			Text:       text,
+			StartTime:  seconds(u.Start),
+			EndTime:    seconds(u.End),
+			Timestamp:  now,
+			Language:   cloudLanguage(c.opts.Language),
+			Confidence: u.Confidence,
+			Words:      words,
+		})
+	}
+	return segments, nil
+}
+
+// Close is a no-op for the Deepgram client
+func (c *DeepgramClient) Close() error {
+	return nil
+}
+
+// doCloudRequest sends req and decodes a JSON response into v
+func doCloudRequest(client *http.Client, req *http.Request, provider string, v any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s error: %s: %s", provider, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid %s response: %w", provider, err)
+	}
+	return nil
+}
+
+// cloudLanguage returns the language code to send, defaulting to English
+func cloudLanguage(lang string) string {
+	if lang == "" {
+		return "en"
+	}
+	return lang
+}
+
+// seconds converts fractional seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,76 @@
+package transcriber
+
+import (
+	"context"
+	"time"
+)
+
+// FakePhrases are the phrases a Fake backend returns by default
+var FakePhrases = []string{
+	"Good morning, everyone.",
+	"Let's go through the agenda.",
+	"The release is planned for Friday.",
+	"Does anyone have questions?",
+}
+
+// fakeSilence is the peak level below which a piece of audio counts as
+// silent and produces no segment
+const fakeSilence = 1e-4
+
+// Fake is a Backend for integration tests and demos that transcribes without
+// a model. Each chunk is cut into pieces of Length, and every piece that is
+// not silent becomes one segment with the next of Phrases, starting over for
+// each chunk. The result depends only on the audio, so the same recording
+// always yields the same transcript regardless of timing or -workers.
+type Fake struct {
+	Phrases  []string
+	Length   time.Duration
+	Language string
+}
+
+// NewFake creates a Fake backend returning FakePhrases for each 2 seconds
+// of sound
+func NewFake(language string) *Fake {
+	return &Fake{Phrases: FakePhrases, Length: 2 * time.Second, Language: language}
+}
+
+// Transcribe implements Backend
+func (f *Fake) Transcribe(ctx context.Context, samples []float32) ([]Segment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	piece := max(1, int(f.Length*16000/time.Second))
+	var segments []Segment
+	for i, start := 0, 0; start < len(samples); i, start = i+1, start+piece {
+		end := min(start+piece, len(samples))
+		if !fakeSound(samples[start:end]) || len(f.Phrases) == 0 {
+			continue
+		}
+		segments = append(segments, Segment{
+			Text:       f.Phrases[i%len(f.Phrases)],
+			StartTime:  time.Duration(start) * time.Second / 16000,
+			EndTime:    time.Duration(end) * time.Second / 16000,
+			Timestamp:  now,
+			Language:   f.Language,
+			Confidence: 1,
+		})
+	}
+	return segments, nil
+}
+
+// Close implements Backend
+func (f *Fake) Close() error {
+	return nil
+}
+
+// fakeSound reports whether samples contain anything above silence
+func fakeSound(samples []float32) bool {
+	for _, v := range samples {
+		if v > fakeSilence || v < -fakeSilence {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package transcriber
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ModelSwitcher is implemented by backends that can change the whisper
+// model between chunks
+type ModelSwitcher interface {
+	// Model returns the path of the model in use
+	Model() string
+	// SetModel switches to the model at path for the next chunks
+	SetModel(path string) error
+}
+
+// modelSizes lists the whisper model sizes from smallest to largest
+var modelSizes = []string{"tiny", "base", "small", "medium", "large"}
+
+// modelSize returns the index of a model file's size in modelSizes, or -1
+// if the name does not follow the ggml-<size>[-variant][.en].bin pattern
+func modelSize(path string) int {
+	name := strings.TrimPrefix(filepath.Base(path), "ggml-")
+	size, _, _ := strings.Cut(strings.TrimSuffix(name, ".bin"), "-")
+	size, _, _ = strings.Cut(size, ".")
+	return slices.Index(modelSizes, size)
+}
+
+// SmallerModel returns the largest valid model next to the model at path
+// that is of a smaller size, e.g. medium for large, preferring one for the
+// same languages (English-only or multilingual). It returns false if there
+// is none.
+func SmallerModel(path string) (string, bool) {
+	current := modelSize(path)
+	if current <= 0 {
+		return "", false
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return "", false
+	}
+
+	english := strings.HasSuffix(path, ".en.bin")
+	best, bestScore := "", -1
+	for _, e := range entries {
+		candidate := filepath.Join(filepath.Dir(path), e.Name())
+		size := modelSize(candidate)
+		if size < 0 || size >= current || ValidateModel(candidate) != nil {
+			continue
+		}
+		// A model for the same languages comes first, then the biggest
+		score := size
+		if strings.HasSuffix(candidate, ".en.bin") == english {
+			score += len(modelSizes)
+		}
+		if score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	return best, best != ""
+}
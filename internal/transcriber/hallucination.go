@@ -0,0 +1,151 @@
+package transcriber
+
+import (
+	"bufio"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultMinSegmentDBFS is the RMS level below which a segment's audio is
+// considered silence, so any text whisper produced for it is discarded
+const DefaultMinSegmentDBFS = -50.0
+
+// defaultHallucinations lists phrases whisper is known to invent on silent
+// or near-silent audio, keyed by language
+var defaultHallucinations = map[string][]string{
+	"en": {
+		"thank you for watching",
+		"thanks for watching",
+		"thank you for watching and see you next time",
+		"please subscribe",
+		"please like and subscribe",
+		"subscribe to my channel",
+		"see you in the next video",
+		"subtitles by the amara org community",
+	},
+	"de": {
+		"untertitel im auftrag des zdf",
+		"untertitel der amara org community",
+		"vielen dank fürs zuschauen",
+	},
+	"fr": {
+		"sous titres réalisés para la communauté d amara org",
+		"merci d avoir regardé",
+	},
+	"es": {
+		"subtítulos realizados por la comunidad de amara org",
+		"gracias por ver",
+	},
+}
+
+// HallucinationFilter drops segments whisper likely made up: those whose
+// audio is near silent and those matching known hallucinated phrases
+type HallucinationFilter struct {
+	MinDBFS  float64
+	Language string
+	phrases  map[string]map[string]bool
+}
+
+// NewHallucinationFilter creates a filter for the given language with the
+// built-in phrase list
+func NewHallucinationFilter(language string) *HallucinationFilter {
+	f := &HallucinationFilter{
+		MinDBFS:  DefaultMinSegmentDBFS,
+		Language: language,
+		phrases:  make(map[string]map[string]bool),
+	}
+	for lang, phrases := range defaultHallucinations {
+		for _, p := range phrases {
+			f.AddPhrase(lang, p)
+		}
+	}
+	return f
+}
+
+// AddPhrase adds a phrase to drop for the given language ("" for all)
+func (f *HallucinationFilter) AddPhrase(language, phrase string) {
+	if f.phrases[language] == nil {
+		f.phrases[language] = make(map[string]bool)
+	}
+	f.phrases[language][normalizePhrase(phrase)] = true
+}
+
+// LoadPhrases adds phrases from a file with one phrase per line. A line may
+// start with a language tag such as "[de]"; untagged phrases apply to all
+// languages. Empty lines and lines starting with # are ignored.
+func (f *HallucinationFilter) LoadPhrases(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		lang := ""
+		if strings.HasPrefix(line, "[") {
+			if tag, rest, ok := strings.Cut(line[1:], "]"); ok {
+				lang, line = strings.TrimSpace(tag), rest
+			}
+		}
+		f.AddPhrase(lang, line)
+	}
+	return scanner.Err()
+}
+
+// Reason returns why a segment transcribed from samples (the whole chunk it
+// came from) should be dropped, or "" if it should be kept
+func (f *HallucinationFilter) Reason(seg Segment, samples []float32, sampleRate int) string {
+	text := normalizePhrase(seg.Text)
+	if f.phrases[f.Language][text] || f.phrases[""][text] {
+		return "known hallucination"
+	}
+
+	if level := segmentDBFS(seg, samples, sampleRate); level < f.MinDBFS {
+		return "silent audio"
+	}
+	return ""
+}
+
+// segmentDBFS returns the RMS level of the audio under a segment, using the
+// whole chunk when the segment has no timing
+func segmentDBFS(seg Segment, samples []float32, sampleRate int) float64 {
+	span := samples
+	if seg.EndTime > seg.StartTime {
+		start := min(int(seg.StartTime*time.Duration(sampleRate)/time.Second), len(samples))
+		end := min(int(seg.EndTime*time.Duration(sampleRate)/time.Second), len(samples))
+		span = samples[start:end]
+	}
+	if len(span) == 0 {
+		return math.Inf(-1)
+	}
+
+	var sum float64
+	for _, s := range span {
+		sum += float64(s) * float64(s)
+	}
+	rms := math.Sqrt(sum / float64(len(span)))
+	if rms == 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(rms)
+}
+
+// normalizePhrase lowercases text, replaces punctuation with spaces and
+// collapses whitespace
+func normalizePhrase(s string) string {
+	words := strings.Fields(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(".,!?;:'\"()[]-–—…♪*", r) {
+			return ' '
+		}
+		return r
+	}, strings.ToLower(s)))
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,60 @@
+package transcriber
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxOverlapWords bounds how far back overlap is searched; the context kept
+// between chunks is only a couple of seconds of speech
+const maxOverlapWords = 12
+
+// TrimOverlap removes the leading words of next that repeat the trailing
+// words of prev, as happens when consecutive chunks share overlapping audio.
+// Words are aligned ignoring case and punctuation. It returns next unchanged
+// if there is no overlap, and an empty string if next is fully repeated.
+func TrimOverlap(prev, next string) string {
+	prevWords := strings.Fields(prev)
+	nextWords := strings.Fields(next)
+	if len(prevWords) == 0 || len(nextWords) == 0 {
+		return next
+	}
+
+	prevTail := prevWords[max(len(prevWords)-maxOverlapWords, 0):]
+
+	// Find the longest suffix of prev that is a prefix of next
+	best := 0
+	for n := min(len(prevTail), len(nextWords)); n > 0; n-- {
+		if wordsEqual(prevTail[len(prevTail)-n:], nextWords[:n]) {
+			best = n
+			break
+		}
+	}
+
+	// A single short repeated word is more likely coincidence than overlap
+	if best == 0 || (best == 1 && len(normalizeWord(nextWords[0])) < 4) {
+		return next
+	}
+
+	return strings.Join(nextWords[best:], " ")
+}
+
+// wordsEqual compares two word slices ignoring case and punctuation
+func wordsEqual(a, b []string) bool {
+	for i := range a {
+		if normalizeWord(a[i]) != normalizeWord(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeWord lowercases a word and strips punctuation
+func normalizeWord(w string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, w)
+}
@@ -0,0 +1,49 @@
+package transcriber
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// Prompter is implemented by backends that accept an initial prompt to bias
+// whisper towards the spelling of domain terms
+type Prompter interface {
+	SetPrompt(prompt string)
+}
+
+// LoadVocabulary reads domain terms (product names, attendee names, jargon)
+// from a file, one per line. Blank lines and lines starting with # are
+// ignored.
+func LoadVocabulary(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var terms []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	return terms, scanner.Err()
+}
+
+// BuildPrompt combines a free-form prompt with a vocabulary list into the
+// initial prompt passed to whisper
+func BuildPrompt(prompt string, terms []string) string {
+	prompt = strings.TrimSpace(prompt)
+	if len(terms) == 0 {
+		return prompt
+	}
+	vocabulary := strings.Join(terms, ", ") + "."
+	if prompt == "" {
+		return vocabulary
+	}
+	return prompt + " " + vocabulary
+}
@@ -0,0 +1,254 @@
+package transcriber
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/exler/rekord/internal/logging"
+)
+
+// lastSeq is the sequence number of the last chunk a worker started, for
+// queues without a sequence of their own
+var lastSeq atomic.Uint64
+
+// MaxQueuedAudio bounds how much audio per source may wait for the backend.
+// When transcription falls further behind, the oldest audio is dropped.
+const MaxQueuedAudio = 60 * time.Second
+
+// Chunk is a piece of audio waiting to be transcribed
+type Chunk struct {
+	// Source is the segment source label the audio belongs to
+	Source string
+	// Samples is 16kHz mono audio
+	Samples []float32
+	// Overlap is the number of leading samples repeated from the previous
+	// chunk of the same source, kept as context for whisper
+	Overlap int
+	// Offset is where the chunk's first sample lies in the recorded audio
+	// of its source
+	Offset time.Duration
+	// Queued is when the chunk was cut from the live audio
+	Queued time.Time
+	// Seq numbers chunks in the order workers start them, from 1 and
+	// without gaps across all queues sharing a sequence, so results can be
+	// put back in order
+	Seq uint64
+}
+
+// Result is the outcome of transcribing a chunk
+type Result struct {
+	Chunk    Chunk
+	Segments []Segment
+	Err      error
+	// Wait is how long the chunk waited in the queue, Took how long the
+	// backend needed for it
+	Wait time.Duration
+	Took time.Duration
+}
+
+// Queue feeds chunks to a backend in order, using up to a fixed number of
+// workers at once. Chunks arriving while an earlier chunk of the same source
+// is still waiting are merged into it, so a slow backend gets fewer, longer
+// chunks instead of a growing backlog. Each chunk gets the backend's
+// TimeoutFor to finish.
+type Queue struct {
+	ctx     context.Context
+	backend Backend
+	handle  func(Result)
+
+	mu      sync.Mutex
+	wake    *sync.Cond
+	onStart func(Chunk)
+	seq     *atomic.Uint64
+	dropped *atomic.Int64
+	pending []Chunk
+	closed  bool
+
+	handleMu sync.Mutex
+	workers  sync.WaitGroup
+}
+
+// NewQueue starts workers transcribing queued chunks with backend in
+// parallel, calling handle exactly once for each chunk started. handle is
+// called for one result at a time, in order with a single worker; with more,
+// a chunk that finishes early is handled before earlier ones, and Chunk.Seq
+// gives the original order. Once ctx is done, the chunks being transcribed
+// are abandoned and waiting chunks are dropped.
+func NewQueue(ctx context.Context, backend Backend, workers int, handle func(Result)) *Queue {
+	q := &Queue{
+		ctx:     ctx,
+		backend: backend,
+		handle:  handle,
+		seq:     &lastSeq,
+	}
+	q.wake = sync.NewCond(&q.mu)
+	for range max(workers, 1) {
+		q.workers.Add(1)
+		go q.run()
+	}
+	return q
+}
+
+// SetStartHandler sets a function called when the backend starts
+// transcribing a chunk
+func (q *Queue) SetStartHandler(fn func(Chunk)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.onStart = fn
+}
+
+// SetSequence numbers the chunks with seq instead of the sequence shared by
+// all queues, for queues whose results go to a session of their own
+func (q *Queue) SetSequence(seq *atomic.Uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq = seq
+}
+
+// SetDropCounter makes the queue add the samples it drops from a growing
+// backlog to n
+func (q *Queue) SetDropCounter(n *atomic.Int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.dropped = n
+}
+
+// Push queues a chunk for transcription
+func (q *Queue) Push(c Chunk) {
+	if c.Queued.IsZero() {
+		c.Queued = time.Now()
+	}
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	if i := q.pendingIndex(c.Source); i >= 0 {
+		var dropped int
+		q.pending[i], dropped = coalesce(q.pending[i], c)
+		if q.dropped != nil {
+			q.dropped.Add(int64(dropped))
+		}
+	} else {
+		q.pending = append(q.pending, c)
+	}
+	q.wake.Signal()
+	q.mu.Unlock()
+}
+
+// Waiting reports whether a chunk of source is queued and not yet being
+// transcribed
+func (q *Queue) Waiting(source string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pendingIndex(source) >= 0
+}
+
+// Len returns how many chunks are waiting for a worker
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Lag returns how long the oldest waiting chunk has been queued
+func (q *Queue) Lag() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return 0
+	}
+	return time.Since(q.pending[0].Queued)
+}
+
+// Close stops accepting chunks and waits for the queued ones to finish
+func (q *Queue) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.wake.Broadcast()
+	q.mu.Unlock()
+	q.workers.Wait()
+}
+
+// pendingIndex returns the index of the waiting chunk for source, or -1.
+// Must be called with q.mu held.
+func (q *Queue) pendingIndex(source string) int {
+	for i, c := range q.pending {
+		if c.Source == source {
+			return i
+		}
+	}
+	return -1
+}
+
+// run is a worker transcribing chunks until the queue is closed and drained
+func (q *Queue) run() {
+	defer q.workers.Done()
+
+	for {
+		q.mu.Lock()
+		for len(q.pending) == 0 && !q.closed {
+			q.wake.Wait()
+		}
+		if len(q.pending) == 0 {
+			q.mu.Unlock()
+			return
+		}
+		if q.ctx.Err() != nil {
+			logging.Warn("Transcription cancelled, dropping %d queued chunk(s)", len(q.pending))
+			q.pending = nil
+			q.mu.Unlock()
+			continue
+		}
+		c := q.pending[0]
+		q.pending = q.pending[1:]
+		c.Seq = q.seq.Add(1)
+		onStart := q.onStart
+		q.mu.Unlock()
+
+		if onStart != nil {
+			onStart(c)
+		}
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(q.ctx, TimeoutFor(q.backend, len(c.Samples)))
+		segments, err := q.backend.Transcribe(ctx, c.Samples)
+		cancel()
+		r := Result{
+			Chunk:    c,
+			Segments: segments,
+			Err:      err,
+			Wait:     start.Sub(c.Queued),
+			Took:     time.Since(start),
+		}
+
+		q.handleMu.Lock()
+		q.handle(r)
+		q.handleMu.Unlock()
+	}
+}
+
+// coalesce appends next to the waiting chunk prev of the same source,
+// skipping the context next repeats and trimming the oldest audio beyond
+// MaxQueuedAudio. It returns the merged chunk and how many samples were
+// trimmed.
+func coalesce(prev, next Chunk) (Chunk, int) {
+	overlap := min(next.Overlap, len(next.Samples))
+	merged := append(prev.Samples, next.Samples[overlap:]...)
+
+	limit := int(MaxQueuedAudio / time.Second * 16000)
+	var dropped int
+	if len(merged) > limit {
+		dropped = len(merged) - limit
+		logging.Warn("Transcription backlog for %q exceeds %s, dropping %.1fs of audio", prev.Source, MaxQueuedAudio, float64(dropped)/16000)
+		merged = merged[dropped:]
+		prev.Overlap = 0
+		prev.Offset += time.Duration(dropped) * time.Second / 16000
+	}
+
+	logging.Debug("Coalesced queued chunk for %q: %d samples", prev.Source, len(merged))
+	prev.Samples = merged
+	return prev, dropped
+}
@@ -0,0 +1,80 @@
+package transcriber
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`)
+
+	// Card numbers are 13-19 digits, optionally grouped with spaces or dashes
+	cardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+	// Phone numbers need at least 7 digits; see redactPhone
+	phonePattern = regexp.MustCompile(`(?:\+|\b)\d[\d ().-]{5,}\d\b`)
+
+	profanityPattern = regexp.MustCompile(`(?i)\b(?:(?:mother)?fuck\w*|shit\w*|bullshit|bitch\w*|bastards?|assholes?|cunts?|dickheads?|piss(?:ed)?)\b`)
+)
+
+// Redact masks emails, phone numbers, credit-card-like numbers and
+// profanity in text. It returns the redacted text and how many items were
+// masked.
+func Redact(text string) (string, int) {
+	count := 0
+
+	text = emailPattern.ReplaceAllStringFunc(text, func(string) string {
+		count++
+		return "[EMAIL]"
+	})
+	text = cardPattern.ReplaceAllStringFunc(text, func(m string) string {
+		if !luhnValid(digitsOf(m)) {
+			return m
+		}
+		count++
+		return "[CARD]"
+	})
+	text = phonePattern.ReplaceAllStringFunc(text, func(m string) string {
+		if len(digitsOf(m)) < 7 {
+			return m
+		}
+		count++
+		return "[PHONE]"
+	})
+	text = profanityPattern.ReplaceAllStringFunc(text, func(m string) string {
+		count++
+		return m[:1] + strings.Repeat("*", len(m)-1)
+	})
+
+	return text, count
+}
+
+// digitsOf returns only the digits of s
+func digitsOf(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// luhnValid reports whether digits pass the Luhn checksum used by payment
+// cards, so ordinary long numbers are not mistaken for them
+func luhnValid(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return len(digits) > 0 && sum%10 == 0
+}
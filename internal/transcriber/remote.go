@@ -0,0 +1,163 @@
+package transcriber
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grandcat/zeroconf"
+)
+
+const (
+	// ServiceType is the mDNS service type rekord model servers advertise
+	ServiceType = "_rekord._tcp"
+
+	// TranscribePath is the model server endpoint accepting raw float32
+	// little-endian 16kHz mono PCM and returning JSON segments
+	TranscribePath = "/v1/transcribe"
+
+	// PCMContentType is the content type of audio sent to a model server
+	PCMContentType = "audio/x-rekord-f32le"
+
+	// DefaultServerWait is how long a chunk may wait by default for a model
+	// server busy with the requests of other clients, which it transcribes
+	// one at a time
+	DefaultServerWait = time.Minute
+)
+
+// RemoteClient is a Backend that sends audio to a rekord model server
+type RemoteClient struct {
+	url    string
+	client *http.Client
+	apiKey string
+	wait   time.Duration
+}
+
+// NewRemoteClient creates a client for the model server at addr (host:port
+// or an http(s) URL)
+func NewRemoteClient(addr string) *RemoteClient {
+	url := addr
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		url = "http://" + url
+	}
+	return &RemoteClient{
+		url:    strings.TrimSuffix(url, "/") + TranscribePath,
+		client: &http.Client{},
+		wait:   DefaultServerWait,
+	}
+}
+
+// SetWait sets how long a chunk may wait for the server to finish the
+// requests of other clients before its own transcription starts
+func (c *RemoteClient) SetWait(wait time.Duration) {
+	c.wait = wait
+}
+
+// ChunkTimeout implements ChunkTimeouter. A chunk gets the time to be
+// transcribed plus the time it may wait for the server.
+func (c *RemoteClient) ChunkTimeout(samples int) time.Duration {
+	return ChunkTimeout(samples) + c.wait
+}
+
+// SetAPIKey sets the key sent to the server, which servers on other machines
+// require
+func (c *RemoteClient) SetAPIKey(key string) {
+	c.apiKey = key
+}
+
+// Transcribe implements Backend by posting the samples to the server
+func (c *RemoteClient) Transcribe(ctx context.Context, samples []float32) ([]Segment, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.ChunkTimeout(len(samples)))
+		defer cancel()
+	}
+
+	body := new(bytes.Buffer)
+	if err := binary.Write(body, binary.LittleEndian, samples); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", PCMContentType)
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("model server request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("model server error: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var segments []Segment
+	if err := json.NewDecoder(resp.Body).Decode(&segments); err != nil {
+		return nil, fmt.Errorf("invalid model server response: %w", err)
+	}
+
+	// Timestamps are local to the client
+	now := time.Now()
+	for i := range segments {
+		segments[i].Timestamp = now
+	}
+	return segments, nil
+}
+
+// Close is a no-op for the remote client
+func (c *RemoteClient) Close() error {
+	return nil
+}
+
+// DiscoverServer looks for a rekord model server on the local network via
+// mDNS and returns its host:port
+func DiscoverServer(timeout time.Duration) (string, error) {
+	resolver, err := zeroconf.NewResolver(nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to start mDNS resolver: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	entries := make(chan *zeroconf.ServiceEntry)
+	if err := resolver.Browse(ctx, ServiceType, "local.", entries); err != nil {
+		return "", fmt.Errorf("mDNS browse failed: %w", err)
+	}
+
+	for {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				return "", errors.New("no rekord model server found on the network")
+			}
+			var ip net.IP
+			switch {
+			case len(entry.AddrIPv4) > 0:
+				ip = entry.AddrIPv4[0]
+			case len(entry.AddrIPv6) > 0:
+				ip = entry.AddrIPv6[0]
+			default:
+				continue
+			}
+			return net.JoinHostPort(ip.String(), strconv.Itoa(entry.Port)), nil
+		case <-ctx.Done():
+			return "", errors.New("no rekord model server found on the network")
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package transcriber
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Replacement rewrites text matching a pattern, fixing a systematic
+// mis-transcription such as a company name
+type Replacement struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// Replacements is an ordered correction dictionary applied to segment text
+type Replacements []Replacement
+
+// LoadReplacements reads a correction dictionary with one
+// "pattern => replacement" rule per line. Patterns are Go regular
+// expressions (prefix with (?i) to ignore case) and replacements may use $1
+// style group references. Blank lines and lines starting with # are ignored.
+func LoadReplacements(path string) (Replacements, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules Replacements
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pattern, replacement, ok := strings.Cut(line, "=>")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"pattern => replacement\"", lineNum)
+		}
+		re, err := regexp.Compile(strings.TrimSpace(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		rules = append(rules, Replacement{Pattern: re, Replacement: strings.TrimSpace(replacement)})
+	}
+	return rules, scanner.Err()
+}
+
+// Apply runs every rule over text in order
+func (r Replacements) Apply(text string) string {
+	for _, rule := range r {
+		text = rule.Pattern.ReplaceAllString(text, rule.Replacement)
+	}
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,149 @@
+package transcriber
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// TimeMode selects which time of a segment is shown
+type TimeMode string
+
+const (
+	// WallClock shows the time of day a segment was transcribed
+	WallClock TimeMode = "wall"
+	// Elapsed shows where a segment starts in the recorded audio
+	Elapsed TimeMode = "elapsed"
+)
+
+// Default layouts of wall-clock times and dates
+const (
+	DefaultTimeLayout = "15:04:05"
+	DefaultDateLayout = "2006-01-02"
+)
+
+// TimeFormat selects how segment times are shown in the UI and exports
+type TimeFormat struct {
+	Mode TimeMode
+	// Layout and DateLayout are the time.Format layouts of wall-clock times
+	// and dates, the defaults if empty
+	Layout     string
+	DateLayout string
+	// WithDate prefixes wall-clock segment times with the date
+	WithDate bool
+	// Location is the time zone of wall-clock times, local time if nil
+	Location *time.Location
+}
+
+// ParseTimeMode parses a -timestamps flag value
+func ParseTimeMode(s string) (TimeMode, error) {
+	switch m := TimeMode(s); m {
+	case WallClock, Elapsed:
+		return m, nil
+	case "":
+		return WallClock, nil
+	}
+	return "", fmt.Errorf("unknown timestamp format %q (want wall or elapsed)", s)
+}
+
+// ParseTimeLayout parses a -time-format flag value into time.Format layouts
+// of times and dates: 24h, 12h, locale (customary for the LC_TIME locale) or
+// a custom Go layout such as "3:04 PM", which keeps ISO dates
+func ParseTimeLayout(s string) (layout, date string, err error) {
+	switch s {
+	case "", "24h":
+		return DefaultTimeLayout, DefaultDateLayout, nil
+	case "12h":
+		return "3:04:05 PM", DefaultDateLayout, nil
+	case "locale":
+		layout, date = localeLayouts()
+		return layout, date, nil
+	}
+	// A layout without any reference time element prints itself
+	if time.Date(2001, 2, 3, 16, 5, 6, 0, time.UTC).Format(s) == s {
+		return "", "", fmt.Errorf("invalid time format %q (want 24h, 12h, locale or a Go time layout like 15:04:05)", s)
+	}
+	return s, DefaultDateLayout, nil
+}
+
+// ParseLocation parses a -timezone flag value: an IANA zone name such as
+// Europe/Berlin, UTC, or Local
+func ParseLocation(s string) (*time.Location, error) {
+	if s == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q (want an IANA name like Europe/Berlin, UTC or Local)", s)
+	}
+	return loc, nil
+}
+
+// Format returns the time of seg in the format
+func (f TimeFormat) Format(seg Segment) string {
+	if f.Mode != Elapsed {
+		if f.WithDate {
+			return f.Stamp(seg.Timestamp)
+		}
+		return f.In(seg.Timestamp).Format(cmp.Or(f.Layout, DefaultTimeLayout))
+	}
+	d := max(seg.StartTime, 0)
+	return fmt.Sprintf("%02d:%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute), int(d%time.Minute/time.Second))
+}
+
+// Date returns the date of t in the format
+func (f TimeFormat) Date(t time.Time) string {
+	return f.In(t).Format(cmp.Or(f.DateLayout, DefaultDateLayout))
+}
+
+// Stamp returns the date and time of t in the format, e.g. for titles
+func (f TimeFormat) Stamp(t time.Time) string {
+	return f.In(t).Format(cmp.Or(f.DateLayout, DefaultDateLayout) + " " + cmp.Or(f.Layout, DefaultTimeLayout))
+}
+
+// In returns t in the time zone of the format
+func (f TimeFormat) In(t time.Time) time.Time {
+	if f.Location == nil {
+		return t
+	}
+	return t.In(f.Location)
+}
+
+// localeLayouts returns the time and date layouts customary for the
+// locale in LC_ALL, LC_TIME or LANG, e.g. 12-hour times and month-first
+// dates for en_US. Unknown locales get 24-hour times and ISO dates.
+func localeLayouts() (layout, date string) {
+	locale := ""
+	for _, env := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if locale = os.Getenv(env); locale != "" {
+			break
+		}
+	}
+	// e.g. de_DE.UTF-8 or en_US@euro
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	lang, country, _ := strings.Cut(locale, "_")
+
+	layout, date = DefaultTimeLayout, DefaultDateLayout
+	switch country {
+	case "US", "CA", "AU", "NZ", "PH", "IN":
+		if lang == "en" {
+			layout = "3:04:05 PM"
+		}
+	}
+	switch {
+	case lang == "en" && (country == "US" || country == "PH"):
+		date = "01/02/2006"
+	case lang == "en" && country != "CA", lang == "fr", lang == "es", lang == "it", lang == "pt", lang == "el":
+		date = "02/01/2006"
+	case lang == "de", lang == "ru", lang == "pl", lang == "cs", lang == "sk", lang == "fi", lang == "nb", lang == "no", lang == "da", lang == "tr", lang == "uk":
+		date = "02.01.2006"
+	case lang == "nl":
+		date = "02-01-2006"
+	case lang == "ja", lang == "zh":
+		date = "2006/01/02"
+	}
+	return layout, date
+}
@@ -0,0 +1,136 @@
+//go:build cgo && whisper_cgo
+
+package transcriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+// WhisperCgoAvailable reports whether this build includes the cgo backend
+const WhisperCgoAvailable = true
+
+// WhisperCgo is an in-process Backend using the whisper.cpp Go bindings. The
+// model stays loaded for the whole session, so there is no process spawn or
+// WAV file per chunk.
+type WhisperCgo struct {
+	mu    sync.Mutex
+	model whisper.Model
+	ctx   whisper.Context
+}
+
+// NewWhisperCgo loads the model at modelPath into memory
+func NewWhisperCgo(modelPath string, opts WhisperOptions) (*WhisperCgo, error) {
+	model, err := whisper.New(modelPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model: %w", err)
+	}
+
+	ctx, err := model.NewContext()
+	if err != nil {
+		model.Close()
+		return nil, fmt.Errorf("failed to create whisper context: %w", err)
+	}
+
+	language := opts.Language
+	if language == "" {
+		language = "en"
+	}
+	if err := ctx.SetLanguage(language); err != nil {
+		model.Close()
+		return nil, fmt.Errorf("failed to set language: %w", err)
+	}
+	if opts.Threads > 0 {
+		ctx.SetThreads(uint(opts.Threads))
+	}
+	if opts.BeamSize > 0 {
+		ctx.SetBeamSize(opts.BeamSize)
+	}
+	if opts.Prompt != "" {
+		ctx.SetInitialPrompt(opts.Prompt)
+	}
+
+	return &WhisperCgo{model: model, ctx: ctx}, nil
+}
+
+// Transcribe implements Backend. Cancelling ctx aborts whisper before its
+// next encoder pass.
+func (w *WhisperCgo) Transcribe(ctx context.Context, samples []float32) ([]Segment, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	proceed := func() bool { return ctx.Err() == nil }
+	if err := w.ctx.Process(samples, proceed, nil, nil); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("whisper aborted: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("whisper failed: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("whisper aborted: %w", err)
+	}
+
+	var segments []Segment
+	now := time.Now()
+	for {
+		seg, err := w.ctx.NextSegment()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("whisper failed: %w", err)
+		}
+
+		text := strings.TrimSpace(seg.Text)
+		if text == "" || text == "[BLANK_AUDIO]" {
+			continue
+		}
+
+		var sum float64
+		var n int
+		tokens := make([]wordToken, 0, len(seg.Tokens))
+		for _, tok := range seg.Tokens {
+			if w.ctx.IsText(tok) {
+				sum += float64(tok.P)
+				n++
+				tokens = append(tokens, wordToken{Text: tok.Text, Start: tok.Start, End: tok.End})
+			}
+		}
+		var confidence float64
+		if n > 0 {
+			confidence = sum / float64(n)
+		}
+
+		segments = append(segments, Segment{
+			Text:       text,
+			StartTime:  seg.Start,
+			EndTime:    seg.End,
+			Timestamp:  now,
+			Language:   w.ctx.DetectedLanguage(),
+			Confidence: confidence,
+			Words:      wordsFromTokens(tokens),
+		})
+	}
+	return segments, nil
+}
+
+// SetPrompt implements Prompter
+func (w *WhisperCgo) SetPrompt(prompt string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ctx.SetInitialPrompt(prompt)
+}
+
+// Close releases the model
+func (w *WhisperCgo) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.model.Close()
+}
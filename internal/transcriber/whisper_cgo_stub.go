@@ -0,0 +1,29 @@
+//go:build !(cgo && whisper_cgo)
+
+package transcriber
+
+import (
+	"context"
+	"errors"
+)
+
+// WhisperCgoAvailable reports whether this build includes the cgo backend
+const WhisperCgoAvailable = false
+
+// WhisperCgo is unavailable in builds without the whisper_cgo tag
+type WhisperCgo struct{}
+
+// NewWhisperCgo always fails in builds without the whisper_cgo tag
+func NewWhisperCgo(modelPath string, opts WhisperOptions) (*WhisperCgo, error) {
+	return nil, errors.New("rekord was built without the cgo backend; rebuild with CGO_ENABLED=1 -tags whisper_cgo against libwhisper")
+}
+
+// Transcribe implements Backend
+func (w *WhisperCgo) Transcribe(ctx context.Context, samples []float32) ([]Segment, error) {
+	return nil, errors.New("cgo backend unavailable")
+}
+
+// Close implements Backend
+func (w *WhisperCgo) Close() error {
+	return nil
+}
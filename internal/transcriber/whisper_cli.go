@@ -2,44 +2,101 @@
 package transcriber
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/exler/rekord/internal/logging"
 )
 
+// WhisperOptions controls how whisper subprocesses are run
+type WhisperOptions struct {
+	// Language is the spoken language code passed to whisper (default "en")
+	Language string
+	// Threads is the number of threads per whisper process (0 for whisper's default)
+	Threads int
+	// CPUs pins whisper processes to these CPUs (Linux only, empty for no pinning)
+	CPUs []int
+	// Slice runs whisper inside this systemd user slice (Linux only, empty to disable)
+	Slice string
+	// NoGPU forces whisper to run on the CPU even when built with GPU support
+	NoGPU bool
+	// GPUDevice selects the GPU to use when several are available
+	GPUDevice int
+	// FlashAttn enables flash attention, which speeds up GPU inference
+	FlashAttn bool
+	// BeamSize sets the beam search width (0 for whisper's default)
+	BeamSize int
+	// Prompt is the initial prompt biasing whisper towards domain terms
+	Prompt string
+}
+
 // WhisperCLI wraps the whisper.cpp command-line tool
 type WhisperCLI struct {
 	modelPath   string
 	whisperPath string
+	opts        WhisperOptions
+	mu          sync.Mutex
 }
 
+// killWaitDelay is how long a killed whisper process may keep its output
+// open before it is abandoned
+const killWaitDelay = 2 * time.Second
+
+// ErrWhisperNotFound is returned when no whisper.cpp executable can be found
+var ErrWhisperNotFound = errors.New("whisper.cpp executable not found. Please install whisper.cpp or set WHISPER_PATH")
+
 // NewWhisperCLI creates a new WhisperCLI instance
-func NewWhisperCLI(modelPath string) (*WhisperCLI, error) {
+func NewWhisperCLI(modelPath string, opts WhisperOptions) (*WhisperCLI, error) {
 	// Find whisper executable
-	whisperPath := findWhisperExecutable()
+	whisperPath := FindWhisperExecutable()
 	if whisperPath == "" {
-		return nil, fmt.Errorf("whisper.cpp executable not found. Please install whisper.cpp or set WHISPER_PATH")
+		return nil, ErrWhisperNotFound
 	}
 
 	return &WhisperCLI{
 		modelPath:   modelPath,
 		whisperPath: whisperPath,
+		opts:        opts,
 	}, nil
 }
 
-// findWhisperExecutable searches for the whisper executable
-func findWhisperExecutable() string {
+// NewPendingWhisperCLI creates a WhisperCLI before whisper.cpp is installed.
+// The executable is looked up when the first chunk is transcribed, so the
+// backend starts working once setup has installed it.
+func NewPendingWhisperCLI(modelPath string, opts WhisperOptions) *WhisperCLI {
+	return &WhisperCLI{
+		modelPath:   modelPath,
+		whisperPath: FindWhisperExecutable(),
+		opts:        opts,
+	}
+}
+
+// ManagedBinDir is where rekord installs its own whisper.cpp executable
+func ManagedBinDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "rekord", "whisper", "bin")
+}
+
+// FindWhisperExecutable searches for the whisper executable, returning an
+// empty path if none is found
+func FindWhisperExecutable() string {
 	// Check environment variable first
 	if path := os.Getenv("WHISPER_PATH"); path != "" {
 		if _, err := os.Stat(path); err == nil {
@@ -59,6 +116,7 @@ func findWhisperExecutable() string {
 
 	// Check common installation locations
 	locations := []string{
+		ManagedBinDir(),
 		"/usr/local/bin",
 		"/usr/bin",
 		filepath.Join(os.Getenv("HOME"), ".local/bin"),
@@ -78,8 +136,30 @@ func findWhisperExecutable() string {
 	return ""
 }
 
-// TranscribeCLI transcribes audio using whisper.cpp CLI and returns segments
-func (w *WhisperCLI) TranscribeCLI(samples []float32) ([]Segment, error) {
+// whisperVersionPattern finds a version such as 1.7.4 in whisper's output
+var whisperVersionPattern = regexp.MustCompile(`\bv?(\d+\.\d+\.\d+)\b`)
+
+// WhisperVersion asks the whisper executable at path for its version,
+// returning "" if it does not tell, as builds without a --version flag
+// print their usage instead
+func WhisperVersion(path string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out, _ := exec.CommandContext(ctx, path, "--version").CombinedOutput()
+	if m := whisperVersionPattern.FindSubmatch(out); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// Transcribe implements Backend using the whisper.cpp CLI
+func (w *WhisperCLI) Transcribe(ctx context.Context, samples []float32) ([]Segment, error) {
+	return w.TranscribeCLI(ctx, samples)
+}
+
+// TranscribeCLI transcribes audio using whisper.cpp CLI and returns segments.
+// The whisper process is killed when ctx is done.
+func (w *WhisperCLI) TranscribeCLI(ctx context.Context, samples []float32) ([]Segment, error) {
 	// Create temporary WAV file
 	tmpFile, err := os.CreateTemp("", "rekord-*.wav")
 	if err != nil {
@@ -97,14 +177,95 @@ func (w *WhisperCLI) TranscribeCLI(samples []float32) ([]Segment, error) {
 
 	logging.Debug("Running whisper on %s (%d samples)", tmpPath, len(samples))
 
-	// Run whisper.cpp with output to stdout only (no progress)
-	cmd := exec.Command(w.whisperPath,
-		"-m", w.modelPath,
+	// Transcripts are read from whisper's full JSON output (with timestamps,
+	// token probabilities and the detected language), written next to the WAV
+	outBase := strings.TrimSuffix(tmpPath, filepath.Ext(tmpPath))
+	jsonPath := outBase + ".json"
+	defer os.Remove(jsonPath)
+
+	// Run whisper.cpp without progress or log prints
+	args := []string{
+		"-m", w.Model(),
 		"-f", tmpPath,
-		"-l", "en",
+		"-l", w.language(),
 		"--no-prints", // Suppress all prints except transcript
 		"--print-progress", "false",
-	)
+		"-ojf", "-of", outBase,
+	}
+	args = append(args, w.tuningArgs()...)
+	if prompt := w.prompt(); prompt != "" {
+		args = append(args, "--prompt", prompt)
+	}
+	output, err := w.run(ctx, args)
+	if err != nil && isExecutableMissing(err) {
+		// whisper may have been upgraded or moved mid-session; look it up
+		// again and retry once before giving up
+		logging.Warn("Whisper executable unavailable: %v", err)
+		if rerr := w.rediscover(); rerr != nil {
+			return nil, rerr
+		}
+		output, err = w.run(ctx, args)
+	}
+	if err != nil {
+		logging.Error("Whisper failed: %v", err)
+		return nil, fmt.Errorf("whisper failed: %w", err)
+	}
+
+	logging.Debug("Whisper output: %s", output)
+
+	segments, err := readWhisperJSON(jsonPath)
+	if err != nil {
+		logging.Error("Failed to read whisper output: %v", err)
+		return nil, err
+	}
+	logging.Info("Transcribed %d segments", len(segments))
+
+	return segments, nil
+}
+
+// tuningArgs returns the performance-related whisper arguments
+func (w *WhisperCLI) tuningArgs() []string {
+	var args []string
+	if w.opts.Threads > 0 {
+		args = append(args, "-t", strconv.Itoa(w.opts.Threads))
+	}
+	if w.opts.NoGPU {
+		args = append(args, "--no-gpu")
+	} else if w.opts.GPUDevice > 0 {
+		args = append(args, "--device", strconv.Itoa(w.opts.GPUDevice))
+	}
+	if w.opts.FlashAttn {
+		args = append(args, "--flash-attn")
+	}
+	if w.opts.BeamSize > 0 {
+		args = append(args, "--beam-size", strconv.Itoa(w.opts.BeamSize))
+	}
+	return args
+}
+
+// Acceleration returns the GPU backends the whisper executable was built with
+func (w *WhisperCLI) Acceleration() []string {
+	return DetectAcceleration(w.executable())
+}
+
+// language returns the configured spoken language
+func (w *WhisperCLI) language() string {
+	if w.opts.Language == "" {
+		return "en"
+	}
+	return w.opts.Language
+}
+
+// run executes whisper with the given arguments and returns its stdout
+func (w *WhisperCLI) run(ctx context.Context, args []string) (string, error) {
+	path := w.executable()
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+
+	cmd := w.command(ctx, path, args)
+	// Don't wait for leftover children holding stdout after a kill
+	cmd.WaitDelay = killWaitDelay
 
 	// Capture stdout for transcript, redirect stderr to log file
 	var stdout bytes.Buffer
@@ -117,24 +278,108 @@ func (w *WhisperCLI) TranscribeCLI(samples []float32) ([]Segment, error) {
 		cmd.Stderr = io.Discard
 	}
 
-	err = cmd.Run()
-	if err != nil {
-		logging.Error("Whisper failed: %v", err)
-		return nil, fmt.Errorf("whisper failed: %w", err)
+	start := cmd.Start
+	if len(w.opts.CPUs) > 0 {
+		// Pinned before it runs, so none of its threads escape the pinning
+		start = func() error { return startPinned(cmd, w.opts.CPUs) }
+	}
+	if err := start(); err != nil {
+		return "", err
 	}
 
-	// Parse output - only the transcript text
-	output := stdout.String()
-	logging.Debug("Whisper output: %s", output)
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("killed: %w", ctx.Err())
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
 
-	segments := parseWhisperOutput(output)
-	logging.Info("Transcribed %d segments", len(segments))
+// SetPrompt implements Prompter, replacing the initial prompt for later chunks
+func (w *WhisperCLI) SetPrompt(prompt string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.opts.Prompt = prompt
+}
 
-	return segments, nil
+// prompt returns the current initial prompt
+func (w *WhisperCLI) prompt() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.opts.Prompt
+}
+
+// Model implements ModelSwitcher
+func (w *WhisperCLI) Model() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.modelPath
+}
+
+// SetModel implements ModelSwitcher. Chunks already being transcribed finish
+// with the previous model.
+func (w *WhisperCLI) SetModel(path string) error {
+	if err := ValidateModel(path); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.modelPath = path
+	return nil
+}
+
+// executable returns the current path of the whisper executable
+func (w *WhisperCLI) executable() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.whisperPath
+}
+
+// rediscover searches for the whisper executable again after the known one
+// became unavailable
+func (w *WhisperCLI) rediscover() error {
+	path := FindWhisperExecutable()
+	if path == "" {
+		return ErrWhisperNotFound
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if path != w.whisperPath {
+		logging.Info("Whisper executable moved: %s -> %s", w.whisperPath, path)
+	}
+	w.whisperPath = path
+	return nil
+}
+
+// isExecutableMissing reports whether err means the whisper executable could
+// not be run at all, as opposed to whisper itself failing
+func isExecutableMissing(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) ||
+		errors.Is(err, fs.ErrPermission) ||
+		errors.Is(err, exec.ErrNotFound) ||
+		errors.Is(err, syscall.ETXTBSY)
+}
+
+// command builds the whisper command, wrapped in a systemd scope when a
+// slice is configured. systemd-run --scope execs whisper in place, so
+// cancelling ctx kills whisper either way.
+func (w *WhisperCLI) command(ctx context.Context, path string, args []string) *exec.Cmd {
+	if w.opts.Slice == "" {
+		return exec.CommandContext(ctx, path, args...)
+	}
+
+	scopeArgs := append([]string{
+		"--user", "--scope", "--quiet",
+		"--slice=" + w.opts.Slice,
+		path,
+	}, args...)
+	return exec.CommandContext(ctx, "systemd-run", scopeArgs...)
 }
 
 // writeWAV writes audio samples to a WAV file
-func writeWAV(f *os.File, samples []float32, sampleRate int) error {
+func writeWAV(f io.Writer, samples []float32, sampleRate int) error {
 	// Convert float32 to int16
 	int16Samples := make([]int16, len(samples))
 	for i, s := range samples {
@@ -179,122 +424,6 @@ func writeWAV(f *os.File, samples []float32, sampleRate int) error {
 	return binary.Write(f, binary.LittleEndian, int16Samples)
 }
 
-// parseWhisperOutput parses whisper.cpp output into segments
-func parseWhisperOutput(output string) []Segment {
-	var segments []Segment
-
-	// Pattern for timestamps: [00:00:00.000 --> 00:00:05.000]
-	timestampPattern := regexp.MustCompile(`\[(\d+:\d+:\d+\.\d+) --> (\d+:\d+:\d+\.\d+)\]\s*(.*)`)
-
-	// Patterns to filter out whisper.cpp log/info lines
-	skipPatterns := []*regexp.Regexp{
-		regexp.MustCompile(`^whisper_`),            // whisper internal logs
-		regexp.MustCompile(`^main:`),               // main function logs
-		regexp.MustCompile(`^\s*$`),                // empty lines
-		regexp.MustCompile(`^system_info:`),        // system info
-		regexp.MustCompile(`^sampling:`),           // sampling info
-		regexp.MustCompile(`^beam_search:`),        // beam search info
-		regexp.MustCompile(`^output_`),             // output file info
-		regexp.MustCompile(`^log_mel_spectrogram`), // mel spectrogram
-		regexp.MustCompile(`^encode:`),             // encode info
-		regexp.MustCompile(`^decode:`),             // decode info
-		regexp.MustCompile(`^run:`),                // run info
-		regexp.MustCompile(`^model:`),              // model info
-		regexp.MustCompile(`^\[BLANK_AUDIO\]`),     // blank audio marker
-		regexp.MustCompile(`^processing audio`),    // processing info
-		regexp.MustCompile(`^loading model`),       // loading info
-		regexp.MustCompile(`^energy:`),             // energy info
-		regexp.MustCompile(`^speed:`),              // speed info
-		regexp.MustCompile(`^total`),               // total info
-		regexp.MustCompile(`^file:`),               // file info
-		regexp.MustCompile(`^n_`),                  // n_ parameters
-		regexp.MustCompile(`^ctx:`),                // context info
-		regexp.MustCompile(`^params:`),             // params info
-		regexp.MustCompile(`^initializing coreml`), // coreml info
-		regexp.MustCompile(`^coreml_`),             // coreml logs
-		regexp.MustCompile(`^cuda`),                // cuda logs
-		regexp.MustCompile(`^using \d+ threads`),   // thread info
-		regexp.MustCompile(`^fallback`),            // fallback info
-		regexp.MustCompile(`^seeking`),             // seeking info
-	}
-
-	scanner := bufio.NewScanner(strings.NewReader(output))
-	for scanner.Scan() {
-		line := scanner.Text()
-		trimmedLine := strings.TrimSpace(line)
-
-		if trimmedLine == "" {
-			continue
-		}
-
-		// Check if line should be skipped
-		skip := false
-		for _, pattern := range skipPatterns {
-			if pattern.MatchString(strings.ToLower(trimmedLine)) {
-				skip = true
-				break
-			}
-		}
-		if skip {
-			continue
-		}
-
-		matches := timestampPattern.FindStringSubmatch(line)
-		if len(matches) == 4 {
-			startTime := parseTimestamp(matches[1])
-			endTime := parseTimestamp(matches[2])
-			text := strings.TrimSpace(matches[3])
-
-			if text != "" && text != "[BLANK_AUDIO]" {
-				segments = append(segments, Segment{
-					Text:      text,
-					StartTime: startTime,
-					EndTime:   endTime,
-					Timestamp: time.Now(),
-				})
-			}
-		} else {
-			// Plain text without timestamps - only include if it looks like actual transcript
-			// Must not start with special characters or look like a log line
-			if !strings.HasPrefix(trimmedLine, "[") &&
-				!strings.HasPrefix(trimmedLine, "#") &&
-				!strings.HasPrefix(trimmedLine, "=") &&
-				!strings.Contains(trimmedLine, ":") &&
-				len(trimmedLine) > 1 {
-				segments = append(segments, Segment{
-					Text:      trimmedLine,
-					Timestamp: time.Now(),
-				})
-			}
-		}
-	}
-
-	return segments
-}
-
-// parseTimestamp parses a timestamp string into a Duration
-func parseTimestamp(ts string) time.Duration {
-	parts := strings.Split(ts, ":")
-	if len(parts) != 3 {
-		return 0
-	}
-
-	hours, _ := strconv.Atoi(parts[0])
-	minutes, _ := strconv.Atoi(parts[1])
-
-	secParts := strings.Split(parts[2], ".")
-	seconds, _ := strconv.Atoi(secParts[0])
-	milliseconds := 0
-	if len(secParts) > 1 {
-		milliseconds, _ = strconv.Atoi(secParts[1])
-	}
-
-	return time.Duration(hours)*time.Hour +
-		time.Duration(minutes)*time.Minute +
-		time.Duration(seconds)*time.Second +
-		time.Duration(milliseconds)*time.Millisecond
-}
-
 // Close is a no-op for CLI wrapper
 func (w *WhisperCLI) Close() error {
 	return nil
@@ -0,0 +1,86 @@
+package transcriber
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// whisperJSON is the subset of whisper.cpp's full JSON output (-ojf) rekord uses
+type whisperJSON struct {
+	Result struct {
+		Language string `json:"language"`
+	} `json:"result"`
+	Transcription []struct {
+		Offsets struct {
+			From int64 `json:"from"`
+			To   int64 `json:"to"`
+		} `json:"offsets"`
+		Text   string `json:"text"`
+		Tokens []struct {
+			Text    string  `json:"text"`
+			P       float64 `json:"p"`
+			Offsets struct {
+				From int64 `json:"from"`
+				To   int64 `json:"to"`
+			} `json:"offsets"`
+		} `json:"tokens"`
+	} `json:"transcription"`
+}
+
+// readWhisperJSON parses the JSON file whisper.cpp wrote for a chunk. Blank
+// audio markers and empty segments are skipped.
+func readWhisperJSON(path string) ([]Segment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var out whisperJSON
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse whisper JSON: %w", err)
+	}
+
+	var segments []Segment
+	now := time.Now()
+	for _, t := range out.Transcription {
+		text := strings.TrimSpace(t.Text)
+		if text == "" || text == "[BLANK_AUDIO]" {
+			continue
+		}
+
+		// Average probability of the real (non-special) tokens
+		var sum float64
+		var n int
+		tokens := make([]wordToken, 0, len(t.Tokens))
+		for _, tok := range t.Tokens {
+			if isSpecialToken(tok.Text) {
+				continue
+			}
+			sum += tok.P
+			n++
+			tokens = append(tokens, wordToken{
+				Text:  tok.Text,
+				Start: time.Duration(tok.Offsets.From) * time.Millisecond,
+				End:   time.Duration(tok.Offsets.To) * time.Millisecond,
+			})
+		}
+		var confidence float64
+		if n > 0 {
+			confidence = sum / float64(n)
+		}
+
+		segments = append(segments, Segment{
+			Text:       text,
+			StartTime:  time.Duration(t.Offsets.From) * time.Millisecond,
+			EndTime:    time.Duration(t.Offsets.To) * time.Millisecond,
+			Timestamp:  now,
+			Language:   out.Result.Language,
+			Confidence: confidence,
+			Words:      wordsFromTokens(tokens),
+		})
+	}
+	return segments, nil
+}
@@ -0,0 +1,80 @@
+package transcriber
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Word is a single word with its timing within the audio
+type Word struct {
+	Text  string
+	Start time.Duration
+	End   time.Duration
+}
+
+// wordToken is a whisper token with its timing
+type wordToken struct {
+	Text       string
+	Start, End time.Duration
+}
+
+// isSpecialToken reports whether a whisper token is a control token such as
+// a timestamp or [_BEG_] rather than text
+func isSpecialToken(text string) bool {
+	return strings.HasPrefix(text, "[_") || strings.HasPrefix(text, "<|")
+}
+
+// wordsFromTokens joins whisper's sub-word tokens into words. A token
+// starting with a space begins a new word.
+func wordsFromTokens(tokens []wordToken) []Word {
+	var words []Word
+	for _, tok := range tokens {
+		if tok.Text == "" || isSpecialToken(tok.Text) {
+			continue
+		}
+		if len(words) == 0 || strings.HasPrefix(tok.Text, " ") {
+			text := strings.TrimSpace(tok.Text)
+			if text == "" {
+				continue
+			}
+			words = append(words, Word{Text: text, Start: tok.Start, End: tok.End})
+			continue
+		}
+		last := &words[len(words)-1]
+		last.Text += tok.Text
+		last.End = max(last.End, tok.End)
+	}
+	return words
+}
+
+// Shift moves the segment and its words later by d, e.g. from chunk-relative
+// to session-relative times
+func (s *Segment) Shift(d time.Duration) {
+	s.StartTime += d
+	s.EndTime += d
+	for i := range s.Words {
+		s.Words[i].Start += d
+		s.Words[i].End += d
+	}
+}
+
+// SetText replaces the segment text. Word timings are kept when the new text
+// is the old text with words cut from the front, as when trimming overlap,
+// and dropped otherwise since they no longer match.
+func (s *Segment) SetText(text string) {
+	if len(s.Words) > 0 {
+		old := strings.Fields(s.Text)
+		kept := strings.Fields(text)
+		cut := len(old) - len(kept)
+		if cut >= 0 && len(old) == len(s.Words) && slices.Equal(old[cut:], kept) {
+			s.Words = s.Words[cut:]
+			if cut > 0 && len(s.Words) > 0 {
+				s.StartTime = s.Words[0].Start
+			}
+		} else {
+			s.Words = nil
+		}
+	}
+	s.Text = text
+}
@@ -0,0 +1,112 @@
+package transcript
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Annotation is a labelled point in a session, as an offset from its start
+type Annotation struct {
+	Offset time.Duration
+	Label  string
+}
+
+// ReadAnnotationsCSV reads annotations from CSV rows of the form time,label.
+// Times may be given as hh:mm:ss[.mmm], mm:ss or plain seconds. A header row
+// is skipped if present. The result is sorted by offset.
+func ReadAnnotationsCSV(r io.Reader) ([]Annotation, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var annotations []Annotation
+	for line := 1; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected time,label", line)
+		}
+
+		offset, err := ParseOffset(record[0])
+		if err != nil {
+			if line == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		annotations = append(annotations, Annotation{
+			Offset: offset,
+			Label:  strings.TrimSpace(record[1]),
+		})
+	}
+
+	slices.SortStableFunc(annotations, func(a, b Annotation) int {
+		return int(a.Offset - b.Offset)
+	})
+	return annotations, nil
+}
+
+// LoadAnnotationsCSV reads annotations from a CSV file
+func LoadAnnotationsCSV(path string) ([]Annotation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadAnnotationsCSV(f)
+}
+
+// WriteAnnotationsCSV writes annotations as CSV with a time,label header
+func WriteAnnotationsCSV(w io.Writer, annotations []Annotation) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "label"})
+	for _, a := range annotations {
+		cw.Write([]string{FormatOffset(a.Offset), a.Label})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ParseOffset parses an offset given as hh:mm:ss[.mmm], mm:ss or seconds
+func ParseOffset(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	parts := strings.Split(s, ":")
+	if s == "" || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+
+	var total float64
+	for _, p := range parts {
+		n, err := strconv.ParseFloat(p, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+		total = total*60 + n
+	}
+	return time.Duration(total * float64(time.Second)).Round(time.Millisecond), nil
+}
+
+// FormatOffset formats an offset as hh:mm:ss, with milliseconds when present
+func FormatOffset(d time.Duration) string {
+	h := int(d / time.Hour)
+	m := int(d % time.Hour / time.Minute)
+	s := int(d % time.Minute / time.Second)
+	ms := int(d % time.Second / time.Millisecond)
+	if ms != 0 {
+		return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+}
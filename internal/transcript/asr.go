@@ -0,0 +1,256 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// JSONFormat selects the schema of the JSON export
+type JSONFormat string
+
+const (
+	// JSONRekord is rekord's own schema, written by WriteJSON
+	JSONRekord JSONFormat = "rekord"
+	// JSONAWS follows the output of Amazon Transcribe, written by
+	// WriteAWSJSON
+	JSONAWS JSONFormat = "aws"
+	// JSONGCP follows the response of Google Cloud Speech-to-Text, written
+	// by WriteGCPJSON
+	JSONGCP JSONFormat = "gcp"
+)
+
+// ParseJSONFormat parses a -json-format flag value
+func ParseJSONFormat(s string) (JSONFormat, error) {
+	switch f := JSONFormat(s); f {
+	case JSONRekord, JSONAWS, JSONGCP:
+		return f, nil
+	case "":
+		return JSONRekord, nil
+	}
+	return "", fmt.Errorf("unknown JSON format %q (want rekord, aws or gcp)", s)
+}
+
+// awsItem is an entry of results.items in Amazon Transcribe output
+type awsItem struct {
+	StartTime    string           `json:"start_time,omitempty"`
+	EndTime      string           `json:"end_time,omitempty"`
+	Alternatives []awsAlternative `json:"alternatives"`
+	Type         string           `json:"type"`
+	SpeakerLabel string           `json:"speaker_label,omitempty"`
+}
+
+// awsAlternative is the recognized content of an item
+type awsAlternative struct {
+	Confidence string `json:"confidence"`
+	Content    string `json:"content"`
+}
+
+// awsSpeakerSegment is an entry of results.speaker_labels.segments
+type awsSpeakerSegment struct {
+	StartTime    string           `json:"start_time"`
+	EndTime      string           `json:"end_time"`
+	SpeakerLabel string           `json:"speaker_label"`
+	Items        []awsSpeakerItem `json:"items"`
+}
+
+// awsSpeakerItem is a word of a speaker segment
+type awsSpeakerItem struct {
+	StartTime    string `json:"start_time"`
+	EndTime      string `json:"end_time"`
+	SpeakerLabel string `json:"speaker_label"`
+}
+
+// WriteAWSJSON writes segments in the output format of Amazon Transcribe
+// jobs named name: the full transcript, one item per word and punctuation
+// mark, and speaker labels spk_0, spk_1, ... for the segment sources, if
+// any. Word confidences are those of their segments.
+func WriteAWSJSON(w io.Writer, name string, segments []transcriber.Segment) error {
+	speakers := speakerIndexes(segments)
+
+	var items []awsItem
+	var speakerSegments []awsSpeakerSegment
+	var text []string
+	for _, seg := range segments {
+		label := ""
+		if i, ok := speakers[seg.Source]; ok {
+			label = fmt.Sprintf("spk_%d", i)
+		}
+		confidence := strconv.FormatFloat(seg.Confidence, 'f', 4, 64)
+
+		var segItems []awsSpeakerItem
+		for _, word := range timedWords(seg) {
+			content, punct := splitPunctuation(word.Text)
+			if content != "" {
+				item := awsItem{
+					StartTime:    awsTime(word.Start),
+					EndTime:      awsTime(word.End),
+					Alternatives: []awsAlternative{{Confidence: confidence, Content: content}},
+					Type:         "pronunciation",
+					SpeakerLabel: label,
+				}
+				items = append(items, item)
+				segItems = append(segItems, awsSpeakerItem{item.StartTime, item.EndTime, label})
+			}
+			if punct != "" {
+				items = append(items, awsItem{
+					Alternatives: []awsAlternative{{Confidence: "0.0", Content: punct}},
+					Type:         "punctuation",
+				})
+			}
+		}
+		text = append(text, seg.Text)
+
+		if label != "" {
+			speakerSegments = append(speakerSegments, awsSpeakerSegment{
+				StartTime:    awsTime(seg.StartTime),
+				EndTime:      awsTime(seg.EndTime),
+				SpeakerLabel: label,
+				Items:        segItems,
+			})
+		}
+	}
+
+	results := map[string]any{
+		"transcripts": []map[string]string{{"transcript": strings.Join(text, " ")}},
+		"items":       items,
+	}
+	if len(speakers) > 0 {
+		results["speaker_labels"] = map[string]any{
+			"speakers": len(speakers),
+			"segments": speakerSegments,
+		}
+	}
+	doc := map[string]any{
+		"jobName": name,
+		"status":  "COMPLETED",
+		"results": results,
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// gcpWord is a word of a Google Cloud Speech-to-Text alternative
+type gcpWord struct {
+	StartTime  string  `json:"startTime"`
+	EndTime    string  `json:"endTime"`
+	Word       string  `json:"word"`
+	Confidence float64 `json:"confidence,omitempty"`
+	SpeakerTag int     `json:"speakerTag,omitempty"`
+}
+
+// WriteGCPJSON writes segments in the response format of Google Cloud
+// Speech-to-Text recognize requests: one result per segment with a single
+// alternative and its words, tagged with speakers 1, 2, ... for the segment
+// sources, if any
+func WriteGCPJSON(w io.Writer, segments []transcriber.Segment) error {
+	speakers := speakerIndexes(segments)
+
+	type alternative struct {
+		Transcript string    `json:"transcript"`
+		Confidence float64   `json:"confidence,omitempty"`
+		Words      []gcpWord `json:"words"`
+	}
+	type result struct {
+		Alternatives  []alternative `json:"alternatives"`
+		ResultEndTime string        `json:"resultEndTime"`
+		LanguageCode  string        `json:"languageCode,omitempty"`
+	}
+
+	results := make([]result, 0, len(segments))
+	for _, seg := range segments {
+		tag := 0
+		if i, ok := speakers[seg.Source]; ok {
+			tag = i + 1
+		}
+		var words []gcpWord
+		for _, word := range timedWords(seg) {
+			words = append(words, gcpWord{
+				StartTime:  gcpTime(word.Start),
+				EndTime:    gcpTime(word.End),
+				Word:       word.Text,
+				Confidence: seg.Confidence,
+				SpeakerTag: tag,
+			})
+		}
+		results = append(results, result{
+			Alternatives:  []alternative{{Transcript: seg.Text, Confidence: seg.Confidence, Words: words}},
+			ResultEndTime: gcpTime(seg.EndTime),
+			LanguageCode:  seg.Language,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{"results": results})
+}
+
+// speakerIndexes numbers the segment sources in order of appearance. Mixed
+// audio without a source gets no speaker.
+func speakerIndexes(segments []transcriber.Segment) map[string]int {
+	speakers := make(map[string]int)
+	for _, seg := range segments {
+		if _, ok := speakers[seg.Source]; !ok && seg.Source != "" {
+			speakers[seg.Source] = len(speakers)
+		}
+	}
+	return speakers
+}
+
+// timedWords returns the words of a segment with their times. Without word
+// timestamps from the backend, the segment is divided among its words by
+// length.
+func timedWords(seg transcriber.Segment) []transcriber.Word {
+	if len(seg.Words) > 0 {
+		words := make([]transcriber.Word, 0, len(seg.Words))
+		for _, w := range seg.Words {
+			if w.Text = strings.TrimSpace(w.Text); w.Text != "" {
+				words = append(words, w)
+			}
+		}
+		return words
+	}
+	fields := strings.Fields(seg.Text)
+	total := 0
+	for _, f := range fields {
+		total += utf8.RuneCountInString(f)
+	}
+	if total == 0 {
+		return nil
+	}
+
+	words := make([]transcriber.Word, len(fields))
+	span := seg.EndTime - seg.StartTime
+	done := 0
+	for i, f := range fields {
+		start := seg.StartTime + span*time.Duration(done)/time.Duration(total)
+		done += utf8.RuneCountInString(f)
+		end := seg.StartTime + span*time.Duration(done)/time.Duration(total)
+		words[i] = transcriber.Word{Text: f, Start: start, End: end}
+	}
+	return words
+}
+
+// splitPunctuation separates trailing punctuation from a word
+func splitPunctuation(word string) (content, punct string) {
+	content = strings.TrimRight(word, ".,?!;:")
+	return content, word[len(content):]
+}
+
+// awsTime formats a time as seconds with millisecond precision, as a string
+func awsTime(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// gcpTime formats a time as a protobuf JSON duration, e.g. "1.500s"
+func gcpTime(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64) + "s"
+}
@@ -0,0 +1,288 @@
+package transcript
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// minCaptionSimilarity is the similarity above which a caption is taken to
+// be the same utterance as a segment when reconciling their timestamps
+const minCaptionSimilarity = 0.3
+
+// Caption is an utterance from a meeting platform's captions, transcript or
+// chat export. Times are offsets from the start of the export, or the time
+// of day for exports that only give clock times.
+type Caption struct {
+	Start   time.Duration
+	End     time.Duration
+	Speaker string
+	Text    string
+}
+
+var (
+	// vttTiming matches the timing line of a WebVTT or SRT cue
+	vttTiming = regexp.MustCompile(`^((?:\d+:)?\d{1,2}:\d{2}[.,]\d{3})\s+-->\s+((?:\d+:)?\d{1,2}:\d{2}[.,]\d{3})`)
+	// vttVoice matches a WebVTT voice span such as "<v Alice Smith>"
+	vttVoice = regexp.MustCompile(`^<v(?:\.[^ >]+)*\s+([^>]+)>`)
+	// vttTag matches the remaining WebVTT markup in cue text
+	vttTag = regexp.MustCompile(`<[^>]*>`)
+	// zoomSpeaker matches the heading of an utterance in a Zoom transcript
+	// saved from a meeting: "[Alice Smith] 10:02:15"
+	zoomSpeaker = regexp.MustCompile(`^\[([^\]]+)\]\s+(\d{1,2}:\d{2}:\d{2})\s*$`)
+	// zoomChat matches a message of a Zoom chat export:
+	// "10:02:15 From Alice Smith to Everyone: text"
+	zoomChat = regexp.MustCompile(`^(\d{1,2}:\d{2}:\d{2})\s+From\s+(.+?)(?:\s+[Tt]o\s+[^:]+?)?\s*:\s*(.*)$`)
+	// captionSpeaker matches a speaker name given at the start of cue text,
+	// as in Zoom's cloud recording captions: "Alice Smith: text"
+	captionSpeaker = regexp.MustCompile(`^([\p{L}][\p{L}\p{N}.'\- ]{0,40}):\s+(.+)$`)
+)
+
+// ReadCaptions reads captions in WebVTT or SRT format, a Zoom transcript
+// saved from a meeting or a Zoom chat export. Speakers are taken from voice
+// spans or a "Name:" prefix of the text. The result is sorted by start.
+func ReadCaptions(r io.Reader) ([]Caption, error) {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		lines = append(lines, strings.TrimRight(sc.Text(), "\r"))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) > 0 {
+		lines[0] = strings.TrimPrefix(lines[0], "\ufeff")
+	}
+
+	var captions []Caption
+	if parse := captionParser(lines); parse != nil {
+		captions = parse(lines)
+	}
+	if len(captions) == 0 {
+		return nil, fmt.Errorf("no captions found (expected WebVTT, SRT or a Zoom transcript or chat)")
+	}
+
+	slices.SortStableFunc(captions, func(a, b Caption) int {
+		return int(a.Start - b.Start)
+	})
+	return captions, nil
+}
+
+// LoadCaptions reads captions from a file
+func LoadCaptions(path string) ([]Caption, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCaptions(f)
+}
+
+// captionParser returns the parser for the format of the first line that
+// identifies one, or nil if none does
+func captionParser(lines []string) func([]string) []Caption {
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case vttTiming.MatchString(line):
+			return readCues
+		case zoomSpeaker.MatchString(line):
+			return readZoomTranscript
+		case zoomChat.MatchString(line):
+			return readZoomChat
+		}
+	}
+	return nil
+}
+
+// readCues parses the cues of WebVTT or SRT captions
+func readCues(lines []string) []Caption {
+	var captions []Caption
+	for i := 0; i < len(lines); i++ {
+		m := vttTiming.FindStringSubmatch(strings.TrimSpace(lines[i]))
+		if m == nil {
+			continue
+		}
+		start, err1 := ParseOffset(strings.Replace(m[1], ",", ".", 1))
+		end, err2 := ParseOffset(strings.Replace(m[2], ",", ".", 1))
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		c := Caption{Start: start, End: end}
+		var text []string
+		for i++; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+			line := strings.TrimSpace(lines[i])
+			if v := vttVoice.FindStringSubmatch(line); v != nil && c.Speaker == "" {
+				c.Speaker = strings.TrimSpace(v[1])
+			}
+			if line = strings.TrimSpace(vttTag.ReplaceAllString(line, "")); line != "" {
+				text = append(text, line)
+			}
+		}
+		c.Text = strings.Join(text, " ")
+		if c.Speaker == "" {
+			c.Speaker, c.Text = splitSpeaker(c.Text)
+		}
+		if c.Text != "" {
+			captions = append(captions, c)
+		}
+	}
+	return captions
+}
+
+// readZoomTranscript parses a transcript saved from a Zoom meeting, where
+// each utterance is a "[Name] hh:mm:ss" line followed by its text
+func readZoomTranscript(lines []string) []Caption {
+	var captions []Caption
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if m := zoomSpeaker.FindStringSubmatch(line); m != nil {
+			start, err := ParseOffset(m[2])
+			if err != nil {
+				continue
+			}
+			captions = append(captions, Caption{Start: start, Speaker: strings.TrimSpace(m[1])})
+			continue
+		}
+		if line == "" || len(captions) == 0 {
+			continue
+		}
+		c := &captions[len(captions)-1]
+		c.Text = strings.TrimSpace(c.Text + " " + line)
+	}
+	endAtNext(captions)
+	return slices.DeleteFunc(captions, func(c Caption) bool { return c.Text == "" })
+}
+
+// readZoomChat parses a Zoom chat export, where each message is a
+// "hh:mm:ss From Name to Recipient: text" line, optionally followed by
+// indented continuation lines
+func readZoomChat(lines []string) []Caption {
+	var captions []Caption
+	for _, line := range lines {
+		if m := zoomChat.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			start, err := ParseOffset(m[1])
+			if err != nil {
+				continue
+			}
+			captions = append(captions, Caption{
+				Start:   start,
+				Speaker: strings.TrimSpace(m[2]),
+				Text:    strings.TrimSpace(m[3]),
+			})
+			continue
+		}
+		if line = strings.TrimSpace(line); line == "" || len(captions) == 0 {
+			continue
+		}
+		c := &captions[len(captions)-1]
+		c.Text = strings.TrimSpace(c.Text + " " + line)
+	}
+	endAtNext(captions)
+	return slices.DeleteFunc(captions, func(c Caption) bool { return c.Text == "" })
+}
+
+// endAtNext ends each caption where the next one starts, for exports that
+// only give start times. The last caption gets no duration.
+func endAtNext(captions []Caption) {
+	for i := range captions {
+		captions[i].End = captions[i].Start
+		if i+1 < len(captions) && captions[i+1].Start > captions[i].Start {
+			captions[i].End = captions[i+1].Start
+		}
+	}
+}
+
+// splitSpeaker splits a "Name: text" prefix off caption text
+func splitSpeaker(text string) (speaker, rest string) {
+	if m := captionSpeaker.FindStringSubmatch(text); m != nil {
+		return strings.TrimSpace(m[1]), m[2]
+	}
+	return "", text
+}
+
+// CaptionOffset estimates the offset of captions relative to segments, i.e.
+// what to add to caption times to get segment times, from the utterances
+// whose wording both share. It reports false if there are none.
+func CaptionOffset(segments []transcriber.Segment, captions []Caption) (time.Duration, bool) {
+	var shifts []time.Duration
+	for _, c := range captions {
+		best, bestSim := -1, minCaptionSimilarity
+		for i, seg := range segments {
+			if sim := Similarity(seg.Text, c.Text); sim >= bestSim {
+				best, bestSim = i, sim
+			}
+		}
+		if best >= 0 {
+			shifts = append(shifts, segments[best].StartTime-c.Start)
+		}
+	}
+	if len(shifts) == 0 {
+		return 0, false
+	}
+
+	// The median ignores the odd utterance matched at the wrong place
+	slices.Sort(shifts)
+	return shifts[len(shifts)/2], true
+}
+
+// AssignSpeakers sets the Source of each segment to the speaker of the
+// caption overlapping it the most once shifted by offset, or of the caption
+// starting closest before it for captions without a duration. Segments
+// without a matching caption are left unchanged. It returns how many
+// segments were assigned a speaker.
+func AssignSpeakers(segments []transcriber.Segment, captions []Caption, offset time.Duration) int {
+	assigned := 0
+	for i := range segments {
+		seg := &segments[i]
+		overlaps := make(map[string]time.Duration)
+		best := ""
+		for _, c := range captions {
+			if c.Speaker == "" {
+				continue
+			}
+			start, end := c.Start+offset, c.End+offset
+			if end <= start {
+				continue
+			}
+			if o := min(end, seg.EndTime) - max(start, seg.StartTime); o > 0 {
+				overlaps[c.Speaker] += o
+				if best == "" || overlaps[c.Speaker] > overlaps[best] {
+					best = c.Speaker
+				}
+			}
+		}
+		if best == "" {
+			best = precedingSpeaker(captions, seg.StartTime-offset)
+		}
+		if best != "" {
+			seg.Source = best
+			assigned++
+		}
+	}
+	return assigned
+}
+
+// precedingSpeaker returns the speaker of the last caption without a
+// duration starting at or before t
+func precedingSpeaker(captions []Caption, t time.Duration) string {
+	speaker := ""
+	for _, c := range captions {
+		if c.Start > t {
+			break
+		}
+		if c.End <= c.Start && c.Speaker != "" {
+			speaker = c.Speaker
+		}
+	}
+	return speaker
+}
@@ -0,0 +1,28 @@
+package transcript
+
+import (
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// Document is a transcript laid out as formal meeting minutes, for the DOCX
+// and PDF exports: a title page with the metadata, then the summary and the
+// timestamped segments
+type Document struct {
+	Title string
+	// Metadata is listed on the title page in order, e.g. date and model
+	Metadata []Field
+
+	// Summary holds the key points and ActionItems the tasks mentioned;
+	// empty sections are left out
+	Summary     []string
+	ActionItems []string
+
+	Segments []transcriber.Segment
+	Format   transcriber.TimeFormat
+}
+
+// Field is a labeled value of the document metadata
+type Field struct {
+	Label string
+	Value string
+}
@@ -0,0 +1,143 @@
+package transcript
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// docxFiles are the parts of a DOCX package besides the document itself
+var docxFiles = map[string]string{
+	"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+</Types>`,
+	"_rels/.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+</Relationships>`,
+	"word/_rels/document.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`,
+	"word/styles.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults>
+<w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:cs="Calibri"/><w:sz w:val="22"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="264" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+</w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:before="2400" w:after="480"/></w:pPr><w:rPr><w:b/><w:sz w:val="52"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="360" w:after="120"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="32"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListItem"><w:name w:val="List Item"/><w:basedOn w:val="Normal"/><w:pPr><w:ind w:left="360" w:hanging="360"/></w:pPr></w:style>
+<w:style w:type="paragraph" w:styleId="Segment"><w:name w:val="Segment"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="60"/><w:ind w:left="1080" w:hanging="1080"/></w:pPr></w:style>
+</w:styles>`,
+}
+
+// WriteDOCX writes doc as a Word document
+func WriteDOCX(w io.Writer, doc Document) error {
+	var body bytes.Buffer
+	paragraph := func(style string, runs ...docxRun) {
+		body.WriteString("<w:p>")
+		if style != "" {
+			body.WriteString(`<w:pPr><w:pStyle w:val="` + style + `"/></w:pPr>`)
+		}
+		for _, r := range runs {
+			r.write(&body)
+		}
+		body.WriteString("</w:p>\n")
+	}
+
+	// Title page
+	paragraph("Title", docxRun{text: doc.Title})
+	for _, f := range doc.Metadata {
+		paragraph("", docxRun{text: f.Label + ": ", bold: true}, docxRun{text: f.Value})
+	}
+	body.WriteString(`<w:p><w:r><w:br w:type="page"/></w:r></w:p>` + "\n")
+
+	list := func(heading string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		paragraph("Heading1", docxRun{text: heading})
+		for _, item := range items {
+			paragraph("ListItem", docxRun{text: "•\t" + item})
+		}
+	}
+	list("Summary", doc.Summary)
+	list("Action items", doc.ActionItems)
+
+	paragraph("Heading1", docxRun{text: "Transcript"})
+	for _, seg := range doc.Segments {
+		runs := []docxRun{{text: doc.Format.Format(seg) + "\t", color: "808080"}}
+		if seg.Source != "" {
+			runs = append(runs, docxRun{text: seg.Source + ": ", bold: true})
+		}
+		paragraph("Segment", append(runs, docxRun{text: seg.Text})...)
+	}
+
+	zw := zip.NewWriter(w)
+	// [Content_Types].xml conventionally comes first
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/_rels/document.xml.rels", "word/styles.xml"} {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, docxFiles[name]); err != nil {
+			return err
+		}
+	}
+	f, err := zw.Create("word/document.xml")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:body>
+`+body.String()+`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr>
+</w:body>
+</w:document>`)
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// docxRun is a run of text with one formatting
+type docxRun struct {
+	text  string
+	bold  bool
+	color string
+}
+
+// write writes the run as WordprocessingML, turning tabs into tab elements
+func (r docxRun) write(b *bytes.Buffer) {
+	b.WriteString("<w:r>")
+	if r.bold || r.color != "" {
+		b.WriteString("<w:rPr>")
+		if r.bold {
+			b.WriteString("<w:b/>")
+		}
+		if r.color != "" {
+			b.WriteString(`<w:color w:val="` + r.color + `"/>`)
+		}
+		b.WriteString("</w:rPr>")
+	}
+	for i, part := range strings.Split(r.text, "\t") {
+		if i > 0 {
+			b.WriteString("<w:tab/>")
+		}
+		if part == "" {
+			continue
+		}
+		b.WriteString(`<w:t xml:space="preserve">`)
+		xml.EscapeText(b, []byte(part))
+		b.WriteString("</w:t>")
+	}
+	b.WriteString("</w:r>")
+}
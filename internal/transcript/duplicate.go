@@ -0,0 +1,166 @@
+// Package transcript provides helpers for working with saved transcripts
+package transcript
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DuplicateThreshold is the similarity above which two transcripts are
+// considered recordings of the same meeting
+const DuplicateThreshold = 0.6
+
+// shingleSize is the number of consecutive words compared at a time
+const shingleSize = 3
+
+// Duplicate describes a saved transcript that closely matches another one
+type Duplicate struct {
+	Path       string
+	Similarity float64
+}
+
+// FindDuplicate looks for a transcript in dir, modified at or after since,
+// whose text is at least DuplicateThreshold similar to text. Files named
+// exclude are skipped. It returns nil if no duplicate is found.
+func FindDuplicate(dir, exclude, text string, since time.Time) (*Duplicate, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	want := shingles(text)
+	if len(want) == 0 {
+		return nil, nil
+	}
+
+	var best *Duplicate
+	for _, path := range matches {
+		if filepath.Base(path) == exclude {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Before(since) {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		score := jaccard(want, shingles(bodyText(string(data))))
+		if score >= DuplicateThreshold && (best == nil || score > best.Similarity) {
+			best = &Duplicate{Path: path, Similarity: score}
+		}
+	}
+
+	return best, nil
+}
+
+// Merge appends the lines of the transcript at other that the transcript at
+// path lacks to the end of path, then discards other. Lines are compared by
+// their text, so the part of a meeting only one of two runs recorded is
+// kept. Only the text transcript is merged, not its exports.
+func Merge(path, other string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	otherData, err := os.ReadFile(other)
+	if err != nil {
+		return err
+	}
+
+	have := make(map[string]bool)
+	for line := range strings.Lines(bodyText(string(data))) {
+		have[strings.ToLower(strings.TrimSpace(line))] = true
+	}
+	body := string(otherData)
+	if _, after, ok := strings.Cut(body, headerSeparator); ok {
+		body = after
+	}
+	var missing strings.Builder
+	for line := range strings.SplitSeq(body, "\n") {
+		text := strings.TrimSpace(leadingTimestamp.ReplaceAllString(line, ""))
+		if text == "" || have[strings.ToLower(text)] {
+			continue
+		}
+		missing.WriteString(strings.TrimSpace(line) + "\n")
+	}
+
+	if missing.Len() > 0 {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(f, "\nMerged from %s:\n%s", filepath.Base(other), missing.String())
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return Discard(other)
+}
+
+// Discard removes the transcript at path and the exports saved next to it,
+// which share its name up to the extension
+func Discard(path string) error {
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	prefix := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + "."
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), prefix) {
+			errs = append(errs, os.Remove(filepath.Join(dir, e.Name())))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Similarity returns the Jaccard similarity of the word shingles of a and b,
+// from 0 (nothing in common) to 1 (identical wording)
+func Similarity(a, b string) float64 {
+	return jaccard(shingles(a), shingles(b))
+}
+
+// shingles returns the set of normalized word n-grams in text
+func shingles(text string) map[string]struct{} {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+
+	set := make(map[string]struct{})
+	for i := 0; i+shingleSize <= len(words); i++ {
+		set[strings.Join(words[i:i+shingleSize], " ")] = struct{}{}
+	}
+	return set
+}
+
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	common := 0
+	for s := range a {
+		if _, ok := b[s]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
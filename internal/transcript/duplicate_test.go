@@ -0,0 +1,53 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMergeAddsMissingLinesAndDiscardsOther(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.txt": "Rekord Meeting Transcript\n" + headerSeparator + "\n[10:00:05] Let's start.\n[10:00:09] The release is on Friday.\n",
+		// The other run started earlier and heard the first line too
+		"b.txt":   "Rekord Meeting Transcript\n" + headerSeparator + "\n[10:00:01] Can everyone hear me?\n[10:00:05] let's start.\n",
+		"b.srt":   "1\n",
+		"b_2.txt": "another transcript\n",
+	})
+
+	if err := Merge(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Rekord Meeting Transcript\n" + headerSeparator + "\n[10:00:05] Let's start.\n[10:00:09] The release is on Friday.\n" +
+		"\nMerged from b.txt:\n[10:00:01] Can everyone hear me?\n"
+	if string(data) != want {
+		t.Errorf("merged transcript:\n%s\nwant:\n%s", data, want)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"a.txt", "b_2.txt"}; !slices.Equal(names, want) {
+		t.Errorf("files after merge = %v, want %v", names, want)
+	}
+}
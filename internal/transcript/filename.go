@@ -0,0 +1,77 @@
+package transcript
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// maxCollisionSuffix bounds the search for a free file name
+const maxCollisionSuffix = 1000
+
+// windowsReserved are device names Windows does not allow as file names
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SanitizeFilename replaces characters that are not allowed in file names on
+// the current OS and trims problematic leading/trailing characters
+func SanitizeFilename(name string) string {
+	illegal := "/\x00"
+	if runtime.GOOS == "windows" {
+		illegal = `<>:"/\|?*` + "\x00"
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(illegal, r) {
+			return '_'
+		}
+		return r
+	}, name)
+
+	// Leading dots hide files, trailing dots and spaces are dropped on Windows
+	name = strings.TrimLeft(name, ". ")
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return "transcript"
+	}
+
+	if runtime.GOOS == "windows" {
+		base := strings.TrimSuffix(name, filepath.Ext(name))
+		if windowsReserved[strings.ToUpper(base)] {
+			name = "_" + name
+		}
+	}
+	return name
+}
+
+// CreateUnique creates a new file in dir named name, adding a numeric suffix
+// (name_2.txt, name_3.txt, ...) instead of overwriting an existing file
+func CreateUnique(dir, name string) (*os.File, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	for i := 1; i <= maxCollisionSuffix; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = fmt.Sprintf("%s_%d%s", base, i, ext)
+		}
+
+		f, err := os.OpenFile(filepath.Join(dir, candidate), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("no free file name for %s in %s", name, dir)
+}
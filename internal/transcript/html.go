@@ -0,0 +1,128 @@
+package transcript
+
+import (
+	"cmp"
+	"html/template"
+	"io"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// videoExtensions are the media files the HTML export shows in a video
+// player rather than an audio player
+var videoExtensions = []string{".mp4", ".m4v", ".mkv", ".mov", ".webm", ".avi"}
+
+// htmlTemplate is a standalone page: the player stays at the top, clicking
+// a segment seeks to it and the segment being played is highlighted
+var htmlTemplate = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 50rem; margin: 0 auto; padding: 0 1rem 2rem; line-height: 1.5; color: #222; }
+header { position: sticky; top: 0; background: #fff; padding: 1rem 0 0.5rem; border-bottom: 1px solid #ddd; }
+h1 { font-size: 1.4rem; margin: 0 0 0.5rem; }
+h2 { font-size: 1.1rem; margin: 1.5rem 0 0.5rem; }
+audio, video { width: 100%; max-height: 40vh; }
+.segment { margin: 0.25rem 0; padding: 0.1rem 0.4rem; border-radius: 4px; cursor: pointer; }
+.segment:hover { background: #f2f2f2; }
+.segment.current { background: #fff3bf; }
+.time { color: #888; font-variant-numeric: tabular-nums; margin-right: 0.5rem; }
+.source { font-weight: 600; margin-right: 0.25rem; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+{{if .Media}}{{if .Video}}<video id="player" controls preload="metadata" src="{{.Media}}"></video>{{else}}<audio id="player" controls preload="metadata" src="{{.Media}}"></audio>{{end}}{{end}}
+</header>
+<main>
+{{range .Items}}{{if .Chapter}}<h2 data-start="{{.Start}}">{{.Chapter}}</h2>
+{{else}}<p class="segment" data-start="{{.Start}}" data-end="{{.End}}"><span class="time">{{.Time}}</span>{{if .Source}}<span class="source">{{.Source}}:</span>{{end}}{{.Text}}</p>
+{{end}}{{end}}</main>
+<script>
+const player = document.getElementById("player");
+const segments = Array.from(document.querySelectorAll(".segment"));
+for (const el of document.querySelectorAll("[data-start]")) {
+	el.addEventListener("click", () => {
+		if (!player) return;
+		player.currentTime = parseFloat(el.dataset.start);
+		player.play();
+	});
+}
+if (player) {
+	let current = null;
+	player.addEventListener("timeupdate", () => {
+		const t = player.currentTime;
+		const playing = segments.find(el => t >= parseFloat(el.dataset.start) && t < parseFloat(el.dataset.end)) || null;
+		if (playing === current) return;
+		if (current) current.classList.remove("current");
+		if (playing) {
+			playing.classList.add("current");
+			playing.scrollIntoView({block: "nearest", behavior: "smooth"});
+		}
+		current = playing;
+	});
+}
+</script>
+</body>
+</html>
+`))
+
+// htmlItem is a segment or a chapter heading of the HTML export
+type htmlItem struct {
+	Start, End float64 // seconds into the media
+	Time       string
+	Source     string
+	Text       string
+	Chapter    string
+}
+
+// WriteHTML writes segments as a standalone HTML page under title with a
+// player for the media file at media, a path relative to the page. Clicking
+// a segment or chapter heading seeks the player to it. Without media the
+// page only shows the transcript.
+func WriteHTML(w io.Writer, title, media string, segments []transcriber.Segment, chapters []Annotation) error {
+	var items []htmlItem
+	for _, ch := range chapters {
+		items = append(items, htmlItem{Start: ch.Offset.Seconds(), Chapter: ch.Label})
+	}
+	for _, seg := range segments {
+		items = append(items, htmlItem{
+			Start:  seg.StartTime.Seconds(),
+			End:    seg.EndTime.Seconds(),
+			Time:   FormatOffset(seg.StartTime.Truncate(time.Second)),
+			Source: seg.Source,
+			Text:   seg.Text,
+		})
+	}
+	// Chapters go before the segments starting at the same time
+	slices.SortStableFunc(items, func(a, b htmlItem) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+
+	var src string
+	if media != "" {
+		// Escape the path so names with spaces or # still load, and keep a
+		// colon in the name from being read as a URL scheme
+		src = "./" + (&url.URL{Path: media}).EscapedPath()
+	}
+	return htmlTemplate.Execute(w, struct {
+		Title string
+		Media template.URL
+		Video bool
+		Items []htmlItem
+	}{
+		Title: title,
+		Media: template.URL(src),
+		Video: slices.Contains(videoExtensions, strings.ToLower(path.Ext(media))),
+		Items: items,
+	})
+}
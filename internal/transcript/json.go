@@ -0,0 +1,65 @@
+package transcript
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// JSONSegment is a segment as written by WriteJSON
+type JSONSegment struct {
+	Time time.Time `json:"time"`
+	// Start and End are where the segment is in the recorded audio
+	Start    time.Duration `json:"start_ns"`
+	End      time.Duration `json:"end_ns"`
+	Source   string        `json:"source,omitempty"`
+	Language string        `json:"language,omitempty"`
+	Text     string        `json:"text"`
+}
+
+// WriteJSON writes segments as a JSON document with a segments array, for
+// processing transcripts with other tools
+func WriteJSON(w io.Writer, segments []transcriber.Segment) error {
+	doc := struct {
+		Segments []JSONSegment `json:"segments"`
+	}{Segments: make([]JSONSegment, len(segments))}
+	for i, seg := range segments {
+		doc.Segments[i] = JSONSegment{
+			Time:     seg.Timestamp,
+			Start:    seg.StartTime,
+			End:      seg.EndTime,
+			Source:   seg.Source,
+			Language: seg.Language,
+			Text:     seg.Text,
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// ReadJSON reads segments written by WriteJSON
+func ReadJSON(r io.Reader) ([]transcriber.Segment, error) {
+	var doc struct {
+		Segments []JSONSegment `json:"segments"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	segments := make([]transcriber.Segment, len(doc.Segments))
+	for i, s := range doc.Segments {
+		segments[i] = transcriber.Segment{
+			Text:      s.Text,
+			StartTime: s.Start,
+			EndTime:   s.End,
+			Timestamp: s.Time,
+			Source:    s.Source,
+			Language:  s.Language,
+		}
+	}
+	return segments, nil
+}
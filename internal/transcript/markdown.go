@@ -0,0 +1,55 @@
+package transcript
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/exler/rekord/internal/summary"
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// Summary is the reading time estimate and TL;DR written at the top of an
+// export. The zero Summary writes nothing.
+type Summary struct {
+	ReadingTime time.Duration
+	Points      []string
+}
+
+// WriteMarkdown writes segments as a Markdown document under title, with
+// the summary below the title and a heading for each chapter before the
+// first segment starting at or after it
+func WriteMarkdown(w io.Writer, title string, sum Summary, segments []transcriber.Segment, chapters []Annotation, format transcriber.TimeFormat) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s\n", title)
+	if sum.ReadingTime > 0 {
+		fmt.Fprintf(bw, "\n_Reading time: ~%d min_\n", summary.Minutes(sum.ReadingTime))
+	}
+	if len(sum.Points) > 0 {
+		bw.WriteString("\n**TL;DR**\n\n")
+		for _, p := range sum.Points {
+			fmt.Fprintf(bw, "- %s\n", p)
+		}
+	}
+
+	next := 0
+	inList := false
+	for _, seg := range segments {
+		for next < len(chapters) && chapters[next].Offset <= seg.StartTime {
+			fmt.Fprintf(bw, "\n## %s\n", chapters[next].Label)
+			next++
+			inList = false
+		}
+		// Lists need a blank line after a heading
+		if !inList {
+			bw.WriteString("\n")
+			inList = true
+		}
+		fmt.Fprintf(bw, "- **%s** %s\n", format.Format(seg), seg.Label())
+	}
+	for _, ch := range chapters[next:] {
+		fmt.Fprintf(bw, "\n## %s\n", ch.Label)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,274 @@
+package transcript
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 page layout of the PDF export, in points
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 56.0
+
+	// pdfTimeIndent is where segment text starts after the timestamp
+	pdfTimeIndent = 60.0
+)
+
+// pdfFont is one of the standard fonts every PDF reader has
+type pdfFont int
+
+const (
+	pdfRegular pdfFont = iota
+	pdfBold
+)
+
+// pdfRun is a run of text in one font
+type pdfRun struct {
+	text string
+	font pdfFont
+	gray bool
+}
+
+// WritePDF writes doc as a PDF document. It uses the standard Helvetica
+// fonts, so nothing has to be embedded, which limits the text to Western
+// European characters; others are replaced with '?'.
+func WritePDF(w io.Writer, doc Document) error {
+	p := &pdfLayout{}
+	p.newPage()
+
+	// Title page
+	p.y = pdfPageHeight - 260
+	p.paragraph([]pdfRun{{text: doc.Title, font: pdfBold}}, 24, 0, 0)
+	p.y -= 24
+	for _, f := range doc.Metadata {
+		p.paragraph([]pdfRun{{text: f.Label + ": ", font: pdfBold}, {text: f.Value}}, 11, 0, 0)
+	}
+	p.newPage()
+
+	list := func(heading string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		p.heading(heading)
+		for _, item := range items {
+			p.paragraph([]pdfRun{{text: "•  " + item}}, 11, 0, 12)
+		}
+	}
+	list("Summary", doc.Summary)
+	list("Action items", doc.ActionItems)
+
+	p.heading("Transcript")
+	for _, seg := range doc.Segments {
+		runs := []pdfRun{{text: doc.Format.Format(seg), gray: true}}
+		if seg.Source != "" {
+			runs = append(runs, pdfRun{text: seg.Source + ":", font: pdfBold})
+		}
+		runs = append(runs, pdfRun{text: seg.Text})
+		p.paragraph(runs, 10, pdfTimeIndent, pdfTimeIndent)
+	}
+	return p.write(w, doc.Title)
+}
+
+// pdfLayout flows text onto pages top to bottom
+type pdfLayout struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+}
+
+// newPage starts a page with its number in the footer
+func (p *pdfLayout) newPage() {
+	p.page = &bytes.Buffer{}
+	p.pages = append(p.pages, p.page)
+	p.y = pdfPageHeight - pdfMargin
+	if n := len(p.pages); n > 1 {
+		label := fmt.Sprint(n)
+		x := (pdfPageWidth - textWidth(label, pdfRegular, 9)) / 2
+		fmt.Fprintf(p.page, "BT 0.5 g /F1 9 Tf %.2f %.2f Td (%s) Tj ET\n", x, pdfMargin/2, pdfString(label))
+	}
+}
+
+// heading writes a section heading, keeping it off the bottom of a page
+func (p *pdfLayout) heading(text string) {
+	if p.y-60 < pdfMargin {
+		p.newPage()
+	}
+	p.y -= 12
+	p.paragraph([]pdfRun{{text: text, font: pdfBold}}, 15, 0, 0)
+	p.y -= 4
+}
+
+// paragraph writes runs wrapped at the right margin. The first run starts at
+// the left margin and the rest after firstIndent, or on the next line if the
+// first run is longer; continuation lines are indented by indent.
+func (p *pdfLayout) paragraph(runs []pdfRun, size, firstIndent, indent float64) {
+	leading := size * 1.35
+	right := pdfPageWidth - pdfMargin
+
+	var line bytes.Buffer
+	x := pdfMargin
+	lineStart := func() {
+		if p.y-leading < pdfMargin {
+			p.newPage()
+		}
+		p.y -= leading
+		line.Reset()
+	}
+	flush := func() {
+		if line.Len() > 0 {
+			p.page.Write(line.Bytes())
+		}
+	}
+
+	lineStart()
+	for i, run := range runs {
+		if i == 1 && firstIndent > 0 {
+			if x+textWidth(" ", run.font, size) > pdfMargin+firstIndent {
+				flush()
+				lineStart()
+			}
+			x = pdfMargin + firstIndent
+		}
+		for j, word := range strings.Fields(run.text) {
+			// The run after the lead starts at the indent instead
+			if j > 0 || (i > 0 && (i > 1 || firstIndent == 0)) {
+				word = " " + word
+			}
+			width := textWidth(word, run.font, size)
+			if x+width > right && x > pdfMargin+indent {
+				flush()
+				lineStart()
+				x = pdfMargin + indent
+				word = strings.TrimPrefix(word, " ")
+				width = textWidth(word, run.font, size)
+			}
+			gray := "0 g"
+			if run.gray {
+				gray = "0.5 g"
+			}
+			fmt.Fprintf(&line, "BT %s /F%d %.1f Tf %.2f %.2f Td (%s) Tj ET\n", gray, run.font+1, size, x, p.y, pdfString(word))
+			x += width
+		}
+		if i == 0 && firstIndent > 0 {
+			// Separate the lead from what follows on the same line
+			x += textWidth(" ", run.font, size)
+		}
+	}
+	flush()
+}
+
+// write writes the pages as a PDF file
+func (p *pdfLayout) write(w io.Writer, title string) error {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// Objects 1-5 are fixed, followed by a page and its contents per page
+	kids := make([]string, len(p.pages))
+	for i := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (rekord) >>", pdfString(title)))
+	for i, page := range p.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 7+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// winAnsi maps the characters of Windows-1252 outside Latin-1 to their codes
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// encodeWinAnsi converts text to the encoding of the standard fonts
+func encodeWinAnsi(text string) []byte {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			out = append(out, byte(r))
+		case winAnsi[r] != 0:
+			out = append(out, winAnsi[r])
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+// pdfString encodes text as the contents of a PDF string literal
+func pdfString(text string) string {
+	var b strings.Builder
+	for _, c := range encodeWinAnsi(text) {
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// textWidth returns the width of text in points
+func textWidth(text string, font pdfFont, size float64) float64 {
+	widths := &helveticaWidths
+	if font == pdfBold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, c := range encodeWinAnsi(text) {
+		if c >= 0x20 && c < 0x7f {
+			total += widths[c-0x20]
+		} else {
+			// Accented letters are about as wide as their base letters
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// helveticaWidths and helveticaBoldWidths are the advance widths of the
+// printable ASCII characters in thousandths of the font size, from the
+// Adobe font metrics
+var (
+	helveticaWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
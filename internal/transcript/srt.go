@@ -0,0 +1,108 @@
+package transcript
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+const (
+	// maxCueChars and maxCueDuration bound subtitle cues built from word
+	// timings so they stay readable
+	maxCueChars    = 42
+	maxCueDuration = 5 * time.Second
+
+	// chapterCueDuration is how long a chapter title is shown
+	chapterCueDuration = 3 * time.Second
+)
+
+// cue is a single subtitle
+type cue struct {
+	start, end time.Duration
+	text       string
+}
+
+// WriteSRT writes segments as SubRip subtitles. Segments with word timings
+// are split into short cues at word boundaries; others become one cue
+// spanning the segment. Each chapter becomes a short cue with its title.
+func WriteSRT(w io.Writer, segments []transcriber.Segment, chapters []Annotation) error {
+	var cues []cue
+	for _, ch := range chapters {
+		cues = append(cues, cue{ch.Offset, ch.Offset + chapterCueDuration, "[" + ch.Label + "]"})
+	}
+	for _, seg := range segments {
+		prefix := ""
+		if seg.Source != "" {
+			prefix = seg.Source + ": "
+		}
+		if len(seg.Words) == 0 {
+			cues = append(cues, cue{seg.StartTime, seg.EndTime, prefix + seg.Text})
+			continue
+		}
+		for _, c := range wordCues(seg.Words) {
+			c.text = prefix + c.text
+			cues = append(cues, c)
+		}
+	}
+
+	// Sources are transcribed separately, so interleave them by time
+	slices.SortStableFunc(cues, func(a, b cue) int {
+		return cmp.Compare(a.start, b.start)
+	})
+
+	bw := bufio.NewWriter(w)
+	for i, c := range cues {
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n", i+1, srtTime(c.start), srtTime(c.end), c.text)
+	}
+	return bw.Flush()
+}
+
+// wordCues groups words into cues, ending a cue after a sentence or when it
+// would grow too long
+func wordCues(words []transcriber.Word) []cue {
+	var cues []cue
+	var cur cue
+	var text []string
+	flush := func() {
+		if len(text) > 0 {
+			cur.text = strings.Join(text, " ")
+			cues = append(cues, cur)
+			text = nil
+		}
+	}
+
+	for _, word := range words {
+		if len(text) > 0 {
+			length := len(strings.Join(text, " ")) + 1 + len(word.Text)
+			if length > maxCueChars || word.End-cur.start > maxCueDuration {
+				flush()
+			}
+		}
+		if len(text) == 0 {
+			cur.start = word.Start
+		}
+		text = append(text, word.Text)
+		cur.end = word.End
+		if strings.HasSuffix(word.Text, ".") || strings.HasSuffix(word.Text, "?") || strings.HasSuffix(word.Text, "!") {
+			flush()
+		}
+	}
+	flush()
+	return cues
+}
+
+// srtTime formats a duration as an SRT timestamp, hh:mm:ss,mmm
+func srtTime(d time.Duration) string {
+	d = max(d, 0)
+	h := int(d / time.Hour)
+	m := int(d % time.Hour / time.Minute)
+	s := int(d % time.Minute / time.Second)
+	ms := int(d % time.Second / time.Millisecond)
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", h, m, s, ms)
+}
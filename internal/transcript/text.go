@@ -0,0 +1,78 @@
+package transcript
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// headerSeparator separates the header of a saved transcript from its body
+const headerSeparator = "----------------------------------------\n"
+
+// leadingTimestamp matches timestamps commonly found at the start of
+// transcript lines: "[12:30:05]", "[00:01:02.500 --> 00:01:04.000]",
+// "12:30", "00:01:02,500 -" and similar
+var leadingTimestamp = regexp.MustCompile(`^\s*(\[[^\]]*\]|\(?\d{1,2}:\d{2}(:\d{2})?([.,]\d+)?\)?)\s*(-->\s*\d{1,2}:\d{2}(:\d{2})?([.,]\d+)?)?\s*[-–:]?\s*`)
+
+// ReadText reads a transcript, either saved by rekord or arbitrary text with
+// optional timestamps, and returns its spoken text with one line per
+// utterance. The rekord header and line timestamps are removed.
+func ReadText(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return bodyText(string(data)), nil
+}
+
+// bodyText strips the header and per-line timestamps from a transcript
+func bodyText(data string) string {
+	if _, body, ok := strings.Cut(data, headerSeparator); ok {
+		data = body
+	}
+
+	var b strings.Builder
+	for line := range strings.SplitSeq(data, "\n") {
+		line = strings.TrimSpace(leadingTimestamp.ReplaceAllString(line, ""))
+		if line == "" {
+			continue
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Line is one utterance of a transcript with the timestamp it was written
+// with, if any
+type Line struct {
+	Time string
+	Text string
+}
+
+// ReadLines reads a transcript like ReadText, keeping each line's timestamp
+// with the brackets and separators removed
+func ReadLines(r io.Reader) ([]Line, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	body := string(data)
+	if _, after, ok := strings.Cut(body, headerSeparator); ok {
+		body = after
+	}
+
+	var lines []Line
+	for line := range strings.SplitSeq(body, "\n") {
+		stamp := leadingTimestamp.FindString(line)
+		text := strings.TrimSpace(line[len(stamp):])
+		if text == "" {
+			continue
+		}
+		lines = append(lines, Line{
+			Time: strings.Trim(strings.TrimSpace(stamp), "[]()-–: "),
+			Text: text,
+		})
+	}
+	return lines, nil
+}
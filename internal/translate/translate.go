@@ -0,0 +1,168 @@
+// Package translate provides machine translation of transcript segments via
+// a LibreTranslate server (self-hosted or public) or the DeepL API
+package translate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultLibreTranslateURL is a LibreTranslate server running locally
+	DefaultLibreTranslateURL = "http://localhost:5000"
+
+	// DeepLKeyEnv and LibreTranslateKeyEnv name the environment variables
+	// holding the API keys
+	DeepLKeyEnv          = "DEEPL_API_KEY"
+	LibreTranslateKeyEnv = "LIBRETRANSLATE_API_KEY"
+
+	requestTimeout = 15 * time.Second
+)
+
+// Translator translates text from the source language to the target
+// language. An empty source asks the service to detect it.
+type Translator interface {
+	Translate(text, source, target string) (string, error)
+}
+
+// Config holds translator configuration
+type Config struct {
+	// Provider is "libretranslate" or "deepl"
+	Provider string
+	// URL is the LibreTranslate server URL (default DefaultLibreTranslateURL)
+	URL string
+	// APIKey overrides the key read from the environment
+	APIKey string
+}
+
+// New creates the translator selected by cfg
+func New(cfg Config) (Translator, error) {
+	client := &http.Client{Timeout: requestTimeout}
+
+	switch cfg.Provider {
+	case "", "libretranslate":
+		url := cfg.URL
+		if url == "" {
+			url = DefaultLibreTranslateURL
+		}
+		key := cfg.APIKey
+		if key == "" {
+			key = os.Getenv(LibreTranslateKeyEnv)
+		}
+		return &LibreTranslate{url: strings.TrimSuffix(url, "/"), apiKey: key, client: client}, nil
+
+	case "deepl":
+		key := cfg.APIKey
+		if key == "" {
+			key = os.Getenv(DeepLKeyEnv)
+		}
+		if key == "" {
+			return nil, fmt.Errorf("no DeepL API key, set %s", DeepLKeyEnv)
+		}
+		// Free plan keys end in ":fx" and use a separate host
+		url := "https://api.deepl.com/v2/translate"
+		if strings.HasSuffix(key, ":fx") {
+			url = "https://api-free.deepl.com/v2/translate"
+		}
+		return &DeepL{url: url, apiKey: key, client: client}, nil
+	}
+	return nil, fmt.Errorf("unknown translator %q, expected libretranslate or deepl", cfg.Provider)
+}
+
+// LibreTranslate translates via a LibreTranslate server
+type LibreTranslate struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// Translate implements Translator
+func (t *LibreTranslate) Translate(text, source, target string) (string, error) {
+	if source == "" {
+		source = "auto"
+	}
+	req := map[string]string{
+		"q":      text,
+		"source": source,
+		"target": target,
+		"format": "text",
+	}
+	if t.apiKey != "" {
+		req["api_key"] = t.apiKey
+	}
+
+	var resp struct {
+		TranslatedText string `json:"translatedText"`
+	}
+	if err := postJSON(t.client, t.url+"/translate", nil, req, &resp); err != nil {
+		return "", fmt.Errorf("LibreTranslate: %w", err)
+	}
+	return resp.TranslatedText, nil
+}
+
+// DeepL translates via the DeepL API
+type DeepL struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// Translate implements Translator
+func (t *DeepL) Translate(text, source, target string) (string, error) {
+	req := map[string]any{
+		"text":        []string{text},
+		"target_lang": strings.ToUpper(target),
+	}
+	if source != "" {
+		req["source_lang"] = strings.ToUpper(source)
+	}
+
+	var resp struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	header := map[string]string{"Authorization": "DeepL-Auth-Key " + t.apiKey}
+	if err := postJSON(t.client, t.url, header, req, &resp); err != nil {
+		return "", fmt.Errorf("DeepL: %w", err)
+	}
+	if len(resp.Translations) == 0 {
+		return "", errors.New("DeepL: empty response")
+	}
+	return resp.Translations[0].Text, nil
+}
+
+// postJSON posts body as JSON and decodes the JSON response into v
+func postJSON(client *http.Client, url string, header map[string]string, body, v any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, val := range header {
+		req.Header.Set(k, val)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
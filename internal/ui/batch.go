@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"charm.land/bubbles/v2/progress"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// BatchFileMsg reports the progress of one file of a batch. Progress is in
+// [0, 1] while the file is transcribed. Done marks the end, successful
+// unless Err is set, with Output the transcript written or Skipped set if
+// there already was one.
+type BatchFileMsg struct {
+	Index    int
+	Progress float64
+	Done     bool
+	Skipped  bool
+	Output   string
+	Err      error
+}
+
+// batchFile is the state of one file of a batch
+type batchFile struct {
+	path     string
+	started  bool
+	progress float64
+	done     bool
+	skipped  bool
+	err      error
+}
+
+// BatchModel shows the progress of transcribing a batch of files
+type BatchModel struct {
+	files    []batchFile
+	bar      progress.Model
+	width    int
+	onCancel func()
+}
+
+// NewBatch creates the progress view for transcribing files. onCancel is
+// called when the user quits before the batch is done.
+func NewBatch(files []string, onCancel func()) BatchModel {
+	m := BatchModel{
+		bar:      progress.New(progress.WithDefaultBlend(), progress.WithWidth(40)),
+		width:    80,
+		onCancel: onCancel,
+	}
+	for _, f := range files {
+		m.files = append(m.files, batchFile{path: f})
+	}
+	return m
+}
+
+// Init implements tea.Model
+func (m BatchModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m BatchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.bar.SetWidth(min(max(msg.Width-20, 10), 60))
+
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			if m.onCancel != nil {
+				m.onCancel()
+			}
+			return m, tea.Quit
+		}
+
+	case BatchFileMsg:
+		f := &m.files[msg.Index]
+		f.started = true
+		f.progress = msg.Progress
+		if msg.Done {
+			f.done, f.skipped, f.err = true, msg.Skipped, msg.Err
+			f.progress = 1
+		}
+		if m.finished() {
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// finished reports whether every file is done
+func (m BatchModel) finished() bool {
+	for _, f := range m.files {
+		if !f.done {
+			return false
+		}
+	}
+	return true
+}
+
+// View implements tea.Model. Files being transcribed get a progress bar;
+// only failures are listed once done, to keep long batches readable.
+func (m BatchModel) View() tea.View {
+	var b strings.Builder
+	var done, failed, skipped int
+	var total float64
+	for _, f := range m.files {
+		total += f.progress
+		switch {
+		case f.err != nil:
+			failed++
+		case f.skipped:
+			skipped++
+		}
+		if f.done {
+			done++
+		}
+	}
+
+	b.WriteString(titleStyle.Render(" REKORD - Batch Transcription "))
+	b.WriteString("\n\n")
+	fmt.Fprintf(&b, "%s %d/%d files", m.bar.ViewAs(total/float64(max(len(m.files), 1))), done, len(m.files))
+	if skipped > 0 {
+		fmt.Fprintf(&b, ", %d already transcribed", skipped)
+	}
+	if failed > 0 {
+		fmt.Fprintf(&b, ", %s", setupMissingStyle.Render(fmt.Sprintf("%d failed", failed)))
+	}
+	b.WriteString("\n\n")
+
+	for _, f := range m.files {
+		name := truncate(filepath.Base(f.path), max(m.width-m.bar.Width()-6, 10))
+		switch {
+		case f.err != nil:
+			b.WriteString(setupMissingStyle.Render("✗ "+name) + "  " + truncate(f.err.Error(), m.width-lipgloss.Width(name)-6) + "\n")
+		case f.started && !f.done:
+			fmt.Fprintf(&b, "  %s  %s\n", m.bar.ViewAs(f.progress), name)
+		}
+	}
+
+	if !m.finished() {
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render("q: cancel"))
+	}
+	b.WriteString("\n")
+	return tea.NewView(b.String())
+}
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/exler/rekord/internal/transcriber"
+	"github.com/exler/rekord/internal/transcript"
+)
+
+var bookmarkStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#FF6B6B")).
+	Bold(true)
+
+// bookmark is a bookmark set during the recording and the wall-clock time
+// it was set at
+type bookmark struct {
+	transcript.Annotation
+	at time.Time
+}
+
+// SetBookmarkCallback sets the callback that records a bookmark at the
+// current point of the recording
+func (m *Model) SetBookmarkCallback(onBookmark func(string) (transcript.Annotation, error)) {
+	m.onBookmark = onBookmark
+}
+
+// openBookmark opens the bookmark name input
+func (m *Model) openBookmark() tea.Cmd {
+	m.bookmarkInput.Reset()
+	return m.bookmarkInput.Focus()
+}
+
+// updateBookmark handles key presses while the bookmark name input is open
+func (m Model) updateBookmark(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.bookmarkInput.Blur()
+		return m, nil
+
+	case "enter":
+		m.bookmarkInput.Blur()
+		label := strings.TrimSpace(m.bookmarkInput.Value())
+		if label == "" {
+			label = fmt.Sprintf("Bookmark %d", len(m.bookmarks)+1)
+		}
+		mark, err := m.onBookmark(label)
+		if err != nil {
+			m.error = err.Error()
+			return m, nil
+		}
+		m.error = ""
+		m.bookmarks = append(m.bookmarks, bookmark{Annotation: mark, at: time.Now()})
+		if m.tab == tabTranscript && !m.reviewing {
+			m.refreshViewport()
+			if !m.followPaused {
+				m.viewport.GotoBottom()
+			}
+		}
+		return m.showNotice(fmt.Sprintf("Bookmarked %q at %s", label, transcript.FormatOffset(mark.Offset)))
+	}
+
+	var cmd tea.Cmd
+	m.bookmarkInput, cmd = m.bookmarkInput.Update(msg)
+	return m, cmd
+}
+
+// bookmarksUntil returns the bookmarks from index from on that lie at or
+// before seg, which are shown above it
+func (m Model) bookmarksUntil(from int, seg transcriber.Segment) []bookmark {
+	to := from
+	for to < len(m.bookmarks) && m.bookmarks[to].Offset <= seg.StartTime {
+		to++
+	}
+	return m.bookmarks[from:to]
+}
+
+// segmentLine returns the transcript line segment idx is rendered on,
+// counting the bookmark lines above it
+func (m Model) segmentLine(idx int) int {
+	return idx + len(m.bookmarksUntil(0, m.segments[idx]))
+}
+
+// renderBookmark renders a bookmark line, timed like the segments
+func (m Model) renderBookmark(mark bookmark) string {
+	timestamp := m.timeFormat.Format(transcriber.Segment{StartTime: mark.Offset, Timestamp: mark.at})
+	return timestampStyle.Render(timestamp) + " " + bookmarkStyle.Render("★ "+mark.Label)
+}
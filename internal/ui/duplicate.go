@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// DuplicateAction resolves a possible duplicate run
+type DuplicateAction string
+
+const (
+	// DuplicateMerge adds what only the other transcript has to the saved
+	// one and discards the other
+	DuplicateMerge DuplicateAction = "merge"
+	// DuplicateDiscard discards the saved transcript
+	DuplicateDiscard DuplicateAction = "discard"
+	// DuplicateDiscardOther discards the other transcript
+	DuplicateDiscardOther DuplicateAction = "discard-other"
+	// DuplicateKeep keeps both transcripts
+	DuplicateKeep DuplicateAction = "keep"
+)
+
+// DuplicateMsg is sent when the saved transcript at Path looks like a
+// recording of the same meeting as the one at Other
+type DuplicateMsg struct {
+	Path       string
+	Other      string
+	Similarity float64
+}
+
+// DuplicateResolvedMsg is sent when a possible duplicate was resolved
+// outside the UI, e.g. through rekord ctl
+type DuplicateResolvedMsg struct {
+	Text string
+}
+
+// SetDuplicateCallback sets the callback resolving a possible duplicate run,
+// which returns a notice describing the outcome
+func (m *Model) SetDuplicateCallback(onDuplicate func(DuplicateAction) (string, error)) {
+	m.onDuplicate = onDuplicate
+}
+
+// updateDuplicate handles key presses while a possible duplicate asks to be
+// resolved
+func (m Model) updateDuplicate(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	var action DuplicateAction
+	switch msg.String() {
+	case "m":
+		action = DuplicateMerge
+	case "d":
+		action = DuplicateDiscard
+	case "x":
+		action = DuplicateDiscardOther
+	case "esc":
+		action = DuplicateKeep
+	default:
+		return m, nil
+	}
+
+	m.duplicate = nil
+	if m.onDuplicate == nil {
+		return m, nil
+	}
+	notice, err := m.onDuplicate(action)
+	if err != nil {
+		m.error = err.Error()
+		return m, nil
+	}
+	return m.showNotice(notice)
+}
+
+// renderDuplicate renders the question shown in place of the help while a
+// possible duplicate waits to be resolved
+func (m Model) renderDuplicate() string {
+	return truncate(fmt.Sprintf("%.0f%% similar to %s: m merge it in · d discard this one · x discard it · esc keep both",
+		m.duplicate.Similarity*100, filepath.Base(m.duplicate.Other)), m.width)
+}
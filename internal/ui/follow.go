@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"fmt"
+)
+
+// resumeFollow scrolls to the newest segment and keeps following new ones
+func (m *Model) resumeFollow() {
+	m.followPaused = false
+	m.unseen = 0
+	m.viewport.GotoBottom()
+}
+
+// trackScroll pauses following when the user scrolls up in the transcript
+// and resumes it once they scroll back to the bottom
+func (m *Model) trackScroll(before int) {
+	if m.tab != tabTranscript || m.reviewing || m.naming {
+		return
+	}
+	switch after := m.viewport.YOffset(); {
+	case after < before:
+		m.followPaused = true
+	case after > before && m.viewport.AtBottom():
+		m.resumeFollow()
+	}
+}
+
+// renderFollow renders the paused follow indicator with the number of
+// segments that arrived since
+func (m Model) renderFollow() string {
+	switch {
+	case !m.followPaused:
+		return ""
+	case m.unseen == 1:
+		return "↓ 1 new segment (f to follow)"
+	case m.unseen > 1:
+		return fmt.Sprintf("↓ %d new segments (f to follow)", m.unseen)
+	}
+	return "Follow paused (f to resume)"
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// karaokeInterval is how often the highlighted word advances
+const karaokeInterval = 100 * time.Millisecond
+
+var (
+	karaokeWordStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#1A1A2E")).
+				Background(lipgloss.Color("#4ECDC4")).
+				Bold(true)
+
+	karaokeUpcomingStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#7F8C8D"))
+)
+
+// karaokeTickMsg advances the karaoke highlight. Ticks for an earlier
+// segment are ignored.
+type karaokeTickMsg struct {
+	gen int
+}
+
+// startKaraoke replays the word timings of the newest segment from now on
+func (m *Model) startKaraoke() tea.Cmd {
+	if !m.karaoke || len(m.segments) == 0 || len(m.segments[len(m.segments)-1].Words) == 0 {
+		return nil
+	}
+	m.karaokeGen++
+	m.karaokeStart = time.Now()
+	return m.tickKaraoke()
+}
+
+// tickKaraoke schedules the next karaoke highlight update
+func (m Model) tickKaraoke() tea.Cmd {
+	gen := m.karaokeGen
+	return tea.Tick(karaokeInterval, func(time.Time) tea.Msg {
+		return karaokeTickMsg{gen: gen}
+	})
+}
+
+// karaokePosition returns the playback position within the newest segment,
+// and false once it has been played through
+func (m Model) karaokePosition() (time.Duration, bool) {
+	if !m.karaoke || m.karaokeStart.IsZero() || len(m.segments) == 0 {
+		return 0, false
+	}
+	words := m.segments[len(m.segments)-1].Words
+	if len(words) == 0 {
+		return 0, false
+	}
+	pos := words[0].Start + time.Since(m.karaokeStart)
+	return pos, pos <= words[len(words)-1].End
+}
+
+// renderKaraoke renders the words of seg if it is the segment being played,
+// highlighting the word spoken at the playback position and dimming those
+// still to come
+func (m Model) renderKaraoke(seg transcriber.Segment) (string, bool) {
+	pos, playing := m.karaokePosition()
+	if !playing {
+		return "", false
+	}
+	// Segments of one chunk share a timestamp, so compare their timing too
+	newest := m.segments[len(m.segments)-1]
+	if !seg.Timestamp.Equal(newest.Timestamp) || seg.Source != newest.Source || seg.StartTime != newest.StartTime {
+		return "", false
+	}
+
+	words := seg.Words
+	parts := make([]string, len(words))
+	for i, w := range words {
+		switch {
+		case w.Start > pos:
+			parts[i] = karaokeUpcomingStyle.Render(w.Text)
+		case i+1 == len(words) || words[i+1].Start > pos:
+			parts[i] = karaokeWordStyle.Render(w.Text)
+		default:
+			parts[i] = w.Text
+		}
+	}
+	return strings.Join(parts, " "), true
+}
@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+const (
+	// narrowWidth is the terminal width below which the header is condensed
+	narrowWidth = 80
+	// shortHeight is the terminal height below which only the status line,
+	// transcript and a single help line are shown
+	shortHeight = 15
+	// shortChromeHeight is the number of lines around the viewport in the
+	// short layout: status line, viewport border and help line
+	shortChromeHeight = 4
+	// inlineLines is the number of transcript lines the inline UI shows
+	inlineLines = 3
+)
+
+// SetInline renders the UI below the shell prompt instead of on the
+// alternate screen, as a status line, the last lines of the transcript and a
+// help line, so it fits a small tmux or screen pane
+func (m *Model) SetInline() {
+	m.inline = true
+	m.viewport.Style = transcriptStyle.UnsetPadding()
+}
+
+// narrow reports whether the terminal is too narrow for the full header
+func (m Model) narrow() bool {
+	return m.width < narrowWidth
+}
+
+// short reports whether the terminal is too short for the full layout
+func (m Model) short() bool {
+	return m.height < shortHeight
+}
+
+// transcriptWidth returns the viewport width, leaving room for its border
+// unless inline
+func (m Model) transcriptWidth() int {
+	if m.inline {
+		return m.width
+	}
+	return m.width - 4
+}
+
+// meterWidth returns the audio meter width for the current terminal width
+func (m Model) meterWidth() int {
+	if m.narrow() {
+		return min(max(m.width/8, 5), barWidth)
+	}
+	return barWidth
+}
+
+// renderStatus renders the recording status line
+func (m Model) renderStatus() string {
+	// Segments can still arrive after stopping while the queue drains
+	follow := m.renderFollow()
+	if !m.isRecording {
+		stopped := stoppedStyle.Render("○ STOPPED - Press 's' to start recording")
+		if m.narrow() {
+			stopped = stoppedStyle.Render("○ STOPPED (s to start)")
+		}
+		if follow != "" {
+			stopped += " " + noticeStyle.Render(follow)
+		}
+		// Remaining audio is still transcribed after stopping
+		if progress := m.renderTranscribing(); m.transcribing > 0 {
+			stopped += " " + statusStyle.Render(progress)
+		}
+		return stopped
+	}
+
+	duration := time.Since(m.startTime).Round(time.Second)
+	var status string
+	if m.narrow() {
+		status = fmt.Sprintf("%s %s", duration, m.renderAudioLevel())
+	} else {
+		status = fmt.Sprintf("%s Recording... %s | Audio: %s",
+			m.spinner.View(),
+			duration.String(),
+			m.renderAudioLevel(),
+		)
+	}
+	if progress := m.renderTranscribing(); progress != "" {
+		status += " | " + progress
+	}
+	if follow != "" {
+		status += " | " + follow
+	}
+	rec := recordingStyle.Render("● REC ") + statusStyle.Render(status)
+	// A dead source records nothing, which must not go unnoticed
+	if len(m.lostSources) > 0 {
+		rec += clipStyle.Render("⚠ No audio from " + strings.Join(m.lostSources, ", ") + ", reconnecting")
+	}
+	return rec
+}
+
+// renderDeviceInfo renders the device, model and latency line. Narrow
+// terminals drop the device names, which are the longest part.
+func (m Model) renderDeviceInfo() string {
+	parts := []string{fmt.Sprintf("Device: %s", m.deviceName), fmt.Sprintf("Model: %s", m.modelPath)}
+	if m.narrow() {
+		parts = parts[1:]
+	}
+	if latency := m.renderLatency(); latency != "" {
+		parts = append(parts, latency)
+	}
+	if lag := m.renderLag(); lag != "" {
+		parts = append(parts, lag)
+	}
+	if talk := m.renderTalkTime(); talk != "" {
+		parts = append(parts, talk)
+	}
+	return truncate(strings.Join(parts, " | "), m.width)
+}
+
+// shortView renders the layout for very short terminals and the inline UI.
+// Errors and notices take the place of the status line.
+func (m Model) shortView() tea.View {
+	var b strings.Builder
+
+	switch {
+	case m.error != "":
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#E74C3C")).Bold(true)
+		b.WriteString(errorStyle.Render(truncate("Error: "+m.error, m.width)))
+	case m.notice != "":
+		b.WriteString(noticeStyle.Render(truncate(m.notice, m.width)))
+	default:
+		b.WriteString(m.renderStatus())
+	}
+	b.WriteString("\n")
+
+	if m.inline {
+		b.WriteString(m.viewport.View())
+	} else {
+		b.WriteString(borderStyle.Render(m.viewport.View()))
+	}
+	b.WriteString("\n")
+
+	switch {
+	case m.duplicate != nil:
+		b.WriteString(noticeStyle.Render(m.renderDuplicate()))
+	case m.gotoInput.Focused():
+		b.WriteString(m.gotoInput.View())
+	case m.promptInput.Focused():
+		b.WriteString(m.promptInput.View())
+	case m.bookmarkInput.Focused():
+		b.WriteString(m.bookmarkInput.View())
+	case m.speakerInput.Focused():
+		b.WriteString(m.speakerInput.View())
+	default:
+		b.WriteString(m.help.View(m.keys))
+	}
+
+	v := tea.NewView(b.String())
+	v.AltScreen = !m.inline
+	return v
+}
+
+// truncate shortens s to at most width cells, marking the cut with an
+// ellipsis
+func truncate(s string, width int) string {
+	if width <= 0 || lipgloss.Width(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
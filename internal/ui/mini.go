@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// miniView renders the mini view, a single line with the recording state
+// and the latest words for keeping rekord in a corner of the screen
+func (m Model) miniView() tea.View {
+	var state string
+	if m.isRecording {
+		state = recordingStyle.Render("● REC ") + statusStyle.Render(formatClock(time.Since(m.startTime)))
+	} else {
+		state = stoppedStyle.Render("○ STOPPED")
+	}
+	if len(m.lostSources) > 0 {
+		state += " " + clipStyle.Render("⚠ no audio")
+	}
+
+	var rest string
+	switch {
+	case m.error != "":
+		rest = lipgloss.NewStyle().Foreground(lipgloss.Color("#E74C3C")).Render(truncate("Error: "+m.error, m.width-lipgloss.Width(state)-3))
+	case len(m.segments) > 0:
+		text := strings.TrimSpace(m.segments[len(m.segments)-1].Text)
+		rest = "last: " + truncateStart(text, m.width-lipgloss.Width(state)-9)
+	default:
+		rest = "M to expand"
+	}
+
+	v := tea.NewView(state + helpStyle.UnsetPadding().Render(" | ") + rest)
+	v.AltScreen = !m.inline
+	return v
+}
+
+// updateMini handles key presses in the mini view. Only M, quit and
+// start/stop work there: the other keys open inputs or switch views that the
+// single line cannot show.
+func (m Model) updateMini(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Mini):
+		m.mini = false
+	case key.Matches(msg, m.keys.Quit):
+		return m.quit()
+	case key.Matches(msg, m.keys.Start) && !m.isRecording && m.setup.pending():
+		m.error = "whisper is not set up yet, press M and then i to install it"
+	case key.Matches(msg, m.keys.Start) && !m.isRecording:
+		return m.startRecording()
+	case key.Matches(msg, m.keys.Stop) && m.isRecording:
+		return m.stopRecording()
+	}
+	return m, nil
+}
+
+// formatClock formats d as m:ss, or h:mm:ss from an hour on
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, mins, s := int(d/time.Hour), int(d%time.Hour/time.Minute), int(d%time.Minute/time.Second)
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, mins, s)
+	}
+	return fmt.Sprintf("%d:%02d", mins, s)
+}
+
+// truncateStart shortens s to at most width cells by cutting its start,
+// marking the cut with an ellipsis, so the latest words stay visible
+func truncateStart(s string, width int) string {
+	if width <= 0 || lipgloss.Width(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[1:]
+	}
+	return "…" + string(runes)
+}
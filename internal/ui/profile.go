@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// ProfileChoice is a recording profile offered by the profile picker
+type ProfileChoice struct {
+	Name        string
+	Description string
+}
+
+// ProfilePicker lets the user choose the recording profile before
+// recording starts
+type ProfilePicker struct {
+	choices []ProfileChoice
+	cursor  int
+	chosen  string
+	width   int
+}
+
+// NewProfilePicker creates a picker offering choices
+func NewProfilePicker(choices []ProfileChoice) ProfilePicker {
+	return ProfilePicker{choices: choices, width: 80}
+}
+
+// Chosen returns the name of the chosen profile, or "" if the picker was
+// cancelled
+func (m ProfilePicker) Chosen() string {
+	return m.chosen
+}
+
+// Init implements tea.Model
+func (m ProfilePicker) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m ProfilePicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "up", "k":
+			m.cursor = max(m.cursor-1, 0)
+		case "down", "j":
+			m.cursor = min(m.cursor+1, len(m.choices)-1)
+		case "enter":
+			if len(m.choices) > 0 {
+				m.chosen = m.choices[m.cursor].Name
+			}
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// View implements tea.Model
+func (m ProfilePicker) View() tea.View {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(" REKORD - Choose a Profile "))
+	b.WriteString("\n\n")
+
+	for i, c := range m.choices {
+		line := c.Name
+		if c.Description != "" {
+			line += "  " + placeholderStyle.Render(truncate(c.Description, max(m.width-len(c.Name)-6, 10)))
+		}
+		if i == m.cursor {
+			line = reviewSelectedStyle.Render("›") + " " + line
+		} else {
+			line = "  " + line
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("↑/↓: select • enter: record with this profile • q: cancel"))
+	b.WriteString("\n")
+	return tea.NewView(b.String())
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+// rtfWindow is how many recent chunks the real-time factor is averaged over
+const rtfWindow = 10
+
+// chunkTiming is the audio length and processing time of a transcribed chunk
+type chunkTiming struct {
+	audio time.Duration
+	took  time.Duration
+}
+
+// addChunkTiming records a transcribed chunk for the real-time factor
+func (m *Model) addChunkTiming(audio, took time.Duration) {
+	if audio <= 0 {
+		return
+	}
+	m.recentChunks = append(m.recentChunks, chunkTiming{audio: audio, took: took})
+	if len(m.recentChunks) > rtfWindow {
+		m.recentChunks = m.recentChunks[len(m.recentChunks)-rtfWindow:]
+	}
+}
+
+// realTimeFactor returns processing time divided by audio time over the
+// recent chunks, 0 if there are none. Above 1 transcription falls behind.
+func (m Model) realTimeFactor() float64 {
+	var audio, took time.Duration
+	for _, c := range m.recentChunks {
+		audio += c.audio
+		took += c.took
+	}
+	if audio == 0 {
+		return 0
+	}
+	return float64(took) / float64(audio)
+}
+
+// renderTranscribing renders the chunk being transcribed, how long it has
+// taken so far and the rolling real-time factor. Narrow terminals only get
+// the elapsed time.
+func (m Model) renderTranscribing() string {
+	var progress string
+	if m.transcribing > 0 {
+		elapsed := time.Since(m.transcribingSince).Round(time.Second)
+		if m.narrow() {
+			return fmt.Sprintf("⟳ %s", elapsed)
+		}
+		progress = fmt.Sprintf("Transcribing %s chunk: %s", m.transcribing.Round(time.Second), elapsed)
+		if more := m.transcribingChunks - 1; more > 0 {
+			progress += fmt.Sprintf(" (+%d more)", more)
+		}
+	}
+	if m.narrow() {
+		return progress
+	}
+
+	rtf := m.realTimeFactor()
+	switch {
+	case rtf == 0:
+		return progress
+	case progress == "":
+		return fmt.Sprintf("RTF %.2f", rtf)
+	}
+	return fmt.Sprintf("%s (RTF %.2f)", progress, rtf)
+}
@@ -0,0 +1,46 @@
+package ui
+
+import (
+	tea "charm.land/bubbletea/v2"
+)
+
+// SetPromptCallback sets the callback used to change whisper's initial
+// prompt, and the prompt currently in use
+func (m *Model) SetPromptCallback(prompt string, onPrompt func(string) error) {
+	m.prompt = prompt
+	m.onPrompt = onPrompt
+}
+
+// openPrompt opens the prompt editor prefilled with the current prompt
+func (m *Model) openPrompt() tea.Cmd {
+	m.promptInput.SetValue(m.prompt)
+	m.promptInput.CursorEnd()
+	return m.promptInput.Focus()
+}
+
+// updatePrompt handles key presses while the prompt editor is open
+func (m Model) updatePrompt(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.promptInput.Blur()
+		return m, nil
+
+	case "enter":
+		m.promptInput.Blur()
+		if m.onPrompt == nil {
+			return m, nil
+		}
+		prompt := m.promptInput.Value()
+		if err := m.onPrompt(prompt); err != nil {
+			m.error = err.Error()
+			return m, nil
+		}
+		m.error = ""
+		m.prompt = prompt
+		return m.showNotice("Prompt updated, applies from the next chunk")
+	}
+
+	var cmd tea.Cmd
+	m.promptInput, cmd = m.promptInput.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+var (
+	reviewSelectedStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#1A1A2E")).
+				Background(lipgloss.Color("#4ECDC4"))
+
+	reviewReasonStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#F1C40F"))
+
+	removedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#95A5A6")).
+			Strikethrough(true)
+)
+
+// FilteredSegment records a segment changed by post-processing. Text is the
+// text that was kept, empty if the segment was dropped entirely.
+type FilteredSegment struct {
+	Original transcriber.Segment
+	Text     string
+	Reason   string
+}
+
+// Dropped reports whether the segment was removed from the transcript
+func (f FilteredSegment) Dropped() bool {
+	return f.Text == ""
+}
+
+// FilteredMsg is sent when post-processing drops or modifies a segment
+type FilteredMsg struct {
+	Filtered FilteredSegment
+}
+
+// SetRestoreCallback sets the callback used to restore a filtered segment
+func (m *Model) SetRestoreCallback(onRestore func(FilteredSegment) error) {
+	m.onRestore = onRestore
+}
+
+// updateReview handles key presses while the review view is open
+func (m Model) updateReview(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "r", "q":
+		m.reviewing = false
+		m.viewport.SetContent(m.renderTranscript())
+		m.resumeFollow()
+		return m, nil
+
+	case "up", "k":
+		m.reviewCursor = max(m.reviewCursor-1, 0)
+
+	case "down", "j":
+		m.reviewCursor = min(m.reviewCursor+1, max(len(m.filtered)-1, 0))
+
+	case "enter", "u":
+		if len(m.filtered) == 0 {
+			return m, nil
+		}
+		f := m.filtered[m.reviewCursor]
+		if m.onRestore != nil {
+			if err := m.onRestore(f); err != nil {
+				m.error = err.Error()
+				return m, nil
+			}
+		}
+		m.restore(f)
+		m.filtered = slices.Delete(m.filtered, m.reviewCursor, m.reviewCursor+1)
+		m.reviewCursor = min(m.reviewCursor, max(len(m.filtered)-1, 0))
+	}
+
+	m.viewport.SetContent(m.renderReview())
+	m.viewport.EnsureVisible(m.reviewCursor, 0, 0)
+	return m, nil
+}
+
+// restore puts the original text of a filtered segment back into the
+// transcript
+func (m *Model) restore(f FilteredSegment) {
+	orig := f.Original
+	if f.Dropped() {
+		idx, _ := slices.BinarySearchFunc(m.segments, orig, func(a, b transcriber.Segment) int {
+			return a.Timestamp.Compare(b.Timestamp)
+		})
+		m.segments = slices.Insert(m.segments, idx, orig)
+		return
+	}
+
+	for i := range m.segments {
+		if m.segments[i].Timestamp.Equal(orig.Timestamp) && m.segments[i].Source == orig.Source {
+			m.segments[i].Text = orig.Text
+			return
+		}
+	}
+}
+
+// renderReview renders the list of filtered segments
+func (m Model) renderReview() string {
+	if len(m.filtered) == 0 {
+		return lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#7F8C8D")).
+			Italic(true).
+			Render("Nothing was filtered. Press r or esc to return to the transcript.")
+	}
+
+	var b strings.Builder
+	for i, f := range m.filtered {
+		timestamp := m.timeFormat.Format(f.Original)
+		var change string
+		if f.Dropped() {
+			change = removedStyle.Render(f.Original.Label())
+		} else {
+			change = fmt.Sprintf("%s → %s", removedStyle.Render(f.Original.Text), f.Text)
+		}
+
+		line := fmt.Sprintf("%s %s %s", timestamp, reviewReasonStyle.Render("["+f.Reason+"]"), change)
+		if i == m.reviewCursor {
+			line = reviewSelectedStyle.Render("›") + " " + line
+		} else {
+			line = "  " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
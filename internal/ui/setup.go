@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// Setup lists the parts of a local whisper setup that are missing
+type Setup struct {
+	// Whisper is set when no whisper.cpp executable was found
+	Whisper bool
+	// Model is the path of the missing model file, empty if it exists
+	Model string
+}
+
+// pending reports whether anything is still missing
+func (s Setup) pending() bool {
+	return s.Whisper || s.Model != ""
+}
+
+// SetupTask identifies one part of the setup
+type SetupTask int
+
+const (
+	SetupWhisper SetupTask = iota
+	SetupModel
+	setupTaskCount
+)
+
+// SetupProgressMsg reports progress of a setup task. Done marks the end of
+// the task, successful unless Err is set.
+type SetupProgressMsg struct {
+	Task   SetupTask
+	Status string
+	Done   bool
+	Err    error
+}
+
+var (
+	setupTitleStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#F7DC6F")).
+			Bold(true)
+
+	setupMissingStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#E74C3C"))
+
+	setupDoneStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#2ECC71"))
+)
+
+// SetSetup shows the setup wizard for the missing parts of the whisper
+// setup. onInstall starts installing them in the background and reports
+// back with SetupProgressMsg.
+func (m *Model) SetSetup(setup Setup, onInstall func() error) {
+	m.setup = setup
+	m.onInstall = onInstall
+}
+
+// installSetup starts installing the missing parts
+func (m Model) installSetup() (tea.Model, tea.Cmd) {
+	if m.installing > 0 || m.onInstall == nil {
+		return m, nil
+	}
+	if err := m.onInstall(); err != nil {
+		m.error = err.Error()
+		return m, nil
+	}
+	m.error = ""
+	if m.setup.Whisper {
+		m.installing++
+	}
+	if m.setup.Model != "" {
+		m.installing++
+	}
+	m.setupStatus = [setupTaskCount]string{}
+	m.refreshViewport()
+	return m, nil
+}
+
+// updateSetup records the progress of a setup task
+func (m Model) updateSetup(msg SetupProgressMsg) (tea.Model, tea.Cmd) {
+	m.setupStatus[msg.Task] = msg.Status
+	if !msg.Done {
+		m.refreshViewport()
+		return m, nil
+	}
+
+	m.installing--
+	switch {
+	case msg.Err != nil:
+		m.setupStatus[msg.Task] = "failed: " + msg.Err.Error()
+	case msg.Task == SetupWhisper:
+		m.setup.Whisper = false
+	case msg.Task == SetupModel:
+		m.setup.Model = ""
+	}
+	m.refreshViewport()
+	if m.setup.pending() {
+		return m, nil
+	}
+	return m.showNotice("Setup complete, press s to start recording")
+}
+
+// renderSetup renders the setup wizard shown in place of the empty transcript
+func (m Model) renderSetup() string {
+	var b strings.Builder
+	b.WriteString(setupTitleStyle.Render("Setup required before recording"))
+	b.WriteString("\n\n")
+
+	line := func(task SetupTask, name string, missing bool, problem string) {
+		name = fmt.Sprintf("%-11s", name)
+		status := m.setupStatus[task]
+		if missing {
+			b.WriteString(setupMissingStyle.Render("  ✗ " + name))
+			if status == "" {
+				status = problem
+			}
+		} else {
+			b.WriteString(setupDoneStyle.Render("  ✓ " + name))
+			if status == "" {
+				status = "ready"
+			}
+		}
+		fmt.Fprintf(&b, "  %s\n", status)
+	}
+	line(SetupWhisper, "whisper.cpp", m.setup.Whisper, "not found")
+	line(SetupModel, "model", m.setup.Model != "", m.setup.Model+" not found")
+
+	b.WriteString("\n")
+	if m.installing > 0 {
+		b.WriteString(placeholderStyle.Render("Installing, this can take a few minutes..."))
+	} else {
+		b.WriteString(placeholderStyle.Render("Press i to download whisper.cpp (or build it from source if there is no prebuilt executable for this system)\nand the model, or install them as described in the README and restart rekord."))
+	}
+	return b.String()
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// SetRenameSpeakerCallback sets the callback used to give the speaker shown
+// as from the name to in the transcript and its exports
+func (m *Model) SetRenameSpeakerCallback(onRename func(from, to string) error) {
+	m.onRenameSpeaker = onRename
+}
+
+// speakers returns the speakers of the transcript in the order they first
+// spoke, with how many segments each has
+func (m Model) speakers() ([]string, map[string]int) {
+	var names []string
+	counts := make(map[string]int)
+	for _, seg := range m.segments {
+		if seg.Source == "" {
+			continue
+		}
+		if counts[seg.Source] == 0 {
+			names = append(names, seg.Source)
+		}
+		counts[seg.Source]++
+	}
+	return names, counts
+}
+
+// openSpeakers opens the list of speakers to name
+func (m Model) openSpeakers() (tea.Model, tea.Cmd) {
+	if names, _ := m.speakers(); len(names) == 0 {
+		m.error = "no speakers to name: the transcript has no source labels"
+		return m, nil
+	}
+	m.naming = true
+	m.speakerCursor = 0
+	m.viewport.SetContent(m.renderSpeakers())
+	m.viewport.GotoTop()
+	return m, nil
+}
+
+// updateSpeakers handles key presses while the speaker list is open
+func (m Model) updateSpeakers(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	names, _ := m.speakers()
+	switch msg.String() {
+	case "esc", "n", "q":
+		m.naming = false
+		m.viewport.SetContent(m.renderTranscript())
+		m.resumeFollow()
+		return m, nil
+
+	case "up", "k":
+		m.speakerCursor = max(m.speakerCursor-1, 0)
+
+	case "down", "j":
+		m.speakerCursor = min(m.speakerCursor+1, max(len(names)-1, 0))
+
+	case "enter":
+		if len(names) == 0 {
+			return m, nil
+		}
+		m.speakerInput.SetValue(names[m.speakerCursor])
+		m.speakerInput.CursorEnd()
+		return m, m.speakerInput.Focus()
+	}
+
+	m.viewport.SetContent(m.renderSpeakers())
+	m.viewport.EnsureVisible(m.speakerCursor+2, 0, 0) // below the hint
+	return m, nil
+}
+
+// updateSpeakerInput handles key presses while a speaker's name is edited
+func (m Model) updateSpeakerInput(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.speakerInput.Blur()
+		return m, nil
+
+	case "enter":
+		m.speakerInput.Blur()
+		names, _ := m.speakers()
+		if m.speakerCursor >= len(names) {
+			return m, nil
+		}
+		from, to := names[m.speakerCursor], strings.TrimSpace(m.speakerInput.Value())
+		if to == "" || to == from {
+			return m, nil
+		}
+		if err := m.onRenameSpeaker(from, to); err != nil {
+			m.error = err.Error()
+			return m, nil
+		}
+		m.error = ""
+		m.renameSpeaker(from, to)
+		m.viewport.SetContent(m.renderSpeakers())
+		return m.showNotice(fmt.Sprintf("%s is now %s", from, to))
+	}
+
+	var cmd tea.Cmd
+	m.speakerInput, cmd = m.speakerInput.Update(msg)
+	return m, cmd
+}
+
+// renameSpeaker relabels the segments of speaker from, including filtered
+// ones so they can still be restored
+func (m *Model) renameSpeaker(from, to string) {
+	for i := range m.segments {
+		if m.segments[i].Source == from {
+			m.segments[i].Source = to
+		}
+	}
+	for i := range m.filtered {
+		if m.filtered[i].Original.Source == from {
+			m.filtered[i].Original.Source = to
+		}
+	}
+
+	// Keep the cursor on the renamed speaker, which may have merged into
+	// one that spoke earlier
+	names, _ := m.speakers()
+	m.speakerCursor = max(slices.Index(names, to), 0)
+}
+
+// renderSpeakers renders the list of speakers to name
+func (m Model) renderSpeakers() string {
+	names, counts := m.speakers()
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#7F8C8D")).
+		Italic(true).
+		Render("Press enter to name a speaker, n or esc to return to the transcript."))
+	b.WriteString("\n\n")
+	for i, name := range names {
+		line := fmt.Sprintf("%s (%d segments)", sourceStyle.Render(name), counts[name])
+		if i == m.speakerCursor {
+			line = reviewSelectedStyle.Render("›") + " " + line
+		} else {
+			line = "  " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,319 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/summary"
+)
+
+// tab is a top-level view of the app
+type tab int
+
+const (
+	tabTranscript tab = iota
+	tabSummary
+	tabLog
+	tabDevices
+	tabCount
+)
+
+// logTailLines is how many lines of the log the log tab shows
+const logTailLines = 200
+
+// logRefreshInterval is how often the log tab rereads the log file
+const logRefreshInterval = time.Second
+
+var (
+	tabNames = [tabCount]string{"Transcript", "Summary", "Log", "Devices"}
+
+	activeTabStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#1A1A2E")).
+			Background(lipgloss.Color("#4ECDC4")).
+			Bold(true).
+			Padding(0, 1)
+
+	inactiveTabStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#7F8C8D")).
+				Padding(0, 1)
+
+	sectionStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#4ECDC4")).
+			Bold(true)
+
+	placeholderStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#7F8C8D")).
+				Italic(true)
+)
+
+// Summary is the content of the summary tab
+type Summary struct {
+	ReadingTime time.Duration
+	KeyPoints   []string
+	ActionItems []string
+}
+
+// Device is an audio source offered in the devices tab
+type Device struct {
+	Name        string
+	Description string
+	// Monitor marks system audio sources; others are microphones
+	Monitor bool
+	// Active marks the devices currently captured
+	Active bool
+}
+
+// logTickMsg refreshes the log tab. Ticks from an earlier visit to the tab
+// carry an old generation and are ignored.
+type logTickMsg struct {
+	gen int
+}
+
+// SetSummaryCallback sets the callback producing the summary tab content
+func (m *Model) SetSummaryCallback(onSummary func() (Summary, error)) {
+	m.onSummary = onSummary
+}
+
+// SetDeviceCallbacks sets the callbacks listing audio devices and switching
+// to one. onSelect returns the new device description for the header.
+func (m *Model) SetDeviceCallbacks(list func() ([]Device, error), onSelect func(Device) (string, error)) {
+	m.listDevices = list
+	m.onSelectDevice = onSelect
+}
+
+// switchTab shows the given tab, loading its content
+func (m Model) switchTab(t tab) (tea.Model, tea.Cmd) {
+	m.tab = (t + tabCount) % tabCount
+	m.error = ""
+
+	var cmd tea.Cmd
+	switch m.tab {
+	case tabSummary:
+		m.loadSummary()
+	case tabLog:
+		m.logGen++
+		m.loadLog()
+		cmd = m.tickLog()
+	case tabDevices:
+		m.loadDevices()
+	}
+
+	m.refreshViewport()
+	switch m.tab {
+	case tabTranscript:
+		m.resumeFollow()
+	case tabLog:
+		m.viewport.GotoBottom()
+	default:
+		m.viewport.GotoTop()
+	}
+	return m, cmd
+}
+
+// toggleLog opens the log tab, or returns to the tab it was opened from
+func (m Model) toggleLog() (tea.Model, tea.Cmd) {
+	if m.tab == tabLog {
+		return m.switchTab(m.logReturn)
+	}
+	m.logReturn = m.tab
+	return m.switchTab(tabLog)
+}
+
+// tabForKey maps the number keys 1-4 to tabs
+func tabForKey(k string) (tab, bool) {
+	if len(k) != 1 || k[0] < '1' || k[0] >= '1'+byte(tabCount) {
+		return 0, false
+	}
+	return tab(k[0] - '1'), true
+}
+
+// tickLog schedules the next log tab refresh
+func (m Model) tickLog() tea.Cmd {
+	gen := m.logGen
+	return tea.Tick(logRefreshInterval, func(time.Time) tea.Msg {
+		return logTickMsg{gen: gen}
+	})
+}
+
+// updateDevices handles key presses specific to the devices tab. It
+// reports whether the key was consumed.
+func (m Model) updateDevices(msg tea.KeyPressMsg) (tea.Model, tea.Cmd, bool) {
+	switch msg.String() {
+	case "up", "k":
+		m.deviceCursor = max(m.deviceCursor-1, 0)
+	case "down", "j":
+		m.deviceCursor = min(m.deviceCursor+1, max(len(m.devices)-1, 0))
+	case "enter":
+		if len(m.devices) == 0 || m.onSelectDevice == nil {
+			return m, nil, true
+		}
+		info, err := m.onSelectDevice(m.devices[m.deviceCursor])
+		if err != nil {
+			m.error = err.Error()
+			return m, nil, true
+		}
+		m.error = ""
+		m.deviceName = info
+		m.loadDevices()
+	default:
+		return m, nil, false
+	}
+
+	m.refreshViewport()
+	m.viewport.EnsureVisible(m.deviceCursor, 0, 0)
+	return m, nil, true
+}
+
+// refreshViewport renders the content of the current view into the viewport
+func (m *Model) refreshViewport() {
+	switch {
+	case m.reviewing:
+		m.viewport.SetContent(m.renderReview())
+	case m.naming:
+		m.viewport.SetContent(m.renderSpeakers())
+	case m.tab == tabSummary:
+		m.viewport.SetContent(m.renderSummary())
+	case m.tab == tabLog:
+		m.viewport.SetContent(m.logTail)
+	case m.tab == tabDevices:
+		m.viewport.SetContent(m.renderDevices())
+	default:
+		m.viewport.SetContent(m.renderTranscript())
+	}
+}
+
+// loadSummary recomputes the summary tab content
+func (m *Model) loadSummary() {
+	if m.onSummary == nil {
+		return
+	}
+	summary, err := m.onSummary()
+	if err != nil {
+		m.error = err.Error()
+		return
+	}
+	m.summary = summary
+}
+
+// loadLog rereads the end of the log file
+func (m *Model) loadLog() {
+	path := logging.GetLogPath()
+	if path == "" {
+		m.logTail = placeholderStyle.Render("Logging is not enabled.")
+		return
+	}
+	lines, err := tailFile(path, logTailLines)
+	if err != nil {
+		m.logTail = placeholderStyle.Render("Could not read log: " + err.Error())
+		return
+	}
+	m.logTail = placeholderStyle.Render(path) + "\n\n" + strings.Join(lines, "\n")
+}
+
+// loadDevices refreshes the device list
+func (m *Model) loadDevices() {
+	if m.listDevices == nil {
+		return
+	}
+	devices, err := m.listDevices()
+	if err != nil {
+		m.error = err.Error()
+		return
+	}
+	m.devices = devices
+	m.deviceCursor = min(m.deviceCursor, max(len(devices)-1, 0))
+}
+
+// renderTabs renders the tab bar
+func (m Model) renderTabs() string {
+	tabs := make([]string, tabCount)
+	for i, name := range tabNames {
+		label := fmt.Sprintf("%d %s", i+1, name)
+		if tab(i) == m.tab {
+			tabs[i] = activeTabStyle.Render(label)
+		} else {
+			tabs[i] = inactiveTabStyle.Render(label)
+		}
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+}
+
+// renderSummary renders the summary tab
+func (m Model) renderSummary() string {
+	if len(m.segments) == 0 {
+		return placeholderStyle.Render("Nothing to summarize yet.")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s ~%d min\n\n", sectionStyle.Render("Reading time:"), summary.Minutes(m.summary.ReadingTime))
+	b.WriteString(sectionStyle.Render("Key points") + "\n")
+	for _, point := range m.summary.KeyPoints {
+		fmt.Fprintf(&b, "  • %s\n", point)
+	}
+	b.WriteString("\n" + sectionStyle.Render("Action items") + "\n")
+	if len(m.summary.ActionItems) == 0 {
+		b.WriteString(placeholderStyle.Render("  none found") + "\n")
+	}
+	for _, item := range m.summary.ActionItems {
+		fmt.Fprintf(&b, "  ☐ %s\n", item)
+	}
+	return b.String()
+}
+
+// renderDevices renders the device picker
+func (m Model) renderDevices() string {
+	if len(m.devices) == 0 {
+		return placeholderStyle.Render("No audio devices found.")
+	}
+
+	var b strings.Builder
+	for i, d := range m.devices {
+		kind := "mic    "
+		if d.Monitor {
+			kind = "system "
+		}
+		name := d.Name
+		if d.Description != "" && d.Description != d.Name {
+			name = d.Description + " (" + d.Name + ")"
+		}
+		marker := "  "
+		if d.Active {
+			marker = sourceStyle.Render("● ")
+		}
+		line := kind + marker + name
+		if i == m.deviceCursor {
+			line = reviewSelectedStyle.Render("›") + " " + line
+		} else {
+			line = "  " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n" + placeholderStyle.Render("enter: use this device"))
+	return b.String()
+}
+
+// tailFile returns the last n lines of the file at path
+func tailFile(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines, scanner.Err()
+}
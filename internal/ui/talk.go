@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// minTalkTime is how much talking must have happened before the talk-time
+// warning can trigger, so a single early sentence does not set it off
+const minTalkTime = time.Minute
+
+// TalkTimeMsg reports how long the user (microphone) and the other
+// participants (system audio) have talked so far
+type TalkTimeMsg struct {
+	You  time.Duration
+	Them time.Duration
+}
+
+// SetTalkWarning warns when the user's share of the talk time exceeds
+// percent. Zero disables the warning.
+func (m *Model) SetTalkWarning(percent float64) {
+	m.talkWarn = percent
+}
+
+// updateTalkTime records a talk time report and shows a notice when the
+// user starts talking more than the configured share
+func (m Model) updateTalkTime(msg TalkTimeMsg) (tea.Model, tea.Cmd) {
+	m.talkYou, m.talkThem = msg.You, msg.Them
+	over := m.overTalking()
+	if over == m.talkWarned {
+		return m, nil
+	}
+	m.talkWarned = over
+	if !over {
+		return m, nil
+	}
+	return m.showNotice(fmt.Sprintf("You have been talking %.0f%% of the time", m.talkShare()))
+}
+
+// talkShare returns the user's share of the talk time in percent
+func (m Model) talkShare() float64 {
+	total := m.talkYou + m.talkThem
+	if total == 0 {
+		return 0
+	}
+	return float64(m.talkYou) / float64(total) * 100
+}
+
+// overTalking reports whether the user's talk share exceeds the warning
+// threshold
+func (m Model) overTalking() bool {
+	return m.talkWarn > 0 && m.talkYou+m.talkThem >= minTalkTime && m.talkShare() > m.talkWarn
+}
+
+// renderTalkTime renders the you vs them talk ratio, or "" before anyone
+// talked
+func (m Model) renderTalkTime() string {
+	if m.talkYou+m.talkThem == 0 {
+		return ""
+	}
+	share := m.talkShare()
+	ratio := fmt.Sprintf("Talk: you %.0f%% / them %.0f%%", share, 100-share)
+	// The device line is truncated to the terminal width, so it is marked
+	// rather than styled
+	if m.overTalking() {
+		return "⚠ " + ratio
+	}
+	return ratio
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"strings"
+
+	"charm.land/lipgloss/v2"
+
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+var (
+	translationStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#AED6F1"))
+
+	pendingTranslationStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#7F8C8D")).
+				Italic(true)
+
+	translationColumnStyle = lipgloss.NewStyle().
+				PaddingLeft(1).
+				Border(lipgloss.NormalBorder(), false, false, false, true).
+				BorderForeground(lipgloss.Color("#7F8C8D"))
+)
+
+// TranslationMsg is sent when the translation of a segment arrives
+type TranslationMsg struct {
+	Segment transcriber.Segment
+	Text    string
+}
+
+// SetTranslation enables the translation pane for the given target language
+func (m *Model) SetTranslation(target string) {
+	m.translateTo = target
+	m.translations = make(map[string]string)
+	m.showTranslation = true
+}
+
+// translationKey identifies a segment across messages
+func translationKey(seg transcriber.Segment) string {
+	return seg.Timestamp.String() + "\x00" + seg.Source + "\x00" + seg.Text
+}
+
+// translationVisible reports whether the transcript is shown split with its
+// translation
+func (m Model) translationVisible() bool {
+	return m.translateTo != "" && m.showTranslation
+}
+
+// renderSplitTranscript renders the original transcript on the left and its
+// translation on the right, one row per segment
+func (m Model) renderSplitTranscript() string {
+	colWidth := max((m.viewport.Width()-3)/2, 10)
+	left := lipgloss.NewStyle().Width(colWidth)
+	right := translationColumnStyle.Width(colWidth + 2)
+
+	var b strings.Builder
+	for _, seg := range m.segments {
+		translation, ok := m.translations[translationKey(seg)]
+		if ok {
+			translation = translationStyle.Render(translation)
+		} else {
+			translation = pendingTranslationStyle.Render("translating…")
+		}
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+			left.Render(m.renderSegment(seg)),
+			right.Render(translation),
+		))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -2,18 +2,25 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"charm.land/bubbles/v2/help"
 	"charm.land/bubbles/v2/key"
 	"charm.land/bubbles/v2/spinner"
+	"charm.land/bubbles/v2/textinput"
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 
+	"github.com/exler/rekord/internal/alert"
+	"github.com/exler/rekord/internal/audio"
 	"github.com/exler/rekord/internal/transcriber"
+	"github.com/exler/rekord/internal/transcript"
 )
 
 // Styles
@@ -44,6 +51,23 @@ var (
 			Foreground(lipgloss.Color("#7F8C8D")).
 			Width(12)
 
+	sourceStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#4ECDC4")).
+			Bold(true)
+
+	lowConfidenceStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#7F8C8D")).
+				Italic(true)
+
+	watchWordStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#1A1A2E")).
+			Background(lipgloss.Color("#F1C40F")).
+			Bold(true)
+
+	alertMarkerStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#F1C40F")).
+				Bold(true)
+
 	helpStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#7F8C8D")).
 			Padding(1, 0)
@@ -55,21 +79,49 @@ var (
 
 	audioLevelStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#2ECC71"))
+
+	peakHoldStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#F1C40F"))
+
+	clipStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#E74C3C")).
+			Bold(true)
+
+	noticeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#F1C40F")).
+			Bold(true)
 )
 
 // Bar width for audio level meter
 const barWidth = 20
 
+// noticeDuration is how long a notice stays visible
+const noticeDuration = 5 * time.Second
+
 // KeyMap defines keyboard shortcuts
 type KeyMap struct {
-	Start key.Binding
-	Stop  key.Binding
-	Save  key.Binding
-	Clear key.Binding
-	Quit  key.Binding
-	Up    key.Binding
-	Down  key.Binding
-	Help  key.Binding
+	Start     key.Binding
+	Stop      key.Binding
+	Save      key.Binding
+	Clear     key.Binding
+	Quit      key.Binding
+	Up        key.Binding
+	Down      key.Binding
+	GoTo      key.Binding
+	Bookmark  key.Binding
+	Follow    key.Binding
+	Waveform  key.Binding
+	Review    key.Binding
+	Prompt    key.Binding
+	Translate key.Binding
+	Karaoke   key.Binding
+	Speakers  key.Binding
+	Log       key.Binding
+	Mini      key.Binding
+	Install   key.Binding
+	NextTab   key.Binding
+	PrevTab   key.Binding
+	Help      key.Binding
 }
 
 // DefaultKeyMap returns the default key bindings
@@ -103,6 +155,62 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("down", "j"),
 			key.WithHelp("↓/j", "scroll down"),
 		),
+		GoTo: key.NewBinding(
+			key.WithKeys(":"),
+			key.WithHelp(":", "go to time"),
+		),
+		Bookmark: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "bookmark moment"),
+		),
+		Follow: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "toggle follow"),
+		),
+		Waveform: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "toggle waveform"),
+		),
+		Review: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "review filtered"),
+		),
+		Prompt: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "edit prompt"),
+		),
+		Translate: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "toggle translation"),
+		),
+		Karaoke: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "toggle karaoke"),
+		),
+		Speakers: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "name speakers"),
+		),
+		Install: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "install whisper"),
+		),
+		Log: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "toggle log"),
+		),
+		Mini: key.NewBinding(
+			key.WithKeys("M"),
+			key.WithHelp("M", "mini view"),
+		),
+		NextTab: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab/1-4", "next tab"),
+		),
+		PrevTab: key.NewBinding(
+			key.WithKeys("shift+tab"),
+			key.WithHelp("shift+tab", "previous tab"),
+		),
 		Help: key.NewBinding(
 			key.WithKeys("?"),
 			key.WithHelp("?", "toggle help"),
@@ -112,15 +220,17 @@ func DefaultKeyMap() KeyMap {
 
 // ShortHelp returns keybindings for the short help view
 func (k KeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Start, k.Save, k.Clear, k.Quit, k.Help}
+	return []key.Binding{k.Start, k.Save, k.Clear, k.NextTab, k.Quit, k.Help}
 }
 
 // FullHelp returns keybindings for the full help view
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Start, k.Stop},
-		{k.Save, k.Clear},
-		{k.Up, k.Down},
+		{k.Save, k.Clear, k.Bookmark},
+		{k.Up, k.Down, k.GoTo, k.Follow},
+		{k.Waveform, k.Review, k.Prompt, k.Translate, k.Karaoke, k.Speakers},
+		{k.NextTab, k.PrevTab, k.Log, k.Mini},
 		{k.Quit, k.Help},
 	}
 }
@@ -128,29 +238,123 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 // Model represents the application state
 type Model struct {
 	// State
-	isRecording bool
-	segments    []transcriber.Segment
-	audioLevel  float32
-	startTime   time.Time
-	error       string
-	modelLoaded bool
-	modelPath   string
-	deviceName  string
+	isRecording  bool
+	segments     []transcriber.Segment
+	audioLevel   audio.Level
+	waveform     waveform
+	showWaveform bool
+	mini         bool // collapsed into a single line
+	startTime    time.Time
+	timeFormat   transcriber.TimeFormat
+	error        string
+	notice       string
+	noticeID     int
+	latency      time.Duration
+	baseline     time.Duration
+	lag          time.Duration
+	modelLoaded  bool
+	modelPath    string
+	deviceName   string
+
+	// The oldest chunk the backend is working on, zero while idle, how many
+	// it is working on, and the audio length and processing time of recent
+	// chunks for the real-time factor
+	transcribing       time.Duration
+	transcribingSince  time.Time
+	transcribingChunks int
+	recentChunks       []chunkTiming
+
+	// Capture sources that stopped delivering audio and are being
+	// reconnected, shown as a warning until they resume
+	lostSources []string
+
+	// Auto-scrolling to new segments, paused while reading back
+	followPaused bool
+	unseen       int
 
 	// Components
-	viewport viewport.Model
-	spinner  spinner.Model
-	help     help.Model
-	keys     KeyMap
+	viewport  viewport.Model
+	spinner   spinner.Model
+	help      help.Model
+	keys      KeyMap
+	gotoInput textinput.Model
+
+	// Bookmarks set during the recording, ordered by offset
+	bookmarks     []bookmark
+	bookmarkInput textinput.Model
+	onBookmark    func(string) (transcript.Annotation, error)
+
+	// Whisper initial prompt editing
+	prompt      string
+	promptInput textinput.Model
+	onPrompt    func(string) error
 
 	// Dimensions
 	width  int
 	height int
+	// inline renders below the shell prompt instead of on the alternate
+	// screen
+	inline bool
 
 	// Callbacks
 	onStart func() error
 	onStop  func() error
 	onSave  func(string) error
+
+	// Tabs
+	tab            tab
+	summary        Summary
+	onSummary      func() (Summary, error)
+	logTail        string
+	logGen         int
+	logReturn      tab
+	devices        []Device
+	deviceCursor   int
+	listDevices    func() ([]Device, error)
+	onSelectDevice func(Device) (string, error)
+
+	// Watch-word highlighting
+	watcher *alert.Watcher
+
+	// Setup wizard for a missing whisper.cpp executable or model
+	setup       Setup
+	setupStatus [setupTaskCount]string
+	installing  int // setup tasks still running
+	onInstall   func() error
+
+	// Talk time of the user and the others, and the share of it above which
+	// the user is warned
+	talkYou    time.Duration
+	talkThem   time.Duration
+	talkWarn   float64
+	talkWarned bool
+
+	// Karaoke highlighting of the newest segment's words as they were spoken
+	karaoke      bool
+	karaokeStart time.Time
+	karaokeGen   int
+
+	// Live translation
+	translateTo     string
+	translations    map[string]string
+	showTranslation bool
+
+	// Post-processing review
+	filtered     []FilteredSegment
+	reviewing    bool
+	reviewCursor int
+	onRestore    func(FilteredSegment) error
+
+	// Naming the speakers after the recording
+	naming          bool
+	speakerCursor   int
+	speakerInput    textinput.Model
+	onRenameSpeaker func(from, to string) error
+
+	// Saved transcript that may duplicate another run, waiting to be merged,
+	// discarded or kept
+	duplicate   *DuplicateMsg
+	onDuplicate func(DuplicateAction) (string, error)
 }
 
 // NewSegmentMsg is sent when a new segment is transcribed
@@ -160,7 +364,7 @@ type NewSegmentMsg struct {
 
 // AudioLevelMsg is sent with audio level updates
 type AudioLevelMsg struct {
-	Level float32
+	Level audio.Level
 }
 
 // ErrorMsg is sent when an error occurs
@@ -171,6 +375,56 @@ type ErrorMsg struct {
 // ModelLoadedMsg is sent when the model is loaded
 type ModelLoadedMsg struct{}
 
+// LatencyMsg reports how long whisper took to transcribe a chunk. Baseline
+// marks the measurement from the startup warm-up run. Lag is the time from
+// cutting the chunk to its result, including waiting behind earlier chunks.
+// Audio is the length of the chunk.
+type LatencyMsg struct {
+	Latency  time.Duration
+	Baseline bool
+	Lag      time.Duration
+	Audio    time.Duration
+}
+
+// TranscribingMsg reports how many chunks the backend is transcribing, and
+// the Audio length and start time of the one started first. The zero value
+// reports that it is idle.
+type TranscribingMsg struct {
+	Audio   time.Duration
+	Started time.Time
+	Chunks  int
+}
+
+// ModelChangedMsg reports that transcription switched to another model,
+// with the Reason shown as a notice
+type ModelChangedMsg struct {
+	Model  string
+	Reason string
+}
+
+// SourceLostMsg reports that the capture source Device stopped delivering
+// audio, or with Lost unset that it resumed
+type SourceLostMsg struct {
+	Device string
+	Lost   bool
+}
+
+// StartRecordingMsg starts recording as if the start key was pressed
+type StartRecordingMsg struct{}
+
+// StopRecordingMsg stops recording as if the stop key was pressed
+type StopRecordingMsg struct{}
+
+// NoticeMsg is sent to show a short-lived informational notice
+type NoticeMsg struct {
+	Text string
+}
+
+// clearNoticeMsg hides the notice with the given ID once it expires
+type clearNoticeMsg struct {
+	id int
+}
+
 // New creates a new UI model
 func New(modelPath, deviceName string) Model {
 	s := spinner.New()
@@ -183,14 +437,37 @@ func New(modelPath, deviceName string) Model {
 	vp := viewport.New(viewport.WithWidth(80), viewport.WithHeight(20))
 	vp.Style = transcriptStyle
 
+	gi := textinput.New()
+	gi.Prompt = "Go to time: "
+	gi.Placeholder = "mm:ss"
+	gi.CharLimit = 12
+
+	pi := textinput.New()
+	pi.Prompt = "Prompt: "
+	pi.Placeholder = "names and terms to spell correctly"
+	pi.CharLimit = 1000
+
+	bi := textinput.New()
+	bi.Prompt = "Bookmark: "
+	bi.Placeholder = "name (enter for a numbered bookmark)"
+	bi.CharLimit = 100
+
+	si := textinput.New()
+	si.Prompt = "Speaker name: "
+	si.CharLimit = 100
+
 	return Model{
-		spinner:    s,
-		help:       h,
-		keys:       DefaultKeyMap(),
-		viewport:   vp,
-		segments:   make([]transcriber.Segment, 0),
-		modelPath:  modelPath,
-		deviceName: deviceName,
+		spinner:       s,
+		help:          h,
+		keys:          DefaultKeyMap(),
+		viewport:      vp,
+		gotoInput:     gi,
+		promptInput:   pi,
+		bookmarkInput: bi,
+		speakerInput:  si,
+		segments:      make([]transcriber.Segment, 0),
+		modelPath:     modelPath,
+		deviceName:    deviceName,
 	}
 }
 
@@ -214,38 +491,75 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.viewport.SetWidth(msg.Width - 4)
-		m.viewport.SetHeight(msg.Height - 10)
+		m.viewport.SetWidth(m.transcriptWidth())
+		m.viewport.SetHeight(m.transcriptHeight())
 		m.help.SetWidth(msg.Width)
+		m.promptInput.SetWidth(msg.Width - 12)
+		m.refreshViewport()
 
 	case tea.KeyPressMsg:
+		if m.mini {
+			return m.updateMini(msg)
+		}
+		if m.gotoInput.Focused() {
+			return m.updateGoTo(msg)
+		}
+		if m.promptInput.Focused() {
+			return m.updatePrompt(msg)
+		}
+		if m.bookmarkInput.Focused() {
+			return m.updateBookmark(msg)
+		}
+		if m.speakerInput.Focused() {
+			return m.updateSpeakerInput(msg)
+		}
+		if m.duplicate != nil {
+			return m.updateDuplicate(msg)
+		}
+		if m.reviewing {
+			return m.updateReview(msg)
+		}
+		if m.naming {
+			return m.updateSpeakers(msg)
+		}
+		if m.tab == tabDevices {
+			if model, cmd, ok := m.updateDevices(msg); ok {
+				return model, cmd
+			}
+		}
+		if t, ok := tabForKey(msg.String()); ok {
+			return m.switchTab(t)
+		}
+
 		switch {
+		case key.Matches(msg, m.keys.NextTab):
+			return m.switchTab(m.tab + 1)
+
+		case key.Matches(msg, m.keys.PrevTab):
+			return m.switchTab(m.tab - 1)
+
+		case key.Matches(msg, m.keys.Log):
+			return m.toggleLog()
+
+		case key.Matches(msg, m.keys.Mini):
+			m.mini = !m.mini
+			return m, nil
+
 		case key.Matches(msg, m.keys.Quit):
-			if m.isRecording && m.onStop != nil {
-				m.onStop()
-			}
-			return m, tea.Quit
+			return m.quit()
+
+		case key.Matches(msg, m.keys.Start) && !m.isRecording && m.setup.pending():
+			m.error = "whisper is not set up yet, press i to install it"
+			return m, nil
 
 		case key.Matches(msg, m.keys.Start) && !m.isRecording:
-			m.isRecording = true
-			m.startTime = time.Now()
-			m.error = ""
-			if m.onStart != nil {
-				if err := m.onStart(); err != nil {
-					m.error = err.Error()
-					m.isRecording = false
-				}
-			}
-			return m, m.spinner.Tick
+			return m.startRecording()
+
+		case key.Matches(msg, m.keys.Install) && m.setup.pending():
+			return m.installSetup()
 
 		case key.Matches(msg, m.keys.Stop) && m.isRecording:
-			m.isRecording = false
-			if m.onStop != nil {
-				if err := m.onStop(); err != nil {
-					m.error = err.Error()
-				}
-			}
-			return m, nil
+			return m.stopRecording()
 
 		case key.Matches(msg, m.keys.Save):
 			if m.onSave != nil {
@@ -258,9 +572,64 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case key.Matches(msg, m.keys.Clear):
 			m.segments = m.segments[:0]
-			m.viewport.SetContent("")
+			m.bookmarks = nil
+			m.filtered = nil
+			m.summary = Summary{}
+			m.refreshViewport()
+			m.resumeFollow()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Translate) && m.translateTo != "" && m.tab == tabTranscript:
+			m.showTranslation = !m.showTranslation
+			m.refreshViewport()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Karaoke):
+			m.karaoke = !m.karaoke
+			m.karaokeStart = time.Time{}
+			m.refreshViewport()
+			if m.karaoke {
+				return m.showNotice("Karaoke on, words are highlighted as they were spoken")
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Prompt) && m.onPrompt != nil:
+			return m, m.openPrompt()
+
+		case key.Matches(msg, m.keys.Bookmark) && m.onBookmark != nil:
+			return m, m.openBookmark()
+
+		case key.Matches(msg, m.keys.Follow) && m.tab == tabTranscript:
+			if m.followPaused {
+				m.resumeFollow()
+			} else {
+				m.followPaused = true
+			}
 			return m, nil
 
+		case key.Matches(msg, m.keys.GoTo) && m.tab == tabTranscript:
+			m.gotoInput.Reset()
+			return m, m.gotoInput.Focus()
+
+		case key.Matches(msg, m.keys.Waveform):
+			m.showWaveform = !m.showWaveform
+			m.viewport.SetHeight(m.transcriptHeight())
+			return m, nil
+
+		case key.Matches(msg, m.keys.Review) && m.tab == tabTranscript:
+			m.reviewing = true
+			m.reviewCursor = max(len(m.filtered)-1, 0)
+			m.viewport.SetContent(m.renderReview())
+			m.viewport.GotoBottom()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Speakers) && m.tab == tabTranscript && m.onRenameSpeaker != nil:
+			if m.isRecording {
+				m.error = "stop recording to name the speakers"
+				return m, nil
+			}
+			return m.openSpeakers()
+
 		case key.Matches(msg, m.keys.Help):
 			m.help.ShowAll = !m.help.ShowAll
 			return m, nil
@@ -268,12 +637,74 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case NewSegmentMsg:
 		m.segments = append(m.segments, msg.Segment)
-		m.viewport.SetContent(m.renderTranscript())
-		m.viewport.GotoBottom()
+		cmd := m.startKaraoke()
+		switch {
+		case m.reviewing:
+		case m.naming:
+			m.viewport.SetContent(m.renderSpeakers())
+		case m.tab == tabTranscript:
+			m.viewport.SetContent(m.renderTranscript())
+			if m.followPaused {
+				m.unseen++
+			} else {
+				m.viewport.GotoBottom()
+			}
+		case m.tab == tabSummary:
+			m.loadSummary()
+			m.refreshViewport()
+		}
+		return m, cmd
+
+	case karaokeTickMsg:
+		if msg.gen != m.karaokeGen {
+			return m, nil
+		}
+		_, playing := m.karaokePosition()
+		if !playing {
+			m.karaokeStart = time.Time{}
+		}
+		if !m.reviewing && !m.naming && m.tab == tabTranscript {
+			m.refreshViewport()
+			if !m.followPaused {
+				m.viewport.GotoBottom()
+			}
+		}
+		if !playing {
+			return m, nil
+		}
+		return m, m.tickKaraoke()
+
+	case logTickMsg:
+		if m.tab != tabLog || msg.gen != m.logGen {
+			return m, nil
+		}
+		atBottom := m.viewport.AtBottom()
+		m.loadLog()
+		m.refreshViewport()
+		if atBottom {
+			m.viewport.GotoBottom()
+		}
+		return m, m.tickLog()
+
+	case TranslationMsg:
+		if m.translations != nil {
+			m.translations[translationKey(msg.Segment)] = msg.Text
+			if !m.reviewing && m.showTranslation && m.tab == tabTranscript {
+				m.refreshViewport()
+			}
+		}
+		return m, nil
+
+	case FilteredMsg:
+		m.filtered = append(m.filtered, msg.Filtered)
+		if m.reviewing {
+			m.viewport.SetContent(m.renderReview())
+		}
 		return m, nil
 
 	case AudioLevelMsg:
 		m.audioLevel = msg.Level
+		m.waveform.add(msg.Level, time.Now())
 		return m, nil
 
 	case ErrorMsg:
@@ -284,8 +715,74 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.modelLoaded = true
 		return m, nil
 
-	case spinner.TickMsg:
+	case LatencyMsg:
+		if msg.Baseline {
+			m.baseline = msg.Latency
+		} else {
+			m.latency = msg.Latency
+			m.lag = msg.Lag
+			m.addChunkTiming(msg.Audio, msg.Latency)
+		}
+		return m, nil
+
+	case TranscribingMsg:
+		m.transcribing = msg.Audio
+		m.transcribingSince = msg.Started
+		m.transcribingChunks = msg.Chunks
+		if m.transcribing > 0 && !m.isRecording {
+			// Keep ticking so the elapsed time updates after stopping
+			return m, m.spinner.Tick
+		}
+		return m, nil
+
+	case NoticeMsg:
+		return m.showNotice(msg.Text)
+
+	case SourceLostMsg:
+		m.lostSources = slices.DeleteFunc(m.lostSources, func(d string) bool { return d == msg.Device })
+		if msg.Lost {
+			m.lostSources = append(m.lostSources, msg.Device)
+		}
+		return m, nil
+
+	case ModelChangedMsg:
+		m.modelPath = msg.Model
+		return m.showNotice(msg.Reason)
+
+	case TalkTimeMsg:
+		return m.updateTalkTime(msg)
+
+	case SetupProgressMsg:
+		return m.updateSetup(msg)
+
+	case DuplicateMsg:
+		m.duplicate = &msg
+		return m, nil
+
+	case DuplicateResolvedMsg:
+		m.duplicate = nil
+		return m.showNotice(msg.Text)
+
+	case StartRecordingMsg:
+		if !m.isRecording && !m.setup.pending() {
+			return m.startRecording()
+		}
+		return m, nil
+
+	case StopRecordingMsg:
 		if m.isRecording {
+			return m.stopRecording()
+		}
+		return m, nil
+
+	case clearNoticeMsg:
+		if msg.id == m.noticeID {
+			m.notice = ""
+		}
+		return m, nil
+
+	case spinner.TickMsg:
+		if m.isRecording || m.transcribing > 0 {
 			var cmd tea.Cmd
 			m.spinner, cmd = m.spinner.Update(msg)
 			cmds = append(cmds, cmd)
@@ -294,7 +791,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// Handle viewport scrolling
 	var cmd tea.Cmd
+	before := m.viewport.YOffset()
 	m.viewport, cmd = m.viewport.Update(msg)
+	m.trackScroll(before)
 	cmds = append(cmds, cmd)
 
 	return m, tea.Batch(cmds...)
@@ -304,38 +803,46 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m Model) View() tea.View {
 	if m.width == 0 {
 		v := tea.NewView("Loading...")
-		v.AltScreen = true
+		v.AltScreen = !m.inline
 		return v
 	}
 
+	if m.mini {
+		return m.miniView()
+	}
+
 	var b strings.Builder
 
-	// Title
-	title := titleStyle.Render(" REKORD - Meeting Transcriber ")
-	b.WriteString(title)
+	// Very short terminals and the inline UI only get a status line, the
+	// viewport and a single help line
+	if m.short() || m.inline {
+		return m.shortView()
+	}
+
+	// Title and tabs
+	title := " REKORD - Meeting Transcriber "
+	if m.narrow() {
+		title = " REKORD "
+	}
+	b.WriteString(titleStyle.Render(title))
+	b.WriteString("\n")
+	b.WriteString(m.renderTabs())
 	b.WriteString("\n\n")
 
 	// Status bar
-	var status string
-	if m.isRecording {
-		duration := time.Since(m.startTime).Round(time.Second)
-		status = fmt.Sprintf("%s Recording... %s | Audio: %s",
-			m.spinner.View(),
-			duration.String(),
-			m.renderAudioLevel(),
-		)
-		status = recordingStyle.Render("● REC ") + statusStyle.Render(status)
-	} else {
-		status = stoppedStyle.Render("○ STOPPED - Press 's' to start recording")
-	}
-	b.WriteString(statusStyle.Render(status))
+	b.WriteString(statusStyle.Render(m.renderStatus()))
 	b.WriteString("\n")
 
 	// Device info
-	deviceInfo := fmt.Sprintf("Device: %s | Model: %s", m.deviceName, m.modelPath)
-	b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#7F8C8D")).Render(deviceInfo))
+	b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#7F8C8D")).Render(m.renderDeviceInfo()))
 	b.WriteString("\n\n")
 
+	// Notice display
+	if m.notice != "" {
+		b.WriteString(noticeStyle.Render(m.notice))
+		b.WriteString("\n\n")
+	}
+
 	// Error display
 	if m.error != "" {
 		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#E74C3C")).Bold(true)
@@ -343,42 +850,287 @@ func (m Model) View() tea.View {
 		b.WriteString("\n\n")
 	}
 
+	// Waveform pane
+	if m.showWaveform {
+		b.WriteString(audioLevelStyle.Render(m.waveform.view(m.width - 4)))
+		b.WriteString("\n")
+	}
+
 	// Transcript viewport
 	b.WriteString(borderStyle.Render(m.viewport.View()))
 	b.WriteString("\n\n")
 
-	// Help
-	b.WriteString(helpStyle.Render(m.help.View(m.keys)))
+	// Help, replaced by the go-to or whisper prompt while one is open
+	switch {
+	case m.duplicate != nil:
+		b.WriteString(noticeStyle.Render(m.renderDuplicate()))
+	case m.gotoInput.Focused():
+		b.WriteString(helpStyle.Render(m.gotoInput.View()))
+	case m.promptInput.Focused():
+		b.WriteString(helpStyle.Render(m.promptInput.View()))
+	case m.bookmarkInput.Focused():
+		b.WriteString(helpStyle.Render(m.bookmarkInput.View()))
+	case m.speakerInput.Focused():
+		b.WriteString(helpStyle.Render(m.speakerInput.View()))
+	default:
+		b.WriteString(helpStyle.Render(m.help.View(m.keys)))
+	}
 
 	v := tea.NewView(b.String())
 	v.AltScreen = true
 	return v
 }
 
+// transcriptHeight returns the viewport height left over by the other panes
+func (m Model) transcriptHeight() int {
+	if m.inline {
+		return inlineLines
+	}
+	if m.short() {
+		return max(m.height-shortChromeHeight, 1)
+	}
+	height := m.height - 12
+	if m.showWaveform {
+		height--
+	}
+	return max(height, 1)
+}
+
+// startRecording starts a recording through the start callback
+func (m Model) startRecording() (tea.Model, tea.Cmd) {
+	m.isRecording = true
+	m.startTime = time.Now()
+	m.lostSources = nil
+	m.error = ""
+	if m.onStart != nil {
+		if err := m.onStart(); err != nil {
+			m.error = err.Error()
+			m.isRecording = false
+		}
+	}
+	return m, m.spinner.Tick
+}
+
+// stopRecording stops the recording through the stop callback
+func (m Model) stopRecording() (tea.Model, tea.Cmd) {
+	m.isRecording = false
+	if m.onStop != nil {
+		if err := m.onStop(); err != nil {
+			m.error = err.Error()
+		}
+	}
+	return m, nil
+}
+
+// quit stops a running recording and ends the program
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	if m.isRecording && m.onStop != nil {
+		m.onStop()
+	}
+	return m, tea.Quit
+}
+
+// showNotice shows a notice and schedules it to be cleared
+func (m Model) showNotice(text string) (tea.Model, tea.Cmd) {
+	m.notice = text
+	m.noticeID++
+	id := m.noticeID
+	return m, tea.Tick(noticeDuration, func(time.Time) tea.Msg {
+		return clearNoticeMsg{id: id}
+	})
+}
+
+// updateGoTo handles key presses while the go-to-time prompt is open
+func (m Model) updateGoTo(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.gotoInput.Blur()
+		return m, nil
+
+	case "enter":
+		m.gotoInput.Blur()
+		target, err := parseElapsed(m.gotoInput.Value())
+		if err != nil {
+			m.error = err.Error()
+			return m, nil
+		}
+		if idx := m.nearestSegment(target); idx >= 0 {
+			m.error = ""
+			m.viewport.SetYOffset(m.segmentLine(idx))
+			m.followPaused = !m.viewport.AtBottom()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.gotoInput, cmd = m.gotoInput.Update(msg)
+	return m, cmd
+}
+
+// nearestSegment returns the index of the segment closest to the given
+// elapsed session time, or -1 if there are no segments. Segments are
+// matched by when they were spoken, whatever the time format: their
+// Timestamp is when they were transcribed, which lags behind.
+func (m Model) nearestSegment(target time.Duration) int {
+	best := -1
+	var bestDiff time.Duration
+	for i, seg := range m.segments {
+		diff := seg.StartTime - target
+		if diff < 0 {
+			diff = -diff
+		}
+		if best < 0 || diff < bestDiff {
+			best = i
+			bestDiff = diff
+		}
+	}
+	return best
+}
+
+// parseElapsed parses an elapsed time in the form ss, mm:ss or hh:mm:ss
+func parseElapsed(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("empty time")
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q: use mm:ss or hh:mm:ss", s)
+	}
+
+	var total time.Duration
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid time %q: use mm:ss or hh:mm:ss", s)
+		}
+		total = total*60 + time.Duration(n)
+	}
+	return total * time.Second, nil
+}
+
 // renderTranscript renders all transcript segments
 func (m Model) renderTranscript() string {
-	if len(m.segments) == 0 {
+	if len(m.segments) == 0 && len(m.bookmarks) == 0 {
+		if m.setup.pending() {
+			return m.renderSetup()
+		}
 		return lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#7F8C8D")).
 			Italic(true).
 			Render("No transcription yet. Start recording to begin...")
 	}
 
+	if m.translationVisible() {
+		return m.renderSplitTranscript()
+	}
+
 	var b strings.Builder
+	shown := 0
 	for _, seg := range m.segments {
-		timestamp := timestampStyle.Render(seg.Timestamp.Format("15:04:05"))
-		text := seg.Text
-		fmt.Fprintf(&b, "%s %s\n", timestamp, text)
+		for _, mark := range m.bookmarksUntil(shown, seg) {
+			b.WriteString(m.renderBookmark(mark))
+			b.WriteString("\n")
+			shown++
+		}
+		b.WriteString(m.renderSegment(seg))
+		b.WriteString("\n")
+	}
+	for _, mark := range m.bookmarks[shown:] {
+		b.WriteString(m.renderBookmark(mark))
+		b.WriteString("\n")
+	}
+	// The inline UI has no line to spare for the empty one after the last
+	// segment
+	if m.inline {
+		return strings.TrimSuffix(b.String(), "\n")
 	}
 	return b.String()
 }
 
-// renderAudioLevel renders an audio level meter
+// renderSegment renders one transcript line
+func (m Model) renderSegment(seg transcriber.Segment) string {
+	timestamp := timestampStyle.Render(m.timeFormat.Format(seg))
+	text := seg.Text
+	alerted := len(m.watcher.Match(text)) > 0
+	if karaoke, ok := m.renderKaraoke(seg); ok {
+		text = karaoke
+	} else if alerted {
+		text = m.watcher.Highlight(text, func(w string) string { return watchWordStyle.Render(w) })
+	}
+	if seg.IsLowConfidence() {
+		text = lowConfidenceStyle.Render(fmt.Sprintf("%s (? %.0f%%)", text, seg.Confidence*100))
+	}
+	if seg.Source != "" {
+		text = sourceStyle.Render(seg.Source+":") + " " + text
+	}
+	if alerted {
+		text = alertMarkerStyle.Render("! ") + text
+	}
+	return timestamp + " " + text
+}
+
+// SetTimeFormat sets how segment times are shown
+func (m *Model) SetTimeFormat(f transcriber.TimeFormat) {
+	m.timeFormat = f
+}
+
+// SetWatcher highlights segments containing the watcher's watch-words
+func (m *Model) SetWatcher(w *alert.Watcher) {
+	m.watcher = w
+}
+
+// renderLag warns when chunks queue up behind a slow backend
+func (m Model) renderLag() string {
+	// Waiting under a second is normal scheduling jitter
+	if m.lag-m.latency < time.Second {
+		return ""
+	}
+	return fmt.Sprintf("Behind by %s", m.lag.Round(time.Second))
+}
+
+// renderLatency renders the last chunk latency and the warm-up baseline
+func (m Model) renderLatency() string {
+	switch {
+	case m.latency > 0 && m.baseline > 0:
+		return fmt.Sprintf("Latency: %s (baseline %s)", m.latency.Round(10*time.Millisecond), m.baseline.Round(10*time.Millisecond))
+	case m.latency > 0:
+		return fmt.Sprintf("Latency: %s", m.latency.Round(10*time.Millisecond))
+	case m.baseline > 0:
+		return fmt.Sprintf("Baseline latency: %s", m.baseline.Round(10*time.Millisecond))
+	}
+	return ""
+}
+
+// renderAudioLevel renders an RMS level meter with a peak-hold marker and a
+// clipping warning
 func (m Model) renderAudioLevel() string {
-	level := int(m.audioLevel * barWidth)
-	level = min(max(level, 0), barWidth) // Clamp level to [0, barWidth]
-	bar := strings.Repeat("█", level) + strings.Repeat("░", barWidth-level)
-	return audioLevelStyle.Render(bar)
+	width := m.meterWidth()
+	level := dbfsToBar(m.audioLevel.RMS, width)
+	hold := dbfsToBar(m.audioLevel.PeakHold, width)
+
+	var b strings.Builder
+	b.WriteString(audioLevelStyle.Render(strings.Repeat("█", level)))
+	for i := level; i < width; i++ {
+		if i == hold-1 && hold > level {
+			b.WriteString(peakHoldStyle.Render("│"))
+		} else {
+			b.WriteString(audioLevelStyle.Render("░"))
+		}
+	}
+
+	fmt.Fprintf(&b, " %4.0f dB", m.audioLevel.RMS)
+	if m.audioLevel.Clipping {
+		b.WriteString(" " + clipStyle.Render("CLIP"))
+	}
+	return b.String()
+}
+
+// dbfsToBar maps a dBFS value to a bar length in [0, width]
+func dbfsToBar(db float64, width int) int {
+	level := int((db - audio.MinDBFS) / -audio.MinDBFS * float64(width))
+	return min(max(level, 0), width)
 }
 
 // AddSegment adds a new transcript segment (for external use)
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/exler/rekord/internal/audio"
+)
+
+const (
+	// waveformWindow is how much recent audio the waveform pane shows
+	waveformWindow = 30 * time.Second
+	// waveformBucket is the time covered by a single waveform column
+	waveformBucket = 500 * time.Millisecond
+)
+
+var sparkRunes = []rune("▁▂▃▄▅▆▇█")
+
+// waveform keeps a rolling history of audio levels for the visualization pane
+type waveform struct {
+	buckets     []float64
+	bucketStart time.Time
+}
+
+// add records a level reading, starting new columns as time advances
+func (w *waveform) add(level audio.Level, now time.Time) {
+	if len(w.buckets) == 0 || now.Sub(w.bucketStart) >= waveformBucket {
+		w.buckets = append(w.buckets, audio.MinDBFS)
+		w.bucketStart = now
+
+		maxBuckets := int(waveformWindow / waveformBucket)
+		if len(w.buckets) > maxBuckets {
+			w.buckets = w.buckets[len(w.buckets)-maxBuckets:]
+		}
+	}
+
+	last := len(w.buckets) - 1
+	w.buckets[last] = max(w.buckets[last], level.RMS)
+}
+
+// view renders the history as a sparkline, right-aligned in the given width
+func (w waveform) view(width int) string {
+	buckets := w.buckets
+	if len(buckets) > width {
+		buckets = buckets[len(buckets)-width:]
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", max(width-len(buckets), 0)))
+	for _, db := range buckets {
+		idx := int((db - audio.MinDBFS) / -audio.MinDBFS * float64(len(sparkRunes)-1))
+		b.WriteRune(sparkRunes[min(max(idx, 0), len(sparkRunes)-1)])
+	}
+	return b.String()
+}
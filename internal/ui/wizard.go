@@ -0,0 +1,570 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/exler/rekord/internal/audio"
+)
+
+// WizardModel is a whisper model offered by the setup wizard
+type WizardModel struct {
+	// Name is the model name without the ggml- prefix, e.g. base.en
+	Name        string
+	Size        string
+	Description string
+	Installed   bool
+}
+
+// WizardDevice is an audio device offered by the setup wizard
+type WizardDevice struct {
+	Name        string
+	Description string
+}
+
+// WizardChoices is what was chosen in the setup wizard. Empty devices stand
+// for the default ones.
+type WizardChoices struct {
+	Model  string
+	Device string
+	Mic    string
+	NoMic  bool
+	Output string
+}
+
+// WizardOptions is what the setup wizard offers and how it acts on the
+// choices
+type WizardOptions struct {
+	Models   []WizardModel
+	Monitors []WizardDevice
+	Inputs   []WizardDevice
+	// DefaultDevice and DefaultMic name the default devices, if known
+	DefaultDevice string
+	DefaultMic    string
+	// Initial is preselected
+	Initial WizardChoices
+	// Whisper is set when whisper.cpp has to be installed too
+	Whisper bool
+	// ConfigPath is where the choices are saved
+	ConfigPath string
+
+	// Test starts recording a few seconds from device, empty for the
+	// default one of its kind, and reports back with WizardLevelMsg and
+	// WizardTestMsg
+	Test func(device string, mic bool) error
+	// CheckOutput creates the output directory, or reports why it cannot
+	// be used
+	CheckOutput func(dir string) error
+	// Install starts downloading the chosen model, and whisper.cpp if it is
+	// missing, and reports back with SetupProgressMsg
+	Install func(choices WizardChoices) error
+}
+
+// WizardLevelMsg reports the level of the device being tested
+type WizardLevelMsg struct {
+	Level audio.Level
+}
+
+// WizardTestMsg ends a device test with the level of the whole recording.
+// Received is false if no audio arrived at all.
+type WizardTestMsg struct {
+	Level    audio.Level
+	Received bool
+	Err      error
+}
+
+// wizardStep is one page of the setup wizard
+type wizardStep int
+
+const (
+	wizardModel wizardStep = iota
+	wizardDevice
+	wizardMic
+	wizardTest
+	wizardOutput
+	wizardInstall
+	wizardStepCount
+)
+
+var wizardTitles = [wizardStepCount]string{
+	"Choose a whisper model",
+	"Choose the system audio to transcribe",
+	"Choose your microphone",
+	"Test the audio",
+	"Choose where transcripts are saved",
+	"Install and save",
+}
+
+var wizardWarnStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#F1C40F"))
+
+// deviceTest is a device tested in the test step and its result
+type deviceTest struct {
+	label  string
+	device string
+	mic    bool
+	result string
+}
+
+// Wizard is the first-run setup wizard. It picks the model to download,
+// the devices to capture and the output directory, tests the devices and
+// installs what is missing.
+type Wizard struct {
+	opts   WizardOptions
+	step   wizardStep
+	cursor [wizardStepCount]int
+
+	tests     []deviceTest
+	test      int
+	recording bool
+	level     audio.Level
+
+	output textinput.Model
+
+	installing  int
+	setupStatus [setupTaskCount]string
+	failed      bool
+	finished    bool
+	completed   bool
+
+	error string
+	width int
+}
+
+// NewWizard creates a setup wizard offering opts
+func NewWizard(opts WizardOptions) Wizard {
+	w := Wizard{opts: opts, width: 80}
+	for i, m := range opts.Models {
+		if m.Name == opts.Initial.Model {
+			w.cursor[wizardModel] = i
+		}
+	}
+	for i, d := range opts.Monitors {
+		if d.Name == opts.Initial.Device {
+			w.cursor[wizardDevice] = i + 1
+		}
+	}
+	for i, d := range opts.Inputs {
+		if d.Name == opts.Initial.Mic {
+			w.cursor[wizardMic] = i + 1
+		}
+	}
+	if opts.Initial.NoMic {
+		w.cursor[wizardMic] = len(opts.Inputs) + 1
+	}
+	w.output = textinput.New()
+	w.output.Prompt = "Directory: "
+	w.output.CharLimit = 1000
+	w.output.SetValue(opts.Initial.Output)
+	return w
+}
+
+// Choices returns what was chosen and whether the wizard was completed
+// rather than cancelled
+func (w Wizard) Choices() (WizardChoices, bool) {
+	c := WizardChoices{Output: strings.TrimSpace(w.output.Value())}
+	if len(w.opts.Models) > 0 {
+		c.Model = w.opts.Models[w.cursor[wizardModel]].Name
+	}
+	if i := w.cursor[wizardDevice]; i > 0 {
+		c.Device = w.opts.Monitors[i-1].Name
+	}
+	switch i := w.cursor[wizardMic]; {
+	case i > len(w.opts.Inputs):
+		c.NoMic = true
+	case i > 0:
+		c.Mic = w.opts.Inputs[i-1].Name
+	}
+	return c, w.completed
+}
+
+// Init implements tea.Model
+func (w Wizard) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (w Wizard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		w.width = msg.Width
+		return w, nil
+
+	case WizardLevelMsg:
+		w.level = msg.Level
+		return w, nil
+
+	case WizardTestMsg:
+		w.recording = false
+		w.tests[w.test].result = testResult(msg)
+		return w, nil
+
+	case SetupProgressMsg:
+		w.setupStatus[msg.Task] = msg.Status
+		if msg.Done {
+			w.installing--
+			switch {
+			case msg.Err != nil:
+				w.setupStatus[msg.Task] = "failed: " + msg.Err.Error()
+				w.failed = true
+			case msg.Task == SetupWhisper:
+				w.opts.Whisper = false
+			case msg.Task == SetupModel:
+				w.opts.Models[w.cursor[wizardModel]].Installed = true
+			}
+			w.finished = w.installing == 0
+		}
+		return w, nil
+
+	case tea.KeyPressMsg:
+		if msg.String() == "ctrl+c" {
+			return w, tea.Quit
+		}
+		if w.step == wizardOutput {
+			return w.updateOutput(msg)
+		}
+		return w.updateKey(msg.String())
+	}
+	return w, nil
+}
+
+// updateKey handles key presses outside of the output directory input
+func (w Wizard) updateKey(key string) (tea.Model, tea.Cmd) {
+	busy := w.recording || w.installing > 0
+	switch key {
+	case "q":
+		if !busy {
+			return w, tea.Quit
+		}
+	case "esc":
+		if busy {
+			return w, nil
+		}
+		if w.step == wizardModel {
+			return w, tea.Quit
+		}
+		return w.back()
+	case "up", "k":
+		w.cursor[w.step] = max(w.cursor[w.step]-1, 0)
+	case "down", "j":
+		w.cursor[w.step] = min(w.cursor[w.step]+1, w.listLen()-1)
+	case "r":
+		if w.step == wizardTest && !busy && w.tests[w.test].result != "" {
+			return w.startTest()
+		}
+	case "s":
+		if w.step == wizardTest && !busy {
+			return w.next()
+		}
+	case "enter":
+		switch {
+		case busy:
+		case w.step == wizardTest:
+			t := w.tests[w.test]
+			if t.result == "" {
+				return w.startTest()
+			}
+			if w.test < len(w.tests)-1 {
+				w.test++
+				return w, nil
+			}
+			return w.next()
+		case w.step == wizardInstall:
+			if w.finished {
+				w.completed = true
+				return w, tea.Quit
+			}
+		default:
+			return w.next()
+		}
+	}
+	return w, nil
+}
+
+// updateOutput handles key presses while the output directory is edited
+func (w Wizard) updateOutput(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		w.output.Blur()
+		return w.back()
+	case "enter":
+		dir := strings.TrimSpace(w.output.Value())
+		if dir == "" {
+			w.error = "Enter a directory"
+			return w, nil
+		}
+		if w.opts.CheckOutput != nil {
+			if err := w.opts.CheckOutput(dir); err != nil {
+				w.error = err.Error()
+				return w, nil
+			}
+		}
+		w.output.Blur()
+		return w.next()
+	}
+	var cmd tea.Cmd
+	w.output, cmd = w.output.Update(msg)
+	return w, cmd
+}
+
+// next moves on to the next step
+func (w Wizard) next() (tea.Model, tea.Cmd) {
+	w.error = ""
+	w.step++
+	switch w.step {
+	case wizardTest:
+		w.tests = w.testedDevices()
+		w.test = 0
+	case wizardOutput:
+		return w, w.output.Focus()
+	case wizardInstall:
+		return w.install()
+	}
+	return w, nil
+}
+
+// back returns to the previous step
+func (w Wizard) back() (tea.Model, tea.Cmd) {
+	w.error = ""
+	w.step--
+	switch w.step {
+	case wizardTest:
+		w.test = 0
+	case wizardOutput:
+		w.finished = false
+		return w, w.output.Focus()
+	}
+	return w, nil
+}
+
+// testedDevices returns the chosen devices to test
+func (w Wizard) testedDevices() []deviceTest {
+	c, _ := w.Choices()
+	tests := []deviceTest{{label: "System audio", device: c.Device}}
+	if !c.NoMic {
+		tests = append(tests, deviceTest{label: "Microphone", device: c.Mic, mic: true})
+	}
+	return tests
+}
+
+// startTest starts recording from the device being tested
+func (w Wizard) startTest() (tea.Model, tea.Cmd) {
+	t := &w.tests[w.test]
+	t.result = ""
+	if w.opts.Test == nil {
+		t.result = placeholderStyle.Render("not tested")
+		return w, nil
+	}
+	if err := w.opts.Test(t.device, t.mic); err != nil {
+		t.result = setupMissingStyle.Render("✗ " + err.Error())
+		return w, nil
+	}
+	w.recording = true
+	w.level = audio.Level{RMS: audio.MinDBFS, Peak: audio.MinDBFS}
+	return w, nil
+}
+
+// install starts installing the chosen model and whisper.cpp if they are
+// missing
+func (w Wizard) install() (tea.Model, tea.Cmd) {
+	w.setupStatus = [setupTaskCount]string{}
+	w.failed = false
+	w.installing = 0
+	if w.opts.Whisper {
+		w.installing++
+	}
+	if len(w.opts.Models) > 0 && !w.opts.Models[w.cursor[wizardModel]].Installed {
+		w.installing++
+	}
+	if w.installing == 0 || w.opts.Install == nil {
+		w.installing = 0
+		w.finished = true
+		return w, nil
+	}
+	c, _ := w.Choices()
+	if err := w.opts.Install(c); err != nil {
+		w.error = err.Error()
+		w.installing = 0
+		w.finished = true
+	}
+	return w, nil
+}
+
+// testResult describes whether a test recording is usable for
+// transcription
+func testResult(msg WizardTestMsg) string {
+	switch {
+	case msg.Err != nil:
+		return setupMissingStyle.Render("✗ " + msg.Err.Error())
+	case !msg.Received:
+		return setupMissingStyle.Render("✗ No audio received; check that the device exists and is not suspended")
+	case msg.Level.Clipping:
+		return wizardWarnStyle.Render(fmt.Sprintf("! Clipping (peak %.1f dBFS); lower the volume", msg.Level.Peak))
+	case msg.Level.RMS < audio.SpeechDBFS:
+		return wizardWarnStyle.Render(fmt.Sprintf("! Very quiet (%.1f dBFS); check that it is not muted", msg.Level.RMS))
+	default:
+		return setupDoneStyle.Render(fmt.Sprintf("✓ Level OK (%.1f dBFS)", msg.Level.RMS))
+	}
+}
+
+// entries returns the entries of the current list step
+func (w Wizard) entries() []string {
+	var entries []string
+	switch w.step {
+	case wizardModel:
+		for _, m := range w.opts.Models {
+			line := fmt.Sprintf("%-15s %8s  %s", m.Name, m.Size, m.Description)
+			if m.Installed {
+				line += " " + setupDoneStyle.Render("(installed)")
+			}
+			entries = append(entries, line)
+		}
+	case wizardDevice:
+		entries = append(entries, defaultEntry("Default system audio", w.opts.DefaultDevice))
+		for _, d := range w.opts.Monitors {
+			entries = append(entries, deviceEntry(d))
+		}
+	case wizardMic:
+		entries = append(entries, defaultEntry("Default microphone", w.opts.DefaultMic))
+		for _, d := range w.opts.Inputs {
+			entries = append(entries, deviceEntry(d))
+		}
+		entries = append(entries, "No microphone, transcribe system audio only")
+	}
+	return entries
+}
+
+// listLen returns the number of entries of the current list step
+func (w Wizard) listLen() int {
+	return len(w.entries())
+}
+
+// View implements tea.Model
+func (w Wizard) View() tea.View {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(" REKORD - Setup "))
+	fmt.Fprintf(&b, "\n\n%s\n\n", setupTitleStyle.Render(fmt.Sprintf("Step %d of %d: %s", w.step+1, wizardStepCount, wizardTitles[w.step])))
+
+	var help string
+	switch w.step {
+	case wizardModel, wizardDevice, wizardMic:
+		for i, line := range w.entries() {
+			line = truncate(line, w.width-2)
+			if i == w.cursor[w.step] {
+				b.WriteString(reviewSelectedStyle.Render("›") + " " + line + "\n")
+			} else {
+				b.WriteString("  " + line + "\n")
+			}
+		}
+		switch w.step {
+		case wizardModel:
+			b.WriteString("\n" + placeholderStyle.Render("Larger models are more accurate but slower. Models ending in .en only understand English."))
+			help = "↑/↓: select • enter: next • q: cancel"
+		case wizardDevice:
+			b.WriteString("\n" + placeholderStyle.Render("Meeting audio is captured from the monitor of the speakers or headset you listen on."))
+			help = "↑/↓: select • enter: next • esc: back • q: cancel"
+		default:
+			help = "↑/↓: select • enter: next • esc: back • q: cancel"
+		}
+
+	case wizardTest:
+		for i, t := range w.tests {
+			fmt.Fprintf(&b, "%-12s %s\n", t.label, placeholderStyle.Render(orDefault(t.device)))
+			switch {
+			case i == w.test && w.recording:
+				bar := dbfsToBar(w.level.RMS, barWidth)
+				fmt.Fprintf(&b, "  %s%s %4.0f dB\n", audioLevelStyle.Render(strings.Repeat("█", bar)), audioLevelStyle.Render(strings.Repeat("░", barWidth-bar)), w.level.RMS)
+			case t.result != "":
+				fmt.Fprintf(&b, "  %s\n", t.result)
+			case i == w.test && t.mic:
+				b.WriteString("  Press enter, then speak into the microphone for a few seconds\n")
+			case i == w.test:
+				b.WriteString("  Press enter, then play something through your speakers for a few seconds\n")
+			}
+			b.WriteString("\n")
+		}
+		switch {
+		case w.recording:
+			help = "recording..."
+		case w.tests[w.test].result != "":
+			help = "enter: next • r: test again • s: skip • esc: back • q: cancel"
+		default:
+			help = "enter: record • s: skip • esc: back • q: cancel"
+		}
+
+	case wizardOutput:
+		b.WriteString(w.output.View() + "\n\n")
+		b.WriteString(placeholderStyle.Render("Transcripts of each recording are saved here. It is created if it does not exist."))
+		help = "enter: next • esc: back"
+
+	case wizardInstall:
+		c, _ := w.Choices()
+		fmt.Fprintf(&b, "Model:        %s\n", c.Model)
+		fmt.Fprintf(&b, "System audio: %s\n", orDefault(c.Device))
+		if c.NoMic {
+			b.WriteString("Microphone:   none\n")
+		} else {
+			fmt.Fprintf(&b, "Microphone:   %s\n", orDefault(c.Mic))
+		}
+		fmt.Fprintf(&b, "Output:       %s\n\n", c.Output)
+
+		line := func(task SetupTask, name string) {
+			status := w.setupStatus[task]
+			if status == "" {
+				status = "waiting"
+			}
+			fmt.Fprintf(&b, "  %-11s  %s\n", name, truncate(status, w.width-17))
+		}
+		if w.opts.Whisper || w.setupStatus[SetupWhisper] != "" {
+			line(SetupWhisper, "whisper.cpp")
+		}
+		if c.Model != "" && (!w.opts.Models[w.cursor[wizardModel]].Installed || w.setupStatus[SetupModel] != "") {
+			line(SetupModel, "model")
+		}
+
+		if w.finished && w.failed {
+			b.WriteString("\n" + placeholderStyle.Render("rekord offers to install what failed again when it starts."))
+		}
+		if w.finished {
+			b.WriteString("\n" + setupDoneStyle.Render("Settings will be saved to "+w.opts.ConfigPath))
+			help = "enter: save • esc: back • q: cancel"
+		} else {
+			b.WriteString("\n" + placeholderStyle.Render("Installing, this can take a few minutes..."))
+			help = "installing..."
+		}
+	}
+
+	if w.error != "" {
+		b.WriteString("\n\n" + setupMissingStyle.Render(w.error))
+	}
+	b.WriteString("\n\n" + helpStyle.Render(help) + "\n")
+	return tea.NewView(b.String())
+}
+
+// defaultEntry labels the default device entry with the device's name
+func defaultEntry(label, name string) string {
+	if name == "" {
+		return label
+	}
+	return label + " " + placeholderStyle.Render("("+name+")")
+}
+
+// deviceEntry labels a device entry with its description and name
+func deviceEntry(d WizardDevice) string {
+	if d.Description == "" || d.Description == d.Name {
+		return d.Name
+	}
+	return d.Description + " " + placeholderStyle.Render("("+d.Name+")")
+}
+
+// orDefault returns device, or "default" for the default device
+func orDefault(device string) string {
+	if device == "" {
+		return "default"
+	}
+	return device
+}
@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// WorkspaceMsg delivers a message to one workspace of a Workspaces model
+type WorkspaceMsg struct {
+	Index int
+	Msg   tea.Msg
+}
+
+// Workspaces shows independent recording sessions, one Model per
+// workspace, with a bar to switch between them. Keys go to the workspace
+// shown; messages for a workspace must be wrapped in a WorkspaceMsg.
+type Workspaces struct {
+	names  []string
+	models []Model
+	active int
+}
+
+// NewWorkspaces creates the view of the workspaces with the given names
+// and models
+func NewWorkspaces(names []string, models []Model) Workspaces {
+	return Workspaces{names: names, models: models}
+}
+
+// Init implements tea.Model
+func (w Workspaces) Init() tea.Cmd {
+	cmds := make([]tea.Cmd, len(w.models))
+	for i, m := range w.models {
+		cmds[i] = forWorkspace(i, m.Init())
+	}
+	return tea.Batch(cmds...)
+}
+
+// Update implements tea.Model
+func (w Workspaces) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// The workspace bar takes the top line
+		msg.Height--
+		cmds := make([]tea.Cmd, len(w.models))
+		for i := range w.models {
+			cmds[i] = w.update(i, msg)
+		}
+		return w, tea.Batch(cmds...)
+
+	case tea.KeyPressMsg:
+		switch k := msg.String(); {
+		case k == "ctrl+n":
+			w.active = (w.active + 1) % len(w.models)
+			return w, nil
+		case k == "ctrl+p":
+			w.active = (w.active + len(w.models) - 1) % len(w.models)
+			return w, nil
+		case len(k) == 5 && strings.HasPrefix(k, "alt+") && k[4] >= '1' && int(k[4]-'1') < len(w.models):
+			w.active = int(k[4] - '1')
+			return w, nil
+		}
+		return w, w.update(w.active, msg)
+
+	case WorkspaceMsg:
+		switch inner := msg.Msg.(type) {
+		case tea.QuitMsg:
+			// Quitting any workspace ends the program
+			return w, tea.Quit
+		case tea.BatchMsg:
+			cmds := make([]tea.Cmd, len(inner))
+			for i, cmd := range inner {
+				cmds[i] = forWorkspace(msg.Index, cmd)
+			}
+			return w, tea.Batch(cmds...)
+		}
+		if msg.Index < 0 || msg.Index >= len(w.models) {
+			return w, nil
+		}
+		return w, w.update(msg.Index, msg.Msg)
+	}
+	return w, w.update(w.active, msg)
+}
+
+// update passes msg to the model of workspace i, tagging the messages its
+// commands produce with the workspace
+func (w *Workspaces) update(i int, msg tea.Msg) tea.Cmd {
+	model, cmd := w.models[i].Update(msg)
+	w.models[i] = model.(Model)
+	return forWorkspace(i, cmd)
+}
+
+// forWorkspace wraps the message produced by cmd in a WorkspaceMsg for
+// workspace i
+func forWorkspace(i int, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		msg := cmd()
+		if msg == nil {
+			return nil
+		}
+		return WorkspaceMsg{Index: i, Msg: msg}
+	}
+}
+
+// View implements tea.Model
+func (w Workspaces) View() tea.View {
+	v := w.models[w.active].View()
+	// The mini view stays a single line
+	if w.models[w.active].mini {
+		return v
+	}
+	v.Content = w.renderBar() + "\n" + v.Content
+	return v
+}
+
+// renderBar renders the workspace bar, marking workspaces that record
+func (w Workspaces) renderBar() string {
+	tabs := make([]string, len(w.models))
+	for i, m := range w.models {
+		label := fmt.Sprintf("%d %s", i+1, w.names[i])
+		if m.isRecording {
+			label += " ●"
+		}
+		if i == w.active {
+			tabs[i] = activeTabStyle.Render(label)
+		} else {
+			tabs[i] = inactiveTabStyle.Render(label)
+		}
+	}
+	bar := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+	return bar + helpStyle.UnsetPadding().Render("  alt+1-9/ctrl+n: switch workspace")
+}
@@ -0,0 +1,48 @@
+package rekord
+
+import (
+	"github.com/exler/rekord/internal/diarize"
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// NewWhisperCLI creates a Backend running whisper-cli from whisper.cpp with
+// the model at modelPath for each chunk. The executable is found like the
+// rekord command finds it, via WHISPER_PATH or PATH.
+func NewWhisperCLI(modelPath, language string) (Backend, error) {
+	whisper, err := transcriber.NewWhisperCLI(modelPath, transcriber.WhisperOptions{Language: language})
+	if err != nil {
+		return nil, err
+	}
+	return whisper, nil
+}
+
+// NewRemote creates a Backend transcribing on a rekord serve server at
+// addr (host:port or an http(s) URL)
+func NewRemote(addr string) Backend {
+	return transcriber.NewRemoteClient(addr)
+}
+
+// NewFake creates a Backend returning canned phrases for each 2 seconds of
+// sound, for tests
+func NewFake(language string) Backend {
+	return transcriber.NewFake(language)
+}
+
+// NewHallucinationFilter creates the filter for segments whisper commonly
+// makes up in the given language, for Config.Filter
+func NewHallucinationFilter(language string) *HallucinationFilter {
+	return transcriber.NewHallucinationFilter(language)
+}
+
+// NewDiarizer creates a Diarizer that tells voices apart by a lightweight
+// spectral embedding of each segment, clustered as segments arrive. Up to
+// maxSpeakers speakers are told apart (0 for no limit); threshold is the
+// embedding distance above which a voice counts as a new speaker, 0 for
+// the default.
+func NewDiarizer(maxSpeakers int, threshold float64) Diarizer {
+	c := diarize.NewClusterer(maxSpeakers)
+	if threshold > 0 {
+		c.Threshold = threshold
+	}
+	return c
+}
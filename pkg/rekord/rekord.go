@@ -0,0 +1,1009 @@
+// Package rekord embeds rekord's live transcription in other Go programs. A
+// Session captures system audio and optionally a microphone, cuts the audio
+// into chunks, transcribes them with a Backend and delivers the finished
+// segments in order on a channel:
+//
+//	s, err := rekord.New(rekord.Config{Backend: backend})
+//	if err != nil {
+//		return err
+//	}
+//	defer s.Close()
+//	if err := s.Start(); err != nil {
+//		return err
+//	}
+//	go func() {
+//		time.Sleep(time.Minute)
+//		s.Stop()
+//	}()
+//	for seg := range s.Segments() {
+//		fmt.Println(seg.Text)
+//	}
+package rekord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/exler/rekord/internal/audio"
+	"github.com/exler/rekord/internal/logging"
+	"github.com/exler/rekord/internal/stats"
+	"github.com/exler/rekord/internal/transcriber"
+)
+
+// Types of the pipeline, shared with the packages implementing it
+type (
+	// Segment is a piece of transcribed text with its time in the recording
+	Segment = transcriber.Segment
+
+	// Backend transcribes chunks of 16kHz mono audio
+	Backend = transcriber.Backend
+
+	// Chunk is a piece of audio handed to the Backend
+	Chunk = transcriber.Chunk
+
+	// Result is the outcome of transcribing a Chunk
+	Result = transcriber.Result
+
+	// HallucinationFilter drops segments the backend made up, e.g. from
+	// silence
+	HallucinationFilter = transcriber.HallucinationFilter
+
+	// Replacements correct the text of segments
+	Replacements = transcriber.Replacements
+
+	// SourceRunner starts the capture of a device, see Config.Runner
+	SourceRunner = audio.SourceRunner
+
+	// SourceProcess is the running capture of a device
+	SourceProcess = audio.SourceProcess
+
+	// DeviceResolver picks the device a lost source is restarted on
+	DeviceResolver = audio.DeviceResolver
+
+	// RestartHandler is notified about sources that were lost and restarted
+	RestartHandler = audio.RestartHandler
+)
+
+const (
+	// SampleRate is the sample rate of all audio in the pipeline
+	SampleRate = audio.SampleRate
+
+	// SilenceSearchSamples is how far back from the end of the buffer smart
+	// chunking looks for a quiet point to cut at
+	SilenceSearchSamples = SampleRate * 3 / 2
+
+	// MaxChunkSamples is the longest chunk cut from a buffer that fell
+	// behind
+	MaxChunkSamples = SampleRate * 30
+
+	// chunkInterval is how often buffered audio is cut into chunks
+	chunkInterval = 5 * time.Second
+
+	// bufferWarnInterval limits how often Hooks.Dropped is called
+	bufferWarnInterval = 30 * time.Second
+)
+
+// Config configures a Session. Only Backend is required.
+type Config struct {
+	// Device is the system audio device to capture, empty for the default
+	// monitor. Mic is the microphone to capture along with it, empty for
+	// none.
+	Device string
+	Mic    string
+
+	// Backend transcribes the audio. It is shared, not closed by the Session.
+	Backend Backend
+
+	// Workers is how many chunks are transcribed in parallel (default 1)
+	Workers int
+
+	// StereoSplit captures Device in stereo and transcribes the left and
+	// right channels as separate sources
+	StereoSplit bool
+
+	// SmartChunks cuts chunks at the quietest point near their end instead
+	// of mid-word
+	SmartChunks bool
+
+	// BufferMemory is how many MB of audio per source wait in memory while
+	// transcription falls behind (default 64). With BufferSpill, the rest
+	// waits in a temporary file; otherwise the oldest audio is dropped.
+	BufferMemory int
+	BufferSpill  bool
+
+	// StallTimeout restarts sources that deliver no audio for this long, 0
+	// to never restart them for that
+	StallTimeout time.Duration
+
+	// Filter drops hallucinated segments, Corrections fix up their text and
+	// Redact masks personal data in them. Filter may be nil.
+	Filter      *HallucinationFilter
+	Corrections Replacements
+	Redact      bool
+
+	// Runner starts the capture of devices, nil to run the capture program
+	// of the build's audio backend (e.g. parec). Replays and test fakes
+	// plug in here.
+	Runner SourceRunner
+
+	// Resolve picks the device to restart a lost source on, nil to retry
+	// the same device
+	Resolve DeviceResolver
+
+	// Diarizer labels the segments of the system audio with the speaker
+	// who spoke them, nil to leave them labeled System. Segments of the
+	// microphone and of StereoSplit channels are not diarized.
+	Diarizer Diarizer
+
+	Hooks Hooks
+}
+
+// Diarizer tells apart the voices of the system audio
+type Diarizer interface {
+	// Speaker returns the label of whoever speaks in 16kHz mono samples,
+	// e.g. "Speaker 2", or "" if it cannot tell. It is called for each
+	// segment in recording order.
+	Speaker(samples []float32) string
+}
+
+// Hooks are optional callbacks about the running pipeline. They are called
+// from its goroutines and should return quickly.
+type Hooks struct {
+	// Audio receives the mixed audio as it is captured, e.g. for a level
+	// meter
+	Audio func(samples []float32)
+
+	// Restart is notified about sources that were lost and restarted
+	Restart RestartHandler
+
+	// Ended is called when a source has no more audio, such as a replay
+	// that reached its end. The recording must still be stopped.
+	Ended func()
+
+	// Dropped warns that audio is lost because transcription fell too far
+	// behind, at most every 30 seconds
+	Dropped func(err error)
+
+	// ChunkStarted is called when the backend starts on a chunk, and Result
+	// when it is done, before the chunk's segments are delivered
+	ChunkStarted func(Chunk)
+	Result       func(Result)
+
+	// Filtered reports a segment dropped or changed by post-processing. text
+	// is what was kept, empty if the segment was dropped.
+	Filtered func(original Segment, text, reason string)
+}
+
+// Session runs the transcription pipeline. A session records any number of
+// times, one recording at a time; segment times continue across recordings.
+type Session struct {
+	cfg    Config
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Captured devices, guarded by devMu since the capture callbacks read
+	// them while mu is held to stop the capture
+	device string
+	mic    string
+	devMu  sync.Mutex
+
+	mu       sync.Mutex // guards the recording state below
+	capture  *audio.MultiCapture
+	queue    *transcriber.Queue
+	segments chan Segment
+	stop     chan struct{}
+	done     chan struct{}
+
+	talk        audio.TalkTime
+	attribution audio.Attribution // sources of the mixed audio buffer
+
+	// The current or last recording, and earlier ones whose remaining
+	// audio is still being transcribed, guarded by bufferMu
+	rec          *recording
+	draining     map[*recording]bool
+	bufferWarned time.Time // when Hooks.Dropped was last called
+	bufferMu     sync.Mutex
+
+	// closed is closed by Close, which waits for the goroutines of all
+	// recordings in wg
+	closed    chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+
+	// Counters for Stats over all recordings of the session
+	captureBytes atomic.Int64
+	dropped      atomic.Int64
+
+	// Guards the post-processing of results and the delivery state of the
+	// recordings, as the last recording may still be transcribed while the
+	// next one runs
+	resultMu sync.Mutex
+}
+
+// recording is the state of one recording. It lives on after Stop until its
+// remaining audio is transcribed, while the next recording runs with its
+// own.
+type recording struct {
+	// Audio waiting to be transcribed, guarded by Session.bufferMu
+	buffers  map[string]*audio.Buffer // keyed by segment source label, "" for mixed audio
+	carried  map[string]int           // samples at the start of each buffer already sent in the previous chunk
+	consumed map[string]int           // samples of each source cut from the front of its buffer so far
+
+	// Chunks are numbered in the order they start and their segments held
+	// back until the chunks started before them are done, since parallel
+	// workers can finish out of order. The rest is guarded by
+	// Session.resultMu.
+	seq     atomic.Uint64
+	nextSeq uint64
+	held    map[uint64][]heldSegment
+	last    map[string]Segment // last segment delivered per source, for trimming the chunk overlap
+
+	// Segments in order, waiting for deliver to send them on segments so
+	// that no worker blocks on the channel while holding resultMu. wake is
+	// signalled when more are ready or the recording finished.
+	segments chan Segment
+	ready    []Segment
+	finished bool
+	wake     chan struct{}
+}
+
+// New creates a session. With an empty Config.Device, it captures the
+// default monitor.
+func New(cfg Config) (*Session, error) {
+	if cfg.Backend == nil {
+		return nil, errors.New("a transcription backend is required")
+	}
+	if cfg.Device == "" {
+		monitor, err := audio.GetDefaultMonitorSource()
+		if err != nil {
+			return nil, fmt.Errorf("finding the default audio monitor: %w", err)
+		}
+		cfg.Device = monitor
+	}
+	cfg.Workers = max(cfg.Workers, 1)
+	if cfg.BufferMemory <= 0 {
+		cfg.BufferMemory = 64
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Session{
+		cfg:      cfg,
+		ctx:      ctx,
+		cancel:   cancel,
+		device:   cfg.Device,
+		mic:      cfg.Mic,
+		draining: make(map[*recording]bool),
+		closed:   make(chan struct{}),
+	}
+	s.rec = s.newRecording()
+	return s, nil
+}
+
+// NewReplayRunner returns a Runner that replays 16kHz mono samples at
+// real-time speed in place of every device. The source ends with the
+// samples, calling Hooks.Ended.
+func NewReplayRunner(samples []float32) SourceRunner {
+	return audio.NewReplayRunner(samples)
+}
+
+// ReadWAV reads a WAV file as 16kHz mono samples, e.g. for NewReplayRunner
+func ReadWAV(path string) ([]float32, error) {
+	return audio.ReadWAV(path)
+}
+
+// SetDevices changes the captured devices for the next recording. An empty
+// mic captures no microphone.
+func (s *Session) SetDevices(device, mic string) {
+	s.devMu.Lock()
+	defer s.devMu.Unlock()
+	s.device, s.mic = device, mic
+}
+
+// Devices returns the captured system audio device and microphone, which
+// follow sources restarted on another device
+func (s *Session) Devices() (device, mic string) {
+	s.devMu.Lock()
+	defer s.devMu.Unlock()
+	return s.device, s.mic
+}
+
+// Start starts a recording. Its segments are delivered on the channel
+// returned by Segments.
+func (s *Session) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return errors.New("already recording")
+	}
+	select {
+	case <-s.closed:
+		return errors.New("session closed")
+	default:
+	}
+
+	device, mic := s.Devices()
+	devices := []string{device}
+	if mic != "" {
+		devices = append(devices, mic)
+	}
+	capture, err := audio.NewMultiCapture(devices, s.onAudio)
+	if err != nil {
+		return fmt.Errorf("failed to create audio capture: %w", err)
+	}
+	if s.cfg.Runner != nil {
+		capture.SetSourceRunner(s.cfg.Runner)
+	}
+	if s.cfg.StereoSplit {
+		capture.SetStereo(0)
+	}
+	capture.SetChannelHandler(s.onChannel)
+	if s.cfg.Resolve != nil {
+		capture.SetDeviceResolver(s.cfg.Resolve)
+	}
+	capture.SetRestartHandler(s.onRestart)
+	capture.SetReplayEndHandler(s.cfg.Hooks.Ended)
+	capture.SetStallTimeout(s.cfg.StallTimeout)
+	capture.SetByteCounter(&s.captureBytes)
+
+	// Audio left over from the last recording stays with it until it is
+	// transcribed; this one continues the timeline after it. It is
+	// installed before capture starts so that no new audio lands in the
+	// last one.
+	s.bufferMu.Lock()
+	prev := s.rec
+	rec := s.newRecording()
+	rec.segments = make(chan Segment)
+	rec.wake = make(chan struct{}, 1)
+	s.rec = rec
+	s.bufferMu.Unlock()
+	if err := capture.Start(); err != nil {
+		s.bufferMu.Lock()
+		s.rec = prev
+		for _, buf := range rec.buffers {
+			buf.Close()
+		}
+		s.bufferMu.Unlock()
+		return fmt.Errorf("failed to start audio capture: %w", err)
+	}
+	s.wg.Add(2)
+	go s.deliver(rec)
+
+	// Chunks are transcribed in order by parallel workers
+	queue := transcriber.NewQueue(s.ctx, s.cfg.Backend, s.cfg.Workers, func(r Result) {
+		s.handleResult(rec, r)
+	})
+	if s.cfg.Hooks.ChunkStarted != nil {
+		queue.SetStartHandler(s.cfg.Hooks.ChunkStarted)
+	}
+	queue.SetSequence(&rec.seq)
+	queue.SetDropCounter(&s.dropped)
+
+	s.capture, s.queue, s.segments = capture, queue, rec.segments
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.chunkLoop(rec, queue, s.stop, s.done)
+	return nil
+}
+
+// newRecording returns the state of a recording whose audio continues the
+// timeline after the current one. Must be called with bufferMu held.
+func (s *Session) newRecording() *recording {
+	rec := &recording{
+		buffers:  map[string]*audio.Buffer{"": s.newBuffer()},
+		carried:  make(map[string]int),
+		consumed: make(map[string]int),
+		nextSeq:  1,
+		held:     make(map[uint64][]heldSegment),
+		last:     make(map[string]Segment),
+	}
+	if s.rec != nil {
+		for label, buf := range s.rec.buffers {
+			rec.consumed[label] = s.rec.consumed[label] + buf.Len()
+		}
+	}
+	return rec
+}
+
+// Segments returns the channel the segments of the current or last
+// recording are delivered on, in order. It must be received from until it
+// is closed, which happens after Stop once the remaining audio is
+// transcribed, or right away on Close. It is nil before the first Start.
+func (s *Session) Segments() <-chan Segment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.segments
+}
+
+// Recording reports whether a recording is running
+func (s *Session) Recording() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stop != nil
+}
+
+// Stop stops the recording. The audio captured so far is still transcribed
+// in the background; the Segments channel is closed once it is done.
+func (s *Session) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop == nil {
+		return nil
+	}
+
+	close(s.stop)
+	s.stop = nil
+	err := s.capture.Stop()
+	if err != nil {
+		err = fmt.Errorf("failed to stop audio capture: %w", err)
+	}
+
+	select {
+	case <-s.done:
+	case <-time.After(2 * time.Second):
+		logging.Warn("Chunking did not finish in time")
+	}
+
+	queue := s.queue
+	s.bufferMu.Lock()
+	rec := s.rec
+	s.draining[rec] = true
+	s.bufferMu.Unlock()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.processRemainingAudio(rec, queue)
+		queue.Close()
+		s.finish(rec)
+	}()
+	return err
+}
+
+// SwitchDevice moves capture from the device old, the system audio device
+// or the microphone, over to device. While recording, the audio continues
+// in the same buffers after a short gap.
+func (s *Session) SwitchDevice(old, device string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		if err := s.capture.SwitchDevice(old, device); err != nil {
+			return err
+		}
+	}
+	s.devMu.Lock()
+	defer s.devMu.Unlock()
+	switch old {
+	case s.device:
+		s.device = device
+	case s.mic:
+		s.mic = device
+	}
+	return nil
+}
+
+// Position returns how much audio has been captured so far, the offset new
+// segments are timed against
+func (s *Session) Position() time.Duration {
+	s.bufferMu.Lock()
+	defer s.bufferMu.Unlock()
+	var samples int
+	for label, buf := range s.rec.buffers {
+		samples = max(samples, s.rec.consumed[label]+buf.Len())
+	}
+	return time.Duration(samples) * time.Second / SampleRate
+}
+
+// Stats are counters of the pipeline of a session, e.g. for monitoring
+type Stats struct {
+	// CaptureBytes is how much raw audio the capture programs delivered
+	CaptureBytes int64
+	// QueuedChunks is how many chunks wait for a transcription worker
+	QueuedChunks int
+	// DroppedSamples is how much audio was lost because transcription fell
+	// too far behind
+	DroppedSamples int64
+}
+
+// Stats returns the counters of all recordings of the session so far
+func (s *Session) Stats() Stats {
+	s.mu.Lock()
+	queue := s.queue
+	s.mu.Unlock()
+
+	stats := Stats{
+		CaptureBytes:   s.captureBytes.Load(),
+		DroppedSamples: s.dropped.Load(),
+	}
+	if queue != nil {
+		stats.QueuedChunks = queue.Len()
+	}
+	return stats
+}
+
+// TalkTime returns how long the microphone and the system audio carried
+// speech so far. Without a microphone, everything counts as them.
+func (s *Session) TalkTime() (you, them time.Duration) {
+	return s.talk.Totals()
+}
+
+// Close stops the recording, cancels running transcriptions and discards
+// the audio not transcribed yet, also that of recordings stopped earlier. It
+// waits for the pipeline to wind down and closes the Segments channels.
+// The session cannot record again afterwards.
+func (s *Session) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		close(s.closed)
+		s.mu.Unlock()
+
+		s.cancel()
+		err = s.Stop()
+
+		s.bufferMu.Lock()
+		live := maps.Clone(s.draining)
+		live[s.rec] = true
+		for rec := range live {
+			for _, buf := range rec.buffers {
+				buf.Close()
+			}
+		}
+		s.bufferMu.Unlock()
+		s.wg.Wait()
+	})
+	return err
+}
+
+// onAudio passes the mixed captured audio to the hook
+func (s *Session) onAudio(samples []float32) {
+	if s.cfg.Hooks.Audio != nil {
+		s.cfg.Hooks.Audio(samples)
+	}
+}
+
+// onChannel measures talk time per source and buffers the audio, each
+// channel separately when splitting speakers by stereo channel and mixed
+// otherwise
+func (s *Session) onChannel(device string, channel int, samples []float32) {
+	s.devMu.Lock()
+	mic := device == s.mic
+	s.devMu.Unlock()
+
+	s.talk.Add(mic, channel, samples)
+	switch {
+	case !s.cfg.StereoSplit && mic:
+		s.bufferAudio("", audio.SourceMic, samples)
+	case !s.cfg.StereoSplit:
+		s.bufferAudio("", audio.SourceSystem, samples)
+	case mic:
+		s.bufferAudio(audio.SourceMic, "", samples)
+	case channel == 0:
+		s.bufferAudio("Left", "", samples)
+	default:
+		s.bufferAudio("Right", "", samples)
+	}
+}
+
+// onRestart tracks sources restarted on another device
+func (s *Session) onRestart(oldDevice, newDevice string, err error) {
+	if err == nil {
+		s.devMu.Lock()
+		switch oldDevice {
+		case s.device:
+			s.device = newDevice
+		case s.mic:
+			s.mic = newDevice
+		}
+		s.devMu.Unlock()
+	}
+	if s.cfg.Hooks.Restart != nil {
+		s.cfg.Hooks.Restart(oldDevice, newDevice, err)
+	}
+}
+
+// newBuffer returns an empty buffer for one source
+func (s *Session) newBuffer() *audio.Buffer {
+	return audio.NewBuffer(s.cfg.BufferMemory<<20/4, s.cfg.BufferSpill)
+}
+
+// bufferAudio appends captured samples to the buffer with the given label
+// and warns when audio is lost because transcription fell too far behind.
+// Samples for the mixed buffer name the source they came from, so its
+// segments can be attributed.
+func (s *Session) bufferAudio(label, source string, samples []float32) {
+	s.bufferMu.Lock()
+	rec := s.rec
+	buf := rec.buffers[label]
+	if buf == nil {
+		buf = s.newBuffer()
+		rec.buffers[label] = buf
+	}
+	if label == "" {
+		s.attribution.Add(source, samples)
+	}
+	dropped, err := buf.Append(samples)
+	if dropped > 0 {
+		s.dropped.Add(int64(dropped))
+		// Dropped audio is still counted so later chunks keep their times
+		rec.consumed[label] += dropped
+		rec.carried[label] = max(rec.carried[label]-dropped, 0)
+	}
+	warn := (dropped > 0 || err != nil) && time.Since(s.bufferWarned) >= bufferWarnInterval
+	if warn {
+		s.bufferWarned = time.Now()
+	}
+	s.bufferMu.Unlock()
+
+	switch {
+	case err != nil:
+		logging.Error("Failed to buffer audio: %v", err)
+		err = fmt.Errorf("failed to buffer audio: %w", err)
+	case dropped > 0:
+		logging.Warn("Audio buffer %q is full, dropped %.1fs of audio", label, float64(dropped)/SampleRate)
+		err = fmt.Errorf("audio buffer %q is full, dropped %.1fs of audio", label, float64(dropped)/SampleRate)
+	}
+	if warn && s.cfg.Hooks.Dropped != nil {
+		s.cfg.Hooks.Dropped(err)
+	}
+}
+
+// chunkLoop periodically queues the buffered audio of rec for transcription
+// until stop is closed
+func (s *Session) chunkLoop(rec *recording, queue *transcriber.Queue, stop <-chan struct{}, done chan<- struct{}) {
+	defer s.wg.Done()
+	defer close(done)
+
+	ticker := time.NewTicker(chunkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			logging.Debug("Chunk loop received stop signal")
+			return
+		case <-ticker.C:
+			s.processAudioBuffers(rec, queue)
+		}
+	}
+}
+
+// processAudioBuffers queues the audio buffers of rec for transcription
+func (s *Session) processAudioBuffers(rec *recording, queue *transcriber.Queue) {
+	for _, label := range s.bufferLabels(rec) {
+		// While the backend is behind, the audio waits in the bounded buffer
+		// rather than piling up in the queue
+		if queue.Waiting(label) {
+			continue
+		}
+		// Need at least 3 seconds, keep last 2 seconds for context
+		chunk, ok := s.takeBuffer(rec, label, SampleRate*3, SampleRate*2)
+		if !ok {
+			continue
+		}
+		logging.Debug("Queueing audio buffer %q: %d samples", label, len(chunk.Samples))
+		queue.Push(chunk)
+	}
+}
+
+// processRemainingAudio queues whatever audio of rec is left after it stops
+func (s *Session) processRemainingAudio(rec *recording, queue *transcriber.Queue) {
+	for _, label := range s.bufferLabels(rec) {
+		// Need at least 1 second. A backlog is cut into several chunks, each
+		// waiting for the previous one so they are not merged again.
+		for {
+			chunk, ok := s.takeBuffer(rec, label, SampleRate, 0)
+			if !ok {
+				break
+			}
+			queue.Push(chunk)
+			for queue.Waiting(label) {
+				time.Sleep(100 * time.Millisecond)
+			}
+		}
+	}
+}
+
+// finish closes the buffers of a stopped recording once its remaining audio
+// is queued and transcribed, and lets deliver close its channel
+func (s *Session) finish(rec *recording) {
+	s.bufferMu.Lock()
+	for label, buf := range rec.buffers {
+		// What is left is too short to transcribe but still counts, so the
+		// next recording's times follow on
+		rec.consumed[label] += buf.Len()
+		buf.Close()
+	}
+	delete(s.draining, rec)
+	s.bufferMu.Unlock()
+
+	s.resultMu.Lock()
+	rec.finished = true
+	s.resultMu.Unlock()
+	rec.signal()
+}
+
+// bufferLabels returns the labels of the audio buffers of rec in a stable
+// order
+func (s *Session) bufferLabels(rec *recording) []string {
+	s.bufferMu.Lock()
+	defer s.bufferMu.Unlock()
+	return slices.Sorted(maps.Keys(rec.buffers))
+}
+
+// takeBuffer cuts a chunk from the buffer of rec with the given label if it
+// holds at least minSamples, keeping the last keepSamples before the cut for
+// context. The chunk records how many leading samples were already part of
+// the previous chunk. It returns false if there is not enough audio yet.
+func (s *Session) takeBuffer(rec *recording, label string, minSamples, keepSamples int) (Chunk, bool) {
+	s.bufferMu.Lock()
+	defer s.bufferMu.Unlock()
+
+	buffer := rec.buffers[label]
+	if buffer == nil || buffer.Len() < minSamples {
+		return Chunk{}, false
+	}
+	// A backlog is transcribed in chunks of at most MaxChunkSamples
+	buf := buffer.Front()
+	buf = buf[:min(len(buf), MaxChunkSamples)]
+
+	// Cut at the quietest point near the end rather than mid-word. Audio
+	// after the cut stays buffered for the next chunk.
+	cut := len(buf)
+	if s.cfg.SmartChunks && keepSamples > 0 {
+		from := max(len(buf)-SilenceSearchSamples, keepSamples+audio.FrameSize)
+		cut = audio.FindQuietestPoint(buf, from, len(buf))
+	}
+
+	samples := make([]float32, cut)
+	copy(samples, buf[:cut])
+
+	// Buffered audio was captured before now, which the queue wait and lag
+	// should include
+	behind := time.Duration(buffer.Len()-cut) * time.Second / SampleRate
+	chunk := Chunk{
+		Source:  label,
+		Samples: samples,
+		Overlap: rec.carried[label],
+		Offset:  time.Duration(rec.consumed[label]) * time.Second / SampleRate,
+		Queued:  time.Now().Add(-behind),
+	}
+	if drop := cut - keepSamples; drop > 0 {
+		if err := buffer.Discard(drop); err != nil {
+			logging.Error("Failed to read buffered audio: %v", err)
+		}
+		rec.carried[label] = keepSamples
+		rec.consumed[label] += drop
+		if label == "" {
+			// Chunks still being transcribed need their part of the mix,
+			// including those of earlier recordings that are still draining
+			before := rec.consumed[label]
+			for d := range s.draining {
+				before = min(before, d.consumed[label])
+			}
+			s.attribution.Trim(before - (s.cfg.Workers+1)*MaxChunkSamples)
+		}
+	}
+	return chunk, true
+}
+
+// handleResult post-processes a transcribed chunk of rec and queues its
+// segments for delivery
+func (s *Session) handleResult(rec *recording, r Result) {
+	timing := stats.ChunkTiming{
+		Source:   r.Chunk.Source,
+		Audio:    time.Duration(len(r.Chunk.Samples)) * time.Second / SampleRate,
+		Queue:    r.Wait,
+		Whisper:  r.Took,
+		Segments: len(r.Segments),
+		Failed:   r.Err != nil,
+	}
+	stats.Log(timing)
+	if r.Err != nil {
+		logging.Error("Transcription failed: %v", r.Err)
+	}
+	if s.cfg.Hooks.Result != nil {
+		s.cfg.Hooks.Result(r)
+	}
+
+	// Changes by post-processing are reported once resultMu is released,
+	// since the hook may block on a busy UI
+	var changes []filtered
+	s.resultMu.Lock()
+	var kept []heldSegment
+	if r.Err == nil {
+		kept = s.filterSegments(r.Chunk, r.Segments, &changes)
+	}
+
+	// A failed chunk still releases its place. Segments are diarized here,
+	// once they are in recording order.
+	for _, held := range rec.reorder(r.Chunk.Seq, kept) {
+		seg := held.Segment
+		if held.audio != nil {
+			if speaker := s.cfg.Diarizer.Speaker(held.audio); speaker != "" {
+				seg.Source = speaker
+			}
+		}
+		logging.Debug("New segment: %s", seg.Text)
+		if seg, ok := s.trimOverlap(rec, seg, &changes); ok {
+			rec.ready = append(rec.ready, seg)
+		}
+	}
+	s.resultMu.Unlock()
+	rec.signal()
+
+	for _, f := range changes {
+		s.reportFiltered(f)
+	}
+}
+
+// deliver sends the segments of rec on its channel as they become ready and
+// closes it once the recording finished or the session is closed
+func (s *Session) deliver(rec *recording) {
+	defer s.wg.Done()
+	defer close(rec.segments)
+	for {
+		s.resultMu.Lock()
+		ready, finished := rec.ready, rec.finished
+		rec.ready = nil
+		s.resultMu.Unlock()
+
+		for _, seg := range ready {
+			select {
+			case rec.segments <- seg:
+			case <-s.closed:
+				return
+			}
+		}
+		if len(ready) == 0 {
+			if finished {
+				return
+			}
+			select {
+			case <-rec.wake:
+			case <-s.closed:
+				return
+			}
+		}
+	}
+}
+
+// signal wakes deliver without blocking
+func (rec *recording) signal() {
+	select {
+	case rec.wake <- struct{}{}:
+	default:
+	}
+}
+
+// reorder takes the segments of the chunk with sequence number seq and
+// returns the segments that are ready to be delivered, in chunk order: none
+// if an earlier chunk is still outstanding, otherwise these and those of any
+// later chunks that were held back. Sequence numbers start at 1 and each
+// must be passed exactly once, with no segments if the chunk failed.
+func (rec *recording) reorder(seq uint64, segments []heldSegment) []heldSegment {
+	if seq < rec.nextSeq {
+		// Not numbered by a queue, nothing to wait for
+		return segments
+	}
+	rec.held[seq] = segments
+
+	var ready []heldSegment
+	for {
+		segs, ok := rec.held[rec.nextSeq]
+		if !ok {
+			return ready
+		}
+		delete(rec.held, rec.nextSeq)
+		rec.nextSeq++
+		ready = append(ready, segs...)
+	}
+}
+
+// heldSegment is a post-processed segment waiting for its turn to be
+// delivered
+type heldSegment struct {
+	Segment
+	// audio is what the segment was transcribed from, for the Diarizer; nil
+	// if it is not diarized
+	audio []float32
+}
+
+// filtered is a segment dropped or changed by post-processing. text is what
+// was kept, empty if the segment was dropped.
+type filtered struct {
+	original     Segment
+	text, reason string
+}
+
+// filterSegments post-processes the segments of a transcribed chunk and
+// moves their times from the chunk to the recording. The segments it drops
+// or changes are added to changes.
+func (s *Session) filterSegments(chunk Chunk, segments []Segment, changes *[]filtered) []heldSegment {
+	var kept []heldSegment
+	for _, seg := range segments {
+		seg.Source = chunk.Source
+		var reason string
+		if s.cfg.Filter != nil {
+			reason = s.cfg.Filter.Reason(seg, chunk.Samples, SampleRate)
+		}
+		from, to := samplesAt(seg.StartTime), samplesAt(seg.EndTime)
+		seg.Shift(chunk.Offset)
+		if chunk.Source == "" {
+			// Mixed audio is attributed to the source that talked most
+			seg.Source = s.attribution.Dominant(samplesAt(seg.StartTime), samplesAt(seg.EndTime))
+		}
+		if reason != "" {
+			*changes = append(*changes, filtered{seg, "", reason})
+			continue
+		}
+		var samples []float32
+		if s.cfg.Diarizer != nil && chunk.Source == "" && seg.Source != audio.SourceMic {
+			from, to = min(max(from, 0), len(chunk.Samples)), min(max(to, 0), len(chunk.Samples))
+			samples = chunk.Samples[from:max(from, to)]
+		}
+		if text := s.cfg.Corrections.Apply(seg.Text); text != seg.Text {
+			*changes = append(*changes, filtered{seg, text, "replaced"})
+			seg.SetText(text)
+			if text == "" {
+				continue
+			}
+		}
+		if s.cfg.Redact {
+			if text, n := transcriber.Redact(seg.Text); n > 0 {
+				logging.Info("Redacted %d item(s) in segment", n)
+				seg.SetText(text)
+			}
+		}
+		kept = append(kept, heldSegment{Segment: seg, audio: samples})
+	}
+	return kept
+}
+
+// reportFiltered passes a segment changed by post-processing to the hook.
+// It must not be called with resultMu held.
+func (s *Session) reportFiltered(f filtered) {
+	// Unredacted text must not reach the log or the hook either
+	if s.cfg.Redact {
+		f.original.Text, _ = transcriber.Redact(f.original.Text)
+		f.text, _ = transcriber.Redact(f.text)
+	}
+	if f.text == "" {
+		logging.Info("Dropped segment (%s): %s", f.reason, f.original.Text)
+	}
+	if s.cfg.Hooks.Filtered != nil {
+		s.cfg.Hooks.Filtered(f.original, f.text, f.reason)
+	}
+}
+
+// trimOverlap trims the words repeated from the previous segment of the same
+// buffer of rec because of the chunk overlap, adding the change to changes.
+// It returns false if nothing is left.
+func (s *Session) trimOverlap(rec *recording, seg Segment, changes *[]filtered) (Segment, bool) {
+	// Segments of the mixed buffer follow each other whatever source they
+	// were attributed to
+	key := ""
+	if s.cfg.StereoSplit {
+		key = seg.Source
+	}
+	if prev, ok := rec.last[key]; ok {
+		if trimmed := transcriber.TrimOverlap(prev.Text, seg.Text); trimmed != seg.Text {
+			*changes = append(*changes, filtered{seg, trimmed, "overlap"})
+			seg.SetText(trimmed)
+		}
+	}
+	if seg.Text == "" {
+		return seg, false
+	}
+	rec.last[key] = seg
+	return seg, true
+}
+
+// samplesAt converts a time in the recorded audio to a sample position
+func samplesAt(d time.Duration) int {
+	return int(d * SampleRate / time.Second)
+}
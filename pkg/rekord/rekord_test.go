@@ -0,0 +1,190 @@
+package rekord_test
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/exler/rekord/internal/transcriber"
+	"github.com/exler/rekord/internal/transcript"
+	"github.com/exler/rekord/pkg/rekord"
+)
+
+// tone returns d of a quiet sine wave, which the fake backend hears as sound
+func tone(d time.Duration) []float32 {
+	samples := make([]float32, int(d*rekord.SampleRate/time.Second))
+	for i := range samples {
+		samples[i] = 0.1 * float32(math.Sin(2*math.Pi*440*float64(i)/rekord.SampleRate))
+	}
+	return samples
+}
+
+// silence returns d of silence
+func silence(d time.Duration) []float32 {
+	return make([]float32, int(d*rekord.SampleRate/time.Second))
+}
+
+// newReplaySession returns a session replaying samples through the fake
+// backend, and a channel closed when the replay reached its end
+func newReplaySession(t *testing.T, samples []float32, workers int) (*rekord.Session, <-chan struct{}) {
+	t.Helper()
+	ended := make(chan struct{})
+	s, err := rekord.New(rekord.Config{
+		Device:  "replay",
+		Backend: transcriber.NewFake("en"),
+		Workers: workers,
+		Runner:  rekord.NewReplayRunner(samples),
+		Hooks: rekord.Hooks{
+			Ended: func() { close(ended) },
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, ended
+}
+
+// drain receives the segments of the recording until the channel is closed
+func drain(t *testing.T, segments <-chan rekord.Segment) []rekord.Segment {
+	t.Helper()
+	var got []rekord.Segment
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case seg, ok := <-segments:
+			if !ok {
+				return got
+			}
+			got = append(got, seg)
+		case <-timeout:
+			t.Fatalf("segments not closed in time, got %d so far", len(got))
+		}
+	}
+}
+
+func TestReplayTranscript(t *testing.T) {
+	// Shorter than the chunk interval, so the whole replay is transcribed
+	// as one chunk after Stop and the fake's phrases follow the pieces of
+	// two seconds: sound, silence, sound
+	samples := append(append(tone(2*time.Second), silence(2*time.Second)...), tone(time.Second/2)...)
+	s, ended := newReplaySession(t, samples, 2)
+
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	segments := s.Segments()
+	select {
+	case <-ended:
+	case <-time.After(10 * time.Second):
+		t.Fatal("replay did not end")
+	}
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	got := drain(t, segments)
+
+	want := []struct {
+		text       string
+		start, end time.Duration
+	}{
+		{"Good morning, everyone.", 0, 2 * time.Second},
+		{"The release is planned for Friday.", 4 * time.Second, 4500 * time.Millisecond},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d segments, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		seg := got[i]
+		// With the system audio as the only source, segments are unlabeled
+		if seg.Text != w.text || seg.StartTime != w.start || seg.EndTime != w.end || seg.Source != "" {
+			t.Errorf("segment %d = %q %s-%s from %q, want %q %s-%s", i, seg.Text, seg.StartTime, seg.EndTime, seg.Source, w.text, w.start, w.end)
+		}
+	}
+
+	var srt strings.Builder
+	if err := transcript.WriteSRT(&srt, got, nil); err != nil {
+		t.Fatalf("WriteSRT: %v", err)
+	}
+	wantSRT := `1
+00:00:00,000 --> 00:00:02,000
+Good morning, everyone.
+
+2
+00:00:04,000 --> 00:00:04,500
+The release is planned for Friday.
+
+`
+	if srt.String() != wantSRT {
+		t.Errorf("SRT export:\n%s\nwant:\n%s", srt.String(), wantSRT)
+	}
+}
+
+func TestCloseEndsSegments(t *testing.T) {
+	s, _ := newReplaySession(t, tone(time.Minute), 1)
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	segments := s.Segments()
+
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+	drain(t, segments)
+	select {
+	case <-closed:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Close did not return")
+	}
+	if err := s.Start(); err == nil {
+		t.Error("Start after Close succeeded")
+	}
+}
+
+func TestBlockedFilteredHookDoesNotHoldBackSegments(t *testing.T) {
+	// The hook of a UI too busy to take the report blocks until released
+	release := make(chan struct{})
+	defer close(release)
+	ended := make(chan struct{})
+	s, err := rekord.New(rekord.Config{
+		Device:  "replay",
+		Backend: transcriber.NewFake("en"),
+		Workers: 1,
+		Runner:  rekord.NewReplayRunner(tone(time.Second)),
+		Corrections: rekord.Replacements{
+			{Pattern: regexp.MustCompile("everyone"), Replacement: "team"},
+		},
+		Hooks: rekord.Hooks{
+			Ended:    func() { close(ended) },
+			Filtered: func(rekord.Segment, string, string) { <-release },
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	segments := s.Segments()
+	select {
+	case <-ended:
+	case <-time.After(10 * time.Second):
+		t.Fatal("replay did not end")
+	}
+	go s.Stop()
+
+	select {
+	case seg := <-segments:
+		if seg.Text != "Good morning, team." {
+			t.Errorf("segment = %q, want the corrected text", seg.Text)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("segment held back while the Filtered hook blocks")
+	}
+}